@@ -0,0 +1,212 @@
+package cqrs
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CheckpointTracker holds back the checkpoint of a projection until it has reported the events of a position.
+type CheckpointTracker interface {
+	Begin() uint64
+	Complete(position uint64, projections ...string)
+	Report(projection string, position uint64)
+	Checkpoint(projection string) uint64
+	Wait(ctx context.Context, projection string, position uint64) error
+}
+
+type CheckpointTrackerOption func(c *checkpointTracker)
+
+// WithPendingTimeout releases positions whose events are never reported.
+func WithPendingTimeout(timeout time.Duration) CheckpointTrackerOption {
+	return func(c *checkpointTracker) {
+		c.pendingTimeout = timeout
+	}
+}
+
+func NewCheckpointTracker(opts ...CheckpointTrackerOption) CheckpointTracker {
+	checkpointTracker := &checkpointTracker{
+		pendingTimeout: time.Minute,
+		inFlight:       make(map[uint64]struct{}),
+		pending:        make(map[string]map[uint64]*pendingPosition),
+		changed:        make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(checkpointTracker)
+	}
+
+	return checkpointTracker
+}
+
+type pendingPosition struct {
+	count     int
+	expiresAt time.Time
+}
+
+type checkpointTracker struct {
+	mu             sync.Mutex
+	last           uint64
+	pendingTimeout time.Duration
+	inFlight       map[uint64]struct{}
+	pending        map[string]map[uint64]*pendingPosition
+	changed        chan struct{}
+}
+
+func (c *checkpointTracker) Begin() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.last++
+	c.inFlight[c.last] = struct{}{}
+
+	return c.last
+}
+
+func (c *checkpointTracker) Complete(position uint64, projections ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.inFlight[position]; !ok {
+		return
+	}
+
+	delete(c.inFlight, position)
+
+	for _, projection := range projections {
+		c.add(projection, position, 1)
+	}
+
+	now := time.Now()
+
+	for projection, positions := range c.pending {
+		if pendingPosition, ok := positions[position]; ok {
+			if pendingPosition.count <= 0 {
+				delete(positions, position)
+			} else {
+				pendingPosition.expiresAt = now.Add(c.pendingTimeout)
+			}
+		}
+
+		c.checkpoint(projection, now)
+	}
+
+	c.notify()
+}
+
+func (c *checkpointTracker) Report(projection string, position uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.inFlight[position]; !ok {
+		if _, ok := c.pending[projection][position]; !ok {
+			return
+		}
+	}
+
+	c.add(projection, position, -1)
+
+	c.notify()
+}
+
+func (c *checkpointTracker) Checkpoint(projection string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	checkpoint, _ := c.checkpoint(projection, time.Now())
+
+	return checkpoint
+}
+
+func (c *checkpointTracker) Wait(ctx context.Context, projection string, position uint64) error {
+	for {
+		c.mu.Lock()
+		checkpoint, expiresAt := c.checkpoint(projection, time.Now())
+		changed := c.changed
+		c.mu.Unlock()
+
+		if checkpoint >= position {
+			return nil
+		}
+
+		if err := c.wait(ctx, changed, expiresAt); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *checkpointTracker) wait(ctx context.Context, changed chan struct{}, expiresAt time.Time) error {
+	var expired <-chan time.Time
+	if !expiresAt.IsZero() {
+		timer := time.NewTimer(time.Until(expiresAt))
+		defer timer.Stop()
+
+		expired = timer.C
+	}
+
+	select {
+	case <-changed:
+		return nil
+	case <-expired:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *checkpointTracker) add(projection string, position uint64, delta int) {
+	positions, ok := c.pending[projection]
+	if !ok {
+		positions = make(map[uint64]*pendingPosition)
+		c.pending[projection] = positions
+	}
+
+	pending, ok := positions[position]
+	if !ok {
+		pending = &pendingPosition{}
+		positions[position] = pending
+	}
+
+	pending.count += delta
+
+	if _, ok := c.inFlight[position]; !ok && pending.count <= 0 {
+		delete(positions, position)
+	}
+
+	if len(positions) == 0 {
+		delete(c.pending, projection)
+	}
+}
+
+func (c *checkpointTracker) checkpoint(projection string, now time.Time) (uint64, time.Time) {
+	lowest := c.last + 1
+	var expiresAt time.Time
+
+	positions := c.pending[projection]
+	for position, pendingPosition := range positions {
+		if _, ok := c.inFlight[position]; ok || pendingPosition.count <= 0 {
+			continue
+		}
+
+		if !now.Before(pendingPosition.expiresAt) {
+			delete(positions, position)
+			continue
+		}
+
+		if position < lowest {
+			lowest = position
+			expiresAt = pendingPosition.expiresAt
+		}
+	}
+
+	if len(positions) == 0 {
+		delete(c.pending, projection)
+	}
+
+	return lowest - 1, expiresAt
+}
+
+func (c *checkpointTracker) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
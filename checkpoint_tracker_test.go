@@ -0,0 +1,181 @@
+package cqrs_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vulpes-ferrilata/cqrs"
+)
+
+func Test_checkpointTracker_Checkpoint(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		prepare func(checkpointTracker cqrs.CheckpointTracker)
+		want    uint64
+	}{
+		{
+			name:    "initial",
+			prepare: func(checkpointTracker cqrs.CheckpointTracker) {},
+			want:    0,
+		},
+		{
+			name: "command in flight",
+			prepare: func(checkpointTracker cqrs.CheckpointTracker) {
+				checkpointTracker.Begin()
+			},
+			want: 1,
+		},
+		{
+			name: "command completed without events",
+			prepare: func(checkpointTracker cqrs.CheckpointTracker) {
+				position := checkpointTracker.Begin()
+				checkpointTracker.Complete(position)
+			},
+			want: 1,
+		},
+		{
+			name: "events reported before completion",
+			prepare: func(checkpointTracker cqrs.CheckpointTracker) {
+				position := checkpointTracker.Begin()
+				checkpointTracker.Report("projection", position)
+				checkpointTracker.Complete(position, "projection")
+			},
+			want: 1,
+		},
+		{
+			name: "events not reported yet",
+			prepare: func(checkpointTracker cqrs.CheckpointTracker) {
+				position := checkpointTracker.Begin()
+				checkpointTracker.Complete(position, "projection")
+			},
+			want: 0,
+		},
+		{
+			name: "events reported after completion",
+			prepare: func(checkpointTracker cqrs.CheckpointTracker) {
+				position := checkpointTracker.Begin()
+				checkpointTracker.Complete(position, "projection", "projection")
+				checkpointTracker.Report("projection", position)
+				checkpointTracker.Report("projection", position)
+			},
+			want: 1,
+		},
+		{
+			name: "events expected by other projection",
+			prepare: func(checkpointTracker cqrs.CheckpointTracker) {
+				position := checkpointTracker.Begin()
+				checkpointTracker.Complete(position, "other-projection")
+			},
+			want: 1,
+		},
+		{
+			name: "later command completed before earlier one",
+			prepare: func(checkpointTracker cqrs.CheckpointTracker) {
+				checkpointTracker.Begin()
+				position := checkpointTracker.Begin()
+				checkpointTracker.Complete(position)
+			},
+			want: 2,
+		},
+		{
+			name: "earlier command of other projection not reported",
+			prepare: func(checkpointTracker cqrs.CheckpointTracker) {
+				position := checkpointTracker.Begin()
+				checkpointTracker.Complete(position, "other-projection")
+				position = checkpointTracker.Begin()
+				checkpointTracker.Complete(position)
+			},
+			want: 2,
+		},
+		{
+			name: "earlier command of projection not reported",
+			prepare: func(checkpointTracker cqrs.CheckpointTracker) {
+				position := checkpointTracker.Begin()
+				checkpointTracker.Complete(position, "projection")
+				position = checkpointTracker.Begin()
+				checkpointTracker.Complete(position)
+			},
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkpointTracker := cqrs.NewCheckpointTracker()
+
+			tt.prepare(checkpointTracker)
+
+			got := checkpointTracker.Checkpoint("projection")
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_checkpointTracker_Wait(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		opts    []cqrs.CheckpointTrackerOption
+		prepare func(checkpointTracker cqrs.CheckpointTracker)
+		wantErr error
+	}{
+		{
+			name: "projection caught up",
+			prepare: func(checkpointTracker cqrs.CheckpointTracker) {
+				position := checkpointTracker.Begin()
+				checkpointTracker.Complete(position)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "projection catches up while waiting",
+			prepare: func(checkpointTracker cqrs.CheckpointTracker) {
+				position := checkpointTracker.Begin()
+				checkpointTracker.Complete(position, "projection")
+
+				go func() {
+					time.Sleep(10 * time.Millisecond)
+					checkpointTracker.Report("projection", position)
+				}()
+			},
+			wantErr: nil,
+		},
+		{
+			name: "projection never catches up",
+			prepare: func(checkpointTracker cqrs.CheckpointTracker) {
+				position := checkpointTracker.Begin()
+				checkpointTracker.Complete(position, "projection")
+			},
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name: "pending position expires while waiting",
+			opts: []cqrs.CheckpointTrackerOption{
+				cqrs.WithPendingTimeout(10 * time.Millisecond),
+			},
+			prepare: func(checkpointTracker cqrs.CheckpointTracker) {
+				position := checkpointTracker.Begin()
+				checkpointTracker.Complete(position, "projection")
+			},
+			wantErr: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkpointTracker := cqrs.NewCheckpointTracker(tt.opts...)
+
+			tt.prepare(checkpointTracker)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			err := checkpointTracker.Wait(ctx, "projection", 1)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
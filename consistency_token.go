@@ -0,0 +1,35 @@
+package cqrs
+
+import "sync"
+
+type ConsistencyToken interface {
+	Version() uint64
+	Observe(version uint64)
+}
+
+func NewConsistencyToken(version uint64) ConsistencyToken {
+	return &consistencyToken{
+		version: version,
+	}
+}
+
+type consistencyToken struct {
+	mu      sync.RWMutex
+	version uint64
+}
+
+func (c *consistencyToken) Version() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.version
+}
+
+func (c *consistencyToken) Observe(version uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if version > c.version {
+		c.version = version
+	}
+}
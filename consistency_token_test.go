@@ -0,0 +1,49 @@
+package cqrs_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vulpes-ferrilata/cqrs"
+)
+
+func Test_consistencyToken_Version(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		prepare func(consistencyToken cqrs.ConsistencyToken)
+		want    uint64
+	}{
+		{
+			name:    "initial",
+			prepare: func(consistencyToken cqrs.ConsistencyToken) {},
+			want:    2,
+		},
+		{
+			name: "newer version observed",
+			prepare: func(consistencyToken cqrs.ConsistencyToken) {
+				consistencyToken.Observe(3)
+			},
+			want: 3,
+		},
+		{
+			name: "older version observed",
+			prepare: func(consistencyToken cqrs.ConsistencyToken) {
+				consistencyToken.Observe(1)
+			},
+			want: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consistencyToken := cqrs.NewConsistencyToken(2)
+
+			tt.prepare(consistencyToken)
+
+			got := consistencyToken.Version()
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -14,3 +14,81 @@ func GetEventProvider(ctx context.Context) (EventProvider, bool) {
 	eventProvider, ok := ctx.Value(eventProviderKey{}).(EventProvider)
 	return eventProvider, ok
 }
+
+type consistencyTokenKey struct{}
+
+func WithConsistencyToken(ctx context.Context, consistencyToken ConsistencyToken) context.Context {
+	return context.WithValue(ctx, consistencyTokenKey{}, consistencyToken)
+}
+
+func GetConsistencyToken(ctx context.Context) (ConsistencyToken, bool) {
+	consistencyToken, ok := ctx.Value(consistencyTokenKey{}).(ConsistencyToken)
+	return consistencyToken, ok
+}
+
+type correlationIDKey struct{}
+
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, correlationID)
+}
+
+func GetCorrelationID(ctx context.Context) (string, bool) {
+	correlationID, ok := ctx.Value(correlationIDKey{}).(string)
+	return correlationID, ok
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+func GetPrincipal(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+type idempotencyKeyKey struct{}
+
+func WithIdempotencyKey(ctx context.Context, idempotencyKey string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyKey{}, idempotencyKey)
+}
+
+func GetIdempotencyKey(ctx context.Context) (string, bool) {
+	idempotencyKey, ok := ctx.Value(idempotencyKeyKey{}).(string)
+	return idempotencyKey, ok && idempotencyKey != ""
+}
+
+type idempotencyOutcomeKey struct{}
+
+func WithIdempotencyOutcome(ctx context.Context, idempotencyOutcome IdempotencyOutcome) context.Context {
+	return context.WithValue(ctx, idempotencyOutcomeKey{}, idempotencyOutcome)
+}
+
+func GetIdempotencyOutcome(ctx context.Context) (IdempotencyOutcome, bool) {
+	idempotencyOutcome, ok := ctx.Value(idempotencyOutcomeKey{}).(IdempotencyOutcome)
+	return idempotencyOutcome, ok && idempotencyOutcome != nil
+}
+
+type eventIDKey struct{}
+
+func WithEventID(ctx context.Context, eventID string) context.Context {
+	return context.WithValue(ctx, eventIDKey{}, eventID)
+}
+
+func GetEventID(ctx context.Context) (string, bool) {
+	eventID, ok := ctx.Value(eventIDKey{}).(string)
+	return eventID, ok && eventID != ""
+}
+
+type eventHandlerNameKey struct{}
+
+func withEventHandlerName(ctx context.Context, eventHandlerName string) context.Context {
+	return context.WithValue(ctx, eventHandlerNameKey{}, eventHandlerName)
+}
+
+// GetEventHandlerName returns the name the event handler being dispatched was registered with.
+func GetEventHandlerName(ctx context.Context) (string, bool) {
+	eventHandlerName, ok := ctx.Value(eventHandlerNameKey{}).(string)
+	return eventHandlerName, ok && eventHandlerName != ""
+}
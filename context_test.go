@@ -57,3 +57,254 @@ func TestGetEventProvider(t *testing.T) {
 		})
 	}
 }
+
+func TestGetConsistencyToken(t *testing.T) {
+	t.Parallel()
+
+	var (
+		consistencyToken = cqrs.NewConsistencyToken(1)
+	)
+
+	type wants struct {
+		consistencyToken cqrs.ConsistencyToken
+		ok               bool
+	}
+	tests := []struct {
+		name    string
+		prepare func() context.Context
+		wants   wants
+	}{
+		{
+			name: "no consistency token injected into context",
+			prepare: func() context.Context {
+				return context.Background()
+			},
+			wants: wants{
+				consistencyToken: nil,
+				ok:               false,
+			},
+		},
+		{
+			name: "consistency token injected into context",
+			prepare: func() context.Context {
+				ctx := context.Background()
+				ctx = cqrs.WithConsistencyToken(ctx, consistencyToken)
+				return ctx
+			},
+			wants: wants{
+				consistencyToken: consistencyToken,
+				ok:               true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tt.prepare()
+			got, ok := cqrs.GetConsistencyToken(ctx)
+			assert.Equal(t, tt.wants.consistencyToken, got)
+			assert.Equal(t, tt.wants.ok, ok)
+		})
+	}
+}
+
+func TestGetCorrelationID(t *testing.T) {
+	t.Parallel()
+
+	type wants struct {
+		correlationID string
+		ok            bool
+	}
+	tests := []struct {
+		name    string
+		prepare func() context.Context
+		wants   wants
+	}{
+		{
+			name: "no correlation id injected into context",
+			prepare: func() context.Context {
+				return context.Background()
+			},
+			wants: wants{
+				correlationID: "",
+				ok:            false,
+			},
+		},
+		{
+			name: "correlation id injected into context",
+			prepare: func() context.Context {
+				ctx := context.Background()
+				ctx = cqrs.WithCorrelationID(ctx, "correlation-id")
+				return ctx
+			},
+			wants: wants{
+				correlationID: "correlation-id",
+				ok:            true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tt.prepare()
+			got, ok := cqrs.GetCorrelationID(ctx)
+			assert.Equal(t, tt.wants.correlationID, got)
+			assert.Equal(t, tt.wants.ok, ok)
+		})
+	}
+}
+
+type user string
+
+func (u user) ID() string {
+	return string(u)
+}
+
+func TestGetPrincipal(t *testing.T) {
+	t.Parallel()
+
+	var (
+		principal = user("user-id")
+	)
+
+	type wants struct {
+		principal cqrs.Principal
+		ok        bool
+	}
+	tests := []struct {
+		name    string
+		prepare func() context.Context
+		wants   wants
+	}{
+		{
+			name: "no principal injected into context",
+			prepare: func() context.Context {
+				return context.Background()
+			},
+			wants: wants{
+				principal: nil,
+				ok:        false,
+			},
+		},
+		{
+			name: "principal injected into context",
+			prepare: func() context.Context {
+				ctx := context.Background()
+				ctx = cqrs.WithPrincipal(ctx, principal)
+				return ctx
+			},
+			wants: wants{
+				principal: principal,
+				ok:        true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tt.prepare()
+			got, ok := cqrs.GetPrincipal(ctx)
+			assert.Equal(t, tt.wants.principal, got)
+			assert.Equal(t, tt.wants.ok, ok)
+		})
+	}
+}
+
+func TestGetIdempotencyKey(t *testing.T) {
+	t.Parallel()
+
+	type wants struct {
+		idempotencyKey string
+		ok             bool
+	}
+	tests := []struct {
+		name    string
+		prepare func() context.Context
+		wants   wants
+	}{
+		{
+			name: "no idempotency key injected into context",
+			prepare: func() context.Context {
+				return context.Background()
+			},
+			wants: wants{
+				idempotencyKey: "",
+				ok:             false,
+			},
+		},
+		{
+			name: "empty idempotency key injected into context",
+			prepare: func() context.Context {
+				ctx := context.Background()
+				ctx = cqrs.WithIdempotencyKey(ctx, "")
+				return ctx
+			},
+			wants: wants{
+				idempotencyKey: "",
+				ok:             false,
+			},
+		},
+		{
+			name: "idempotency key injected into context",
+			prepare: func() context.Context {
+				ctx := context.Background()
+				ctx = cqrs.WithIdempotencyKey(ctx, "idempotency-key")
+				return ctx
+			},
+			wants: wants{
+				idempotencyKey: "idempotency-key",
+				ok:             true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tt.prepare()
+			got, ok := cqrs.GetIdempotencyKey(ctx)
+			assert.Equal(t, tt.wants.idempotencyKey, got)
+			assert.Equal(t, tt.wants.ok, ok)
+		})
+	}
+}
+
+func TestGetEventID(t *testing.T) {
+	t.Parallel()
+
+	type wants struct {
+		eventID string
+		ok      bool
+	}
+	tests := []struct {
+		name    string
+		prepare func() context.Context
+		wants   wants
+	}{
+		{
+			name: "no event id injected into context",
+			prepare: func() context.Context {
+				return context.Background()
+			},
+			wants: wants{
+				eventID: "",
+				ok:      false,
+			},
+		},
+		{
+			name: "event id injected into context",
+			prepare: func() context.Context {
+				ctx := context.Background()
+				ctx = cqrs.WithEventID(ctx, "event-id")
+				return ctx
+			},
+			wants: wants{
+				eventID: "event-id",
+				ok:      true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tt.prepare()
+			got, ok := cqrs.GetEventID(ctx)
+			assert.Equal(t, tt.wants.eventID, got)
+			assert.Equal(t, tt.wants.ok, ok)
+		})
+	}
+}
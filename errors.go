@@ -11,6 +11,7 @@ var (
 	ErrHandlerResultMustBeError                             = errors.New("hander result must be error")
 	ErrCommandAlreadyRegistered                             = errors.New("command already registered")
 	ErrCommandHasNotRegisteredYet                           = errors.New("command has not registered yet")
+	ErrEventHandlerAlreadyRegistered                        = errors.New("event handler already registered")
 )
 
 var (
@@ -21,5 +22,13 @@ var (
 )
 
 var (
-	ErrEventProviderNotFound = errors.New("event provider not found")
+	ErrEventProviderNotFound         = errors.New("event provider not found")
+	ErrProjectionNotCaughtUp         = errors.New("projection has not caught up yet")
+	ErrConcurrencyConflict           = errors.New("concurrency conflict")
+	ErrRateLimited                   = errors.New("rate limited")
+	ErrCircuitOpen                   = errors.New("circuit open")
+	ErrAccessDenied                  = errors.New("access denied")
+	ErrIdempotencyKeyConflict        = errors.New("idempotency key conflict")
+	ErrEventHandlerNotNamed          = errors.New("event handler must be registered with a name")
+	ErrNamedEventHandlerNotSupported = errors.New("named event handler not supported")
 )
@@ -15,19 +15,31 @@ type EventBus interface {
 	Dispatch(ctx context.Context, events []interface{}) error
 }
 
+// NamedEventBus is implemented by EventBuses able to register a handler under a name unique for the event
+// and stable across restarts.
+type NamedEventBus interface {
+	EventBus
+	RegisterNamed(name string, handler interface{}) error
+}
+
 func NewEventBus() EventBus {
 	return &eventBus{
 		middlewares: make([]EventMiddlewareFunc, 0),
-		handlers:    make(map[reflect.Type][]EventHandlerFunc[any]),
+		handlers:    make(map[reflect.Type][]eventHandler),
 	}
 }
 
 type eventBus struct {
 	middlewares []EventMiddlewareFunc
-	handlers    map[reflect.Type][]EventHandlerFunc[any]
+	handlers    map[reflect.Type][]eventHandler
 	mu          sync.RWMutex
 }
 
+type eventHandler struct {
+	name    string
+	handler EventHandlerFunc[any]
+}
+
 func (c *eventBus) validate(handler interface{}) error {
 	handlerVal := reflect.ValueOf(handler)
 
@@ -90,6 +102,10 @@ func (c *eventBus) Use(middlewares ...EventMiddlewareFunc) {
 }
 
 func (c *eventBus) Register(handler interface{}) error {
+	return c.RegisterNamed("", handler)
+}
+
+func (c *eventBus) RegisterNamed(name string, handler interface{}) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -100,7 +116,18 @@ func (c *eventBus) Register(handler interface{}) error {
 	handlerType := reflect.TypeOf(handler)
 	eventType := handlerType.In(1)
 
-	c.handlers[eventType] = append(c.handlers[eventType], c.wrapHandler(handler))
+	if name != "" {
+		for _, eventHandler := range c.handlers[eventType] {
+			if eventHandler.name == name {
+				return ErrEventHandlerAlreadyRegistered
+			}
+		}
+	}
+
+	c.handlers[eventType] = append(c.handlers[eventType], eventHandler{
+		name:    name,
+		handler: c.wrapHandler(handler),
+	})
 
 	return nil
 }
@@ -120,15 +147,16 @@ func (c *eventBus) Dispatch(ctx context.Context, events []interface{}) error {
 			continue
 		}
 
-		for _, handler := range handlers {
-			handler := handler
+		for _, eventHandler := range handlers {
+			handler := eventHandler.handler
+			handlerCtx := withEventHandlerName(ctx, eventHandler.name)
 
 			for i := len(c.middlewares) - 1; i >= 0; i-- {
 				handler = c.middlewares[i](handler)
 			}
 
 			wg.Go(func() error {
-				if err := handler(ctx, event); err != nil {
+				if err := handler(handlerCtx, event); err != nil {
 					return err
 				}
 
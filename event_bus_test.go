@@ -3,6 +3,7 @@ package cqrs_test
 import (
 	"context"
 	"errors"
+	"sort"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -196,3 +197,42 @@ func Test_eventBus_Dispatch(t *testing.T) {
 		})
 	}
 }
+
+func Test_eventBus_Dispatch_EventHandlerName(t *testing.T) {
+	t.Parallel()
+
+	eventBus, ok := cqrs.NewEventBus().(cqrs.NamedEventBus)
+	assert.True(t, ok)
+
+	names := make(chan string, 3)
+	newHandler := func() func(ctx context.Context, event Event) error {
+		return func(ctx context.Context, event Event) error {
+			name, ok := cqrs.GetEventHandlerName(ctx)
+			if ok {
+				names <- name
+			}
+			return nil
+		}
+	}
+
+	err := eventBus.RegisterNamed("first", newHandler())
+	assert.NoError(t, err)
+	err = eventBus.RegisterNamed("second", newHandler())
+	assert.NoError(t, err)
+	err = eventBus.RegisterNamed("first", newHandler())
+	assert.ErrorIs(t, err, cqrs.ErrEventHandlerAlreadyRegistered)
+	err = eventBus.Register(newHandler())
+	assert.NoError(t, err)
+
+	err = eventBus.Dispatch(context.Background(), []interface{}{Event{}})
+	assert.NoError(t, err)
+	close(names)
+
+	got := make([]string, 0)
+	for name := range names {
+		got = append(got, name)
+	}
+	sort.Strings(got)
+	// handlers registered without a name have none.
+	assert.Equal(t, []string{"first", "second"}, got)
+}
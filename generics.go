@@ -18,6 +18,20 @@ func RegisterEventHandler[Event any](eventBus EventBus, handler EventHandlerFunc
 	return nil
 }
 
+// RegisterNamedEventHandler requires the EventBus to implement NamedEventBus.
+func RegisterNamedEventHandler[Event any](eventBus EventBus, name string, handler EventHandlerFunc[Event]) error {
+	namedEventBus, ok := eventBus.(NamedEventBus)
+	if !ok {
+		return ErrNamedEventHandlerNotSupported
+	}
+
+	if err := namedEventBus.RegisterNamed(name, handler); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func RegisterQueryHandler[Query any, Result any](queryBus QueryBus, handler QueryHandlerFunc[Query, Result]) error {
 	if err := queryBus.Register(handler); err != nil {
 		return err
@@ -134,6 +134,81 @@ func TestRegisterEventHandler(t *testing.T) {
 	}
 }
 
+func TestRegisterNamedEventHandler(t *testing.T) {
+	t.Parallel()
+
+	var (
+		Err = errors.New("error")
+	)
+
+	type mocks struct {
+		eventBus *mock_cqrs.MockNamedEventBus
+	}
+	type args struct {
+		handler cqrs.EventHandlerFunc[Event]
+	}
+	tests := []struct {
+		name    string
+		prepare func(mocks mocks, args args)
+		args    args
+		wantErr error
+	}{
+		{
+			name: "register handler return error",
+			prepare: func(mocks mocks, args args) {
+				mocks.eventBus.EXPECT().RegisterNamed("handler", gomock.AssignableToTypeOf(args.handler)).Return(Err)
+			},
+			args: args{
+				handler: func(ctx context.Context, event Event) error {
+					return nil
+				},
+			},
+			wantErr: Err,
+		},
+		{
+			name: "success",
+			prepare: func(mocks mocks, args args) {
+				mocks.eventBus.EXPECT().RegisterNamed("handler", gomock.AssignableToTypeOf(args.handler)).Return(nil)
+			},
+			args: args{
+				handler: func(ctx context.Context, event Event) error {
+					return nil
+				},
+			},
+			wantErr: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := mocks{
+				eventBus: mock_cqrs.NewMockNamedEventBus(mockCtrl),
+			}
+
+			tt.prepare(mocks, tt.args)
+
+			err := cqrs.RegisterNamedEventHandler(mocks.eventBus, "handler", tt.args.handler)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestRegisterNamedEventHandler_NotSupported(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	eventBus := mock_cqrs.NewMockEventBus(mockCtrl)
+
+	err := cqrs.RegisterNamedEventHandler(eventBus, "handler", func(ctx context.Context, event Event) error {
+		return nil
+	})
+	assert.ErrorIs(t, err, cqrs.ErrNamedEventHandlerNotSupported)
+}
+
 func TestRegisterQueryHandler(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,45 @@
+package cqrs
+
+import (
+	"sync"
+	"time"
+)
+
+// IdempotencyOutcome tells the caller whether an idempotent command has been replayed.
+type IdempotencyOutcome interface {
+	Replayed() bool
+	ExecutedAt() time.Time
+	Observe(replayed bool, executedAt time.Time)
+}
+
+func NewIdempotencyOutcome() IdempotencyOutcome {
+	return &idempotencyOutcome{}
+}
+
+type idempotencyOutcome struct {
+	mu         sync.RWMutex
+	replayed   bool
+	executedAt time.Time
+}
+
+func (i *idempotencyOutcome) Replayed() bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return i.replayed
+}
+
+func (i *idempotencyOutcome) ExecutedAt() time.Time {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return i.executedAt
+}
+
+func (i *idempotencyOutcome) Observe(replayed bool, executedAt time.Time) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.replayed = replayed
+	i.executedAt = executedAt
+}
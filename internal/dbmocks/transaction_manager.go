@@ -0,0 +1,57 @@
+package dbmocks
+
+import (
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+	mock_db "github.com/vulpes-ferrilata/cqrs/pkg/db/mocks"
+)
+
+// TransactionManager implements every optional interface of the db package.
+type TransactionManager struct {
+	*mock_db.MockTransactionManager[*gorm.DB]
+	*mock_db.MockTransactionSuspender
+	*mock_db.MockNestedTransactionManager
+	*mock_db.MockTransactionRetrier
+	*mock_db.MockReadOnlyTransactionManager
+	*mock_db.MockConfigurableTransactionManager
+}
+
+type Mocks struct {
+	TransactionManager             *mock_db.MockTransactionManager[*gorm.DB]
+	TransactionSuspender           *mock_db.MockTransactionSuspender
+	NestedTransactionManager       *mock_db.MockNestedTransactionManager
+	TransactionRetrier             *mock_db.MockTransactionRetrier
+	ReadOnlyTransactionManager     *mock_db.MockReadOnlyTransactionManager
+	ConfigurableTransactionManager *mock_db.MockConfigurableTransactionManager
+	Committer                      *mock_db.MockCommitter
+}
+
+func NewMocks(mockCtrl *gomock.Controller) Mocks {
+	return Mocks{
+		TransactionManager:             mock_db.NewMockTransactionManager[*gorm.DB](mockCtrl),
+		TransactionSuspender:           mock_db.NewMockTransactionSuspender(mockCtrl),
+		NestedTransactionManager:       mock_db.NewMockNestedTransactionManager(mockCtrl),
+		TransactionRetrier:             mock_db.NewMockTransactionRetrier(mockCtrl),
+		ReadOnlyTransactionManager:     mock_db.NewMockReadOnlyTransactionManager(mockCtrl),
+		ConfigurableTransactionManager: mock_db.NewMockConfigurableTransactionManager(mockCtrl),
+		Committer:                      mock_db.NewMockCommitter(mockCtrl),
+	}
+}
+
+// Build returns a TransactionManager implementing the optional interfaces of the db package when supported.
+func (m Mocks) Build(supported bool) db.TransactionManager[*gorm.DB] {
+	if !supported {
+		return m.TransactionManager
+	}
+
+	return &TransactionManager{
+		MockTransactionManager:             m.TransactionManager,
+		MockTransactionSuspender:           m.TransactionSuspender,
+		MockNestedTransactionManager:       m.NestedTransactionManager,
+		MockTransactionRetrier:             m.TransactionRetrier,
+		MockReadOnlyTransactionManager:     m.ReadOnlyTransactionManager,
+		MockConfigurableTransactionManager: m.ConfigurableTransactionManager,
+	}
+}
@@ -0,0 +1,51 @@
+// Package testdb opens in-memory SQLite databases for tests.
+package testdb
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/glebarez/go-sqlite"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// NewGorm opens a database migrated for the models, it is closed when the test ends.
+func NewGorm(t testing.TB, models ...interface{}) *gorm.DB {
+	t.Helper()
+
+	gormDB, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	sqlDB, err := gormDB.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	// every connection to an in-memory database opens a new database.
+	sqlDB.SetMaxOpenConns(1)
+
+	err = gormDB.AutoMigrate(models...)
+	require.NoError(t, err)
+
+	return gormDB
+}
+
+// NewSQL opens a database on which the statements have run, it is closed when the test ends.
+func NewSQL(t testing.TB, statements ...string) *sql.DB {
+	t.Helper()
+
+	sqlDB, err := sql.Open("sqlite", "file::memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	// every connection to an in-memory database opens a new database.
+	sqlDB.SetMaxOpenConns(1)
+
+	for _, statement := range statements {
+		_, err = sqlDB.Exec(statement)
+		require.NoError(t, err)
+	}
+
+	return sqlDB
+}
@@ -0,0 +1,159 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/vulpes-ferrilata/cqrs"
+	"github.com/vulpes-ferrilata/cqrs/pkg/authorization"
+)
+
+type AuthorizationError struct {
+	MessageType string
+	Reason      string
+}
+
+func (a AuthorizationError) Error() string {
+	return fmt.Sprintf("%s denied: %s", a.MessageType, a.Reason)
+}
+
+func (a AuthorizationError) Unwrap() error {
+	return cqrs.ErrAccessDenied
+}
+
+type AuthorizationOption func(a *AuthorizationMiddleware)
+
+func WithPolicy(message interface{}, policy authorization.Policy) AuthorizationOption {
+	return func(a *AuthorizationMiddleware) {
+		messageType := reflect.TypeOf(message)
+		a.policies[messageType] = append(a.policies[messageType], policy)
+	}
+}
+
+// WithMarkerPolicy applies the policy to every message implementing the marker interface M,
+// it panics if M is not an interface.
+func WithMarkerPolicy[M any](policy authorization.Policy) AuthorizationOption {
+	marker := reflect.TypeOf((*M)(nil)).Elem()
+	if marker.Kind() != reflect.Interface {
+		panic(fmt.Sprintf("marker policy requires an interface, got %s", marker))
+	}
+
+	return func(a *AuthorizationMiddleware) {
+		a.markerPolicies = append(a.markerPolicies, markerPolicy{
+			marker: marker,
+			policy: policy,
+		})
+	}
+}
+
+func WithStrictAuthorization() AuthorizationOption {
+	return func(a *AuthorizationMiddleware) {
+		a.strict = true
+	}
+}
+
+func NewAuthorizationMiddleware(opts ...AuthorizationOption) *AuthorizationMiddleware {
+	authorizationMiddleware := &AuthorizationMiddleware{
+		policies: make(map[reflect.Type][]authorization.Policy),
+	}
+
+	for _, opt := range opts {
+		opt(authorizationMiddleware)
+	}
+
+	return authorizationMiddleware
+}
+
+// AuthorizationMiddleware requires every policy of the message and its marker interfaces to allow it.
+type AuthorizationMiddleware struct {
+	policies       map[reflect.Type][]authorization.Policy
+	markerPolicies []markerPolicy
+	strict         bool
+}
+
+type markerPolicy struct {
+	marker reflect.Type
+	policy authorization.Policy
+}
+
+func (a AuthorizationMiddleware) matchPolicies(message interface{}) []authorization.Policy {
+	messageType := reflect.TypeOf(message)
+
+	policies := make([]authorization.Policy, 0)
+	policies = append(policies, a.policies[messageType]...)
+
+	for _, markerPolicy := range a.markerPolicies {
+		if messageType != nil && messageType.Implements(markerPolicy.marker) {
+			policies = append(policies, markerPolicy.policy)
+		}
+	}
+
+	return policies
+}
+
+func (a AuthorizationMiddleware) authorize(ctx context.Context, message interface{}) error {
+	policies := a.matchPolicies(message)
+
+	if len(policies) == 0 {
+		if a.strict {
+			return AuthorizationError{
+				MessageType: messageType(message),
+				Reason:      "no policy registered",
+			}
+		}
+
+		return nil
+	}
+
+	principal, _ := cqrs.GetPrincipal(ctx)
+
+	for _, policy := range policies {
+		decision, err := policy.Authorize(ctx, principal, message)
+		if err != nil {
+			return err
+		}
+
+		if !decision.Allowed {
+			return AuthorizationError{
+				MessageType: messageType(message),
+				Reason:      decision.Reason,
+			}
+		}
+	}
+
+	return nil
+}
+
+func (a AuthorizationMiddleware) CommandMiddleware() cqrs.CommandMiddlewareFunc {
+	return func(handler cqrs.CommandHandlerFunc[any]) cqrs.CommandHandlerFunc[any] {
+		return func(ctx context.Context, command any) error {
+			if err := a.authorize(ctx, command); err != nil {
+				return err
+			}
+
+			if err := handler(ctx, command); err != nil {
+				return err
+			}
+
+			return nil
+		}
+	}
+}
+
+func (a AuthorizationMiddleware) QueryMiddleware() cqrs.QueryMiddlewareFunc {
+	return func(handler cqrs.QueryHandlerFunc[any, any]) cqrs.QueryHandlerFunc[any, any] {
+		return func(ctx context.Context, query any) (interface{}, error) {
+			if err := a.authorize(ctx, query); err != nil {
+				return nil, err
+			}
+
+			result, err := handler(ctx, query)
+			if err != nil {
+				return nil, err
+			}
+
+			return result, nil
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package middlewares_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/vulpes-ferrilata/cqrs"
+	"github.com/vulpes-ferrilata/cqrs/middlewares"
+	"github.com/vulpes-ferrilata/cqrs/pkg/authorization"
+	mock_authorization "github.com/vulpes-ferrilata/cqrs/pkg/authorization/mocks"
+)
+
+type AdminOnly interface {
+	AdminOnly()
+}
+
+type (
+	ProtectedCommand struct{}
+	AdminCommand     struct{}
+	PublicQuery      struct{}
+)
+
+func (a AdminCommand) AdminOnly() {}
+
+type principal string
+
+func (p principal) ID() string {
+	return string(p)
+}
+
+func TestAuthorizationMiddleware_CommandMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		admin = principal("admin")
+		ctx   = cqrs.WithPrincipal(context.Background(), admin)
+		Err   = errors.New("error")
+	)
+
+	type mocks struct {
+		commandPolicy *mock_authorization.MockPolicy
+		markerPolicy  *mock_authorization.MockPolicy
+	}
+	type args struct {
+		strict  bool
+		command interface{}
+		handler cqrs.CommandHandlerFunc[any]
+	}
+	tests := []struct {
+		name    string
+		prepare func(mocks mocks)
+		args    args
+		wantErr error
+	}{
+		{
+			name:    "unregistered command",
+			prepare: func(mocks mocks) {},
+			args: args{
+				command: struct{}{},
+				handler: func(ctx context.Context, command interface{}) error {
+					return nil
+				},
+			},
+			wantErr: nil,
+		},
+		{
+			name:    "unregistered command in strict mode",
+			prepare: func(mocks mocks) {},
+			args: args{
+				strict:  true,
+				command: struct{}{},
+				handler: func(ctx context.Context, command interface{}) error {
+					return nil
+				},
+			},
+			wantErr: middlewares.AuthorizationError{
+				MessageType: "struct {}",
+				Reason:      "no policy registered",
+			},
+		},
+		{
+			name: "policy fail",
+			prepare: func(mocks mocks) {
+				mocks.commandPolicy.EXPECT().Authorize(ctx, admin, ProtectedCommand{}).Return(authorization.Decision{}, Err)
+			},
+			args: args{
+				command: ProtectedCommand{},
+				handler: func(ctx context.Context, command interface{}) error {
+					return nil
+				},
+			},
+			wantErr: Err,
+		},
+		{
+			name: "policy deny",
+			prepare: func(mocks mocks) {
+				mocks.commandPolicy.EXPECT().Authorize(ctx, admin, ProtectedCommand{}).Return(authorization.Deny("not owner"), nil)
+			},
+			args: args{
+				command: ProtectedCommand{},
+				handler: func(ctx context.Context, command interface{}) error {
+					return nil
+				},
+			},
+			wantErr: middlewares.AuthorizationError{
+				MessageType: "github.com/vulpes-ferrilata/cqrs/middlewares_test.ProtectedCommand",
+				Reason:      "not owner",
+			},
+		},
+		{
+			name: "marker policy deny",
+			prepare: func(mocks mocks) {
+				mocks.markerPolicy.EXPECT().Authorize(ctx, admin, AdminCommand{}).Return(authorization.Deny("not admin"), nil)
+			},
+			args: args{
+				strict:  true,
+				command: AdminCommand{},
+				handler: func(ctx context.Context, command interface{}) error {
+					return nil
+				},
+			},
+			wantErr: cqrs.ErrAccessDenied,
+		},
+		{
+			name: "handler return error",
+			prepare: func(mocks mocks) {
+				mocks.commandPolicy.EXPECT().Authorize(ctx, admin, ProtectedCommand{}).Return(authorization.Allow(), nil)
+			},
+			args: args{
+				command: ProtectedCommand{},
+				handler: func(ctx context.Context, command interface{}) error {
+					return Err
+				},
+			},
+			wantErr: Err,
+		},
+		{
+			name: "success",
+			prepare: func(mocks mocks) {
+				mocks.markerPolicy.EXPECT().Authorize(ctx, admin, AdminCommand{}).Return(authorization.Allow(), nil)
+			},
+			args: args{
+				strict:  true,
+				command: AdminCommand{},
+				handler: func(ctx context.Context, command interface{}) error {
+					return nil
+				},
+			},
+			wantErr: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := mocks{
+				commandPolicy: mock_authorization.NewMockPolicy(mockCtrl),
+				markerPolicy:  mock_authorization.NewMockPolicy(mockCtrl),
+			}
+
+			tt.prepare(mocks)
+
+			opts := []middlewares.AuthorizationOption{
+				middlewares.WithPolicy(ProtectedCommand{}, mocks.commandPolicy),
+				middlewares.WithMarkerPolicy[AdminOnly](mocks.markerPolicy),
+			}
+			if tt.args.strict {
+				opts = append(opts, middlewares.WithStrictAuthorization())
+			}
+
+			authorizationMiddleware := middlewares.NewAuthorizationMiddleware(opts...)
+			commandMiddleware := authorizationMiddleware.CommandMiddleware()
+			handler := commandMiddleware(tt.args.handler)
+			err := handler(ctx, tt.args.command)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestAuthorizationMiddleware_QueryMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		result = struct{}{}
+	)
+
+	type args struct {
+		ctx context.Context
+	}
+	type wants struct {
+		result interface{}
+		err    error
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "anonymous principal",
+			args: args{
+				ctx: context.Background(),
+			},
+			wants: wants{
+				result: nil,
+				err: middlewares.AuthorizationError{
+					MessageType: "github.com/vulpes-ferrilata/cqrs/middlewares_test.PublicQuery",
+					Reason:      "authentication required",
+				},
+			},
+		},
+		{
+			name: "authenticated principal",
+			args: args{
+				ctx: cqrs.WithPrincipal(context.Background(), principal("user")),
+			},
+			wants: wants{
+				result: result,
+				err:    nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authorizationMiddleware := middlewares.NewAuthorizationMiddleware(
+				middlewares.WithStrictAuthorization(),
+				middlewares.WithPolicy(PublicQuery{}, authorization.PolicyFunc(func(ctx context.Context, principal cqrs.Principal, message interface{}) (authorization.Decision, error) {
+					if principal == nil {
+						return authorization.Deny("authentication required"), nil
+					}
+
+					return authorization.Allow(), nil
+				})),
+			)
+			queryMiddleware := authorizationMiddleware.QueryMiddleware()
+			handler := queryMiddleware(func(ctx context.Context, query interface{}) (interface{}, error) {
+				return result, nil
+			})
+			result, err := handler(tt.args.ctx, PublicQuery{})
+			assert.Equal(t, tt.wants.err, err)
+			assert.Equal(t, tt.wants.result, result)
+		})
+	}
+}
+
+func TestWithMarkerPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy := authorization.PolicyFunc(func(ctx context.Context, principal cqrs.Principal, message interface{}) (authorization.Decision, error) {
+		return authorization.Allow(), nil
+	})
+
+	assert.NotPanics(t, func() {
+		middlewares.WithMarkerPolicy[AdminOnly](policy)
+	})
+	assert.PanicsWithValue(t, "marker policy requires an interface, got middlewares_test.AdminCommand", func() {
+		middlewares.WithMarkerPolicy[AdminCommand](policy)
+	})
+}
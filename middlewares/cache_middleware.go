@@ -0,0 +1,197 @@
+package middlewares
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/vulpes-ferrilata/cqrs"
+	"github.com/vulpes-ferrilata/cqrs/pkg/cache"
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+)
+
+type CacheKeyer interface {
+	CacheKey() string
+}
+
+type CacheOption func(c *CacheMiddleware)
+
+func WithDefaultCacheTTL(ttl time.Duration) CacheOption {
+	return func(c *CacheMiddleware) {
+		c.defaultTTL = ttl
+	}
+}
+
+func WithCacheTTL(query interface{}, ttl time.Duration) CacheOption {
+	return func(c *CacheMiddleware) {
+		c.ttls[reflect.TypeOf(query)] = ttl
+	}
+}
+
+func WithCacheInvalidation(event interface{}, queries ...interface{}) CacheOption {
+	return func(c *CacheMiddleware) {
+		eventType := reflect.TypeOf(event)
+
+		for _, query := range queries {
+			c.invalidations[eventType] = append(c.invalidations[eventType], messageType(query))
+		}
+	}
+}
+
+// WithCacheKeyScope prefixes cache keys with a scope, e.g. the tenant.
+func WithCacheKeyScope(scope func(ctx context.Context) (string, error)) CacheOption {
+	return func(c *CacheMiddleware) {
+		c.scope = scope
+	}
+}
+
+// WithCacheErrorHandler receives errors of the store which do not fail the message, e.g. a failed Set.
+func WithCacheErrorHandler(errorHandler func(ctx context.Context, err error)) CacheOption {
+	return func(c *CacheMiddleware) {
+		c.errorHandler = errorHandler
+	}
+}
+
+func NewCacheMiddleware(store cache.Store, opts ...CacheOption) *CacheMiddleware {
+	cacheMiddleware := &CacheMiddleware{
+		store:         store,
+		ttls:          make(map[reflect.Type]time.Duration),
+		invalidations: make(map[reflect.Type][]string),
+		errorHandler:  func(ctx context.Context, err error) {},
+	}
+
+	for _, opt := range opts {
+		opt(cacheMiddleware)
+	}
+
+	return cacheMiddleware
+}
+
+type CacheMiddleware struct {
+	store         cache.Store
+	defaultTTL    time.Duration
+	ttls          map[reflect.Type]time.Duration
+	invalidations map[reflect.Type][]string
+	scope         func(ctx context.Context) (string, error)
+	errorHandler  func(ctx context.Context, err error)
+}
+
+func (c CacheMiddleware) ttl(query interface{}) time.Duration {
+	ttl, ok := c.ttls[reflect.TypeOf(query)]
+	if !ok {
+		return c.defaultTTL
+	}
+
+	return ttl
+}
+
+func (c CacheMiddleware) key(ctx context.Context, query interface{}) (string, bool, error) {
+	var key string
+
+	if cacheKeyer, ok := query.(CacheKeyer); ok {
+		key = cacheKeyer.CacheKey()
+	} else {
+		messageKey, err := messageKey(query)
+		if err != nil {
+			return "", false, nil
+		}
+
+		key = messageKey
+	}
+
+	if c.scope == nil {
+		return key, true, nil
+	}
+
+	scope, err := c.scope(ctx)
+	if err != nil {
+		return "", false, err
+	}
+
+	return scope + ":" + key, true, nil
+}
+
+func (c CacheMiddleware) QueryMiddleware() cqrs.QueryMiddlewareFunc {
+	return func(handler cqrs.QueryHandlerFunc[any, any]) cqrs.QueryHandlerFunc[any, any] {
+		return func(ctx context.Context, query any) (interface{}, error) {
+			ttl := c.ttl(query)
+			if ttl <= 0 {
+				return handler(ctx, query)
+			}
+
+			key, ok, err := c.key(ctx, query)
+			if err != nil {
+				return nil, err
+			}
+
+			if !ok {
+				return handler(ctx, query)
+			}
+
+			group := messageType(query)
+
+			// results read before an invalidation of the group are not cached.
+			generation, err := c.store.Generation(ctx, group)
+			if err != nil {
+				return nil, err
+			}
+
+			result, ok, err := c.store.Get(ctx, group, key)
+			if err != nil {
+				return nil, err
+			}
+
+			if ok {
+				return result, nil
+			}
+
+			result, err = handler(ctx, query)
+			if err != nil {
+				return nil, err
+			}
+
+			// results read inside a transaction may be rolled back.
+			if db.InTransaction(ctx) {
+				return result, nil
+			}
+
+			if err := c.store.Set(ctx, group, key, result, ttl, generation); err != nil {
+				c.errorHandler(ctx, err)
+			}
+
+			return result, nil
+		}
+	}
+}
+
+func (c CacheMiddleware) EventMiddleware() cqrs.EventMiddlewareFunc {
+	return func(handler cqrs.EventHandlerFunc[any]) cqrs.EventHandlerFunc[any] {
+		return func(ctx context.Context, event any) error {
+			if err := handler(ctx, event); err != nil {
+				return err
+			}
+
+			groups, ok := c.invalidations[reflect.TypeOf(event)]
+			if !ok {
+				return nil
+			}
+
+			if err := c.store.Invalidate(ctx, groups...); err != nil {
+				return err
+			}
+
+			// results read before the transaction of the event commits are stale once it has.
+			if db.InTransaction(ctx) {
+				if err := db.OnAfterCommit(ctx, func(ctx context.Context) {
+					if err := c.store.Invalidate(ctx, groups...); err != nil {
+						c.errorHandler(ctx, err)
+					}
+				}); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}
+	}
+}
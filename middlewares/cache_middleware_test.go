@@ -0,0 +1,516 @@
+package middlewares_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/vulpes-ferrilata/cqrs"
+	"github.com/vulpes-ferrilata/cqrs/middlewares"
+	cache_memory "github.com/vulpes-ferrilata/cqrs/pkg/cache/memory"
+	mock_cache "github.com/vulpes-ferrilata/cqrs/pkg/cache/mocks"
+	db_memory "github.com/vulpes-ferrilata/cqrs/pkg/db/memory"
+)
+
+type (
+	CachedQuery struct {
+		ID int
+	}
+	KeyedQuery struct {
+		ID int
+	}
+	UncachedQuery struct{}
+	CacheEvent    struct{}
+)
+
+func (k KeyedQuery) CacheKey() string {
+	return "keyed"
+}
+
+func TestCacheMiddleware_QueryMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx        = context.Background()
+		group      = "github.com/vulpes-ferrilata/cqrs/middlewares_test.CachedQuery"
+		result     = struct{}{}
+		generation = uint64(7)
+		Err        = errors.New("error")
+	)
+
+	type mocks struct {
+		store *mock_cache.MockStore
+	}
+	type args struct {
+		handler cqrs.QueryHandlerFunc[any, any]
+		query   interface{}
+	}
+	type wants struct {
+		result      interface{}
+		err         error
+		reportedErr error
+	}
+	tests := []struct {
+		name    string
+		prepare func(mocks mocks)
+		args    args
+		wants   wants
+	}{
+		{
+			name:    "query without ttl is not cached",
+			prepare: func(mocks mocks) {},
+			args: args{
+				handler: func(ctx context.Context, query interface{}) (interface{}, error) {
+					return result, nil
+				},
+				query: UncachedQuery{},
+			},
+			wants: wants{
+				result: result,
+				err:    nil,
+			},
+		},
+		{
+			name: "get generation from store fail",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().Generation(ctx, group).Return(uint64(0), Err)
+			},
+			args: args{
+				handler: func(ctx context.Context, query interface{}) (interface{}, error) {
+					return result, nil
+				},
+				query: CachedQuery{ID: 1},
+			},
+			wants: wants{
+				result: nil,
+				err:    Err,
+			},
+		},
+		{
+			name: "get from store fail",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().Generation(ctx, group).Return(generation, nil)
+				mocks.store.EXPECT().Get(ctx, group, `{"ID":1}`).Return(nil, false, Err)
+			},
+			args: args{
+				handler: func(ctx context.Context, query interface{}) (interface{}, error) {
+					return result, nil
+				},
+				query: CachedQuery{ID: 1},
+			},
+			wants: wants{
+				result: nil,
+				err:    Err,
+			},
+		},
+		{
+			name: "cache hit",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().Generation(ctx, group).Return(generation, nil)
+				mocks.store.EXPECT().Get(ctx, group, `{"ID":1}`).Return(result, true, nil)
+			},
+			args: args{
+				handler: func(ctx context.Context, query interface{}) (interface{}, error) {
+					return nil, Err
+				},
+				query: CachedQuery{ID: 1},
+			},
+			wants: wants{
+				result: result,
+				err:    nil,
+			},
+		},
+		{
+			name: "cache miss - handler return error",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().Generation(ctx, group).Return(generation, nil)
+				mocks.store.EXPECT().Get(ctx, group, `{"ID":1}`).Return(nil, false, nil)
+			},
+			args: args{
+				handler: func(ctx context.Context, query interface{}) (interface{}, error) {
+					return nil, Err
+				},
+				query: CachedQuery{ID: 1},
+			},
+			wants: wants{
+				result: nil,
+				err:    Err,
+			},
+		},
+		{
+			name: "cache miss - set to store fail",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().Generation(ctx, group).Return(generation, nil)
+				mocks.store.EXPECT().Get(ctx, group, `{"ID":1}`).Return(nil, false, nil)
+				mocks.store.EXPECT().Set(ctx, group, `{"ID":1}`, result, time.Minute, generation).Return(Err)
+			},
+			args: args{
+				handler: func(ctx context.Context, query interface{}) (interface{}, error) {
+					return result, nil
+				},
+				query: CachedQuery{ID: 1},
+			},
+			wants: wants{
+				result:      result,
+				err:         nil,
+				reportedErr: Err,
+			},
+		},
+		{
+			name: "cache miss - success",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().Generation(ctx, group).Return(generation, nil)
+				mocks.store.EXPECT().Get(ctx, group, `{"ID":1}`).Return(nil, false, nil)
+				mocks.store.EXPECT().Set(ctx, group, `{"ID":1}`, result, time.Minute, generation).Return(nil)
+			},
+			args: args{
+				handler: func(ctx context.Context, query interface{}) (interface{}, error) {
+					return result, nil
+				},
+				query: CachedQuery{ID: 1},
+			},
+			wants: wants{
+				result: result,
+				err:    nil,
+			},
+		},
+		{
+			name: "cache key from query",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().Generation(ctx, "github.com/vulpes-ferrilata/cqrs/middlewares_test.KeyedQuery").Return(generation, nil)
+				mocks.store.EXPECT().Get(ctx, "github.com/vulpes-ferrilata/cqrs/middlewares_test.KeyedQuery", "keyed").Return(nil, false, nil)
+				mocks.store.EXPECT().Set(ctx, "github.com/vulpes-ferrilata/cqrs/middlewares_test.KeyedQuery", "keyed", result, time.Second, generation).Return(nil)
+			},
+			args: args{
+				handler: func(ctx context.Context, query interface{}) (interface{}, error) {
+					return result, nil
+				},
+				query: KeyedQuery{ID: 1},
+			},
+			wants: wants{
+				result: result,
+				err:    nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := mocks{
+				store: mock_cache.NewMockStore(mockCtrl),
+			}
+
+			tt.prepare(mocks)
+
+			var reportedErr error
+			cacheMiddleware := middlewares.NewCacheMiddleware(mocks.store,
+				middlewares.WithCacheTTL(CachedQuery{}, time.Minute),
+				middlewares.WithCacheTTL(KeyedQuery{}, time.Second),
+				middlewares.WithCacheErrorHandler(func(ctx context.Context, err error) {
+					reportedErr = err
+				}),
+			)
+			queryMiddleware := cacheMiddleware.QueryMiddleware()
+			handler := queryMiddleware(tt.args.handler)
+			result, err := handler(ctx, tt.args.query)
+			assert.ErrorIs(t, err, tt.wants.err)
+			assert.Equal(t, tt.wants.result, result)
+			assert.ErrorIs(t, reportedErr, tt.wants.reportedErr)
+		})
+	}
+}
+
+func TestCacheMiddleware_EventMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx    = context.Background()
+		groups = []interface{}{
+			"github.com/vulpes-ferrilata/cqrs/middlewares_test.CachedQuery",
+			"github.com/vulpes-ferrilata/cqrs/middlewares_test.KeyedQuery",
+		}
+		Err = errors.New("error")
+	)
+
+	type mocks struct {
+		store *mock_cache.MockStore
+	}
+	type args struct {
+		handler cqrs.EventHandlerFunc[any]
+		event   interface{}
+	}
+	tests := []struct {
+		name    string
+		prepare func(mocks mocks)
+		args    args
+		wantErr error
+	}{
+		{
+			name:    "event without invalidation rule",
+			prepare: func(mocks mocks) {},
+			args: args{
+				handler: func(ctx context.Context, event interface{}) error {
+					return nil
+				},
+				event: struct{}{},
+			},
+			wantErr: nil,
+		},
+		{
+			name:    "handler return error",
+			prepare: func(mocks mocks) {},
+			args: args{
+				handler: func(ctx context.Context, event interface{}) error {
+					return Err
+				},
+				event: CacheEvent{},
+			},
+			wantErr: Err,
+		},
+		{
+			name: "invalidate fail",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().Invalidate(ctx, groups...).Return(Err)
+			},
+			args: args{
+				handler: func(ctx context.Context, event interface{}) error {
+					return nil
+				},
+				event: CacheEvent{},
+			},
+			wantErr: Err,
+		},
+		{
+			name: "success",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().Invalidate(ctx, groups...).Return(nil)
+			},
+			args: args{
+				handler: func(ctx context.Context, event interface{}) error {
+					return nil
+				},
+				event: CacheEvent{},
+			},
+			wantErr: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := mocks{
+				store: mock_cache.NewMockStore(mockCtrl),
+			}
+
+			tt.prepare(mocks)
+
+			cacheMiddleware := middlewares.NewCacheMiddleware(mocks.store,
+				middlewares.WithCacheInvalidation(CacheEvent{}, CachedQuery{}, KeyedQuery{}),
+			)
+			eventMiddleware := cacheMiddleware.EventMiddleware()
+			handler := eventMiddleware(tt.args.handler)
+			err := handler(ctx, tt.args.event)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestCacheMiddleware_InvalidationDuringQuery(t *testing.T) {
+	t.Parallel()
+
+	store := cache_memory.NewStore(0)
+	cacheMiddleware := middlewares.NewCacheMiddleware(store,
+		middlewares.WithCacheTTL(CachedQuery{}, time.Minute),
+		middlewares.WithCacheInvalidation(CacheEvent{}, CachedQuery{}),
+	)
+	invalidate := cacheMiddleware.EventMiddleware()(func(ctx context.Context, event interface{}) error {
+		return nil
+	})
+
+	results := []string{"stale", "fresh"}
+	handler := cacheMiddleware.QueryMiddleware()(func(ctx context.Context, query interface{}) (interface{}, error) {
+		result := results[0]
+		results = results[1:]
+
+		// the projection is updated while the query is running.
+		if result == "stale" {
+			if err := invalidate(ctx, CacheEvent{}); err != nil {
+				return nil, err
+			}
+		}
+
+		return result, nil
+	})
+
+	result, err := handler(context.Background(), CachedQuery{ID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "stale", result)
+
+	result, err = handler(context.Background(), CachedQuery{ID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "fresh", result)
+}
+
+func TestCacheMiddleware_InvalidationBeforeCommit(t *testing.T) {
+	t.Parallel()
+
+	store := cache_memory.NewStore(0)
+	cacheMiddleware := middlewares.NewCacheMiddleware(store,
+		middlewares.WithCacheTTL(CachedQuery{}, time.Minute),
+		middlewares.WithCacheInvalidation(CacheEvent{}, CachedQuery{}),
+	)
+	invalidate := cacheMiddleware.EventMiddleware()(func(ctx context.Context, event interface{}) error {
+		return nil
+	})
+
+	results := []string{"stale", "fresh"}
+	handler := cacheMiddleware.QueryMiddleware()(func(ctx context.Context, query interface{}) (interface{}, error) {
+		result := results[0]
+		results = results[1:]
+
+		return result, nil
+	})
+
+	transactionManager := db_memory.NewTransactionManager(db_memory.NewStore())
+	committer, tx, err := transactionManager.StartTransaction(context.Background())
+	assert.NoError(t, err)
+
+	err = invalidate(tx, CacheEvent{})
+	assert.NoError(t, err)
+
+	// the query runs before the transaction of the event commits.
+	result, err := handler(context.Background(), CachedQuery{ID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "stale", result)
+
+	err = committer.CommitTransaction(tx)
+	assert.NoError(t, err)
+
+	result, err = handler(context.Background(), CachedQuery{ID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "fresh", result)
+}
+
+func TestCacheMiddleware_QueryInTransaction(t *testing.T) {
+	t.Parallel()
+
+	store := cache_memory.NewStore(0)
+	cacheMiddleware := middlewares.NewCacheMiddleware(store,
+		middlewares.WithCacheTTL(CachedQuery{}, time.Minute),
+	)
+
+	results := []string{"uncommitted", "committed"}
+	handler := cacheMiddleware.QueryMiddleware()(func(ctx context.Context, query interface{}) (interface{}, error) {
+		result := results[0]
+		results = results[1:]
+
+		return result, nil
+	})
+
+	transactionManager := db_memory.NewTransactionManager(db_memory.NewStore())
+	committer, tx, err := transactionManager.StartTransaction(context.Background())
+	assert.NoError(t, err)
+
+	result, err := handler(tx, CachedQuery{ID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "uncommitted", result)
+
+	err = committer.RollbackTransaction(tx)
+	assert.NoError(t, err)
+
+	result, err = handler(context.Background(), CachedQuery{ID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "committed", result)
+}
+
+func TestCacheMiddleware_KeyScope(t *testing.T) {
+	t.Parallel()
+
+	var (
+		Err = errors.New("error")
+	)
+
+	type args struct {
+		principal cqrs.Principal
+	}
+	type wants struct {
+		result      interface{}
+		err         error
+		reportedErr error
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "same scope shares the entry",
+			args: args{
+				principal: principal("alice"),
+			},
+			wants: wants{
+				result: "alice",
+				err:    nil,
+			},
+		},
+		{
+			name: "other scope",
+			args: args{
+				principal: principal("bob"),
+			},
+			wants: wants{
+				result: "bob",
+				err:    nil,
+			},
+		},
+		{
+			name: "scope fail",
+			args: args{
+				principal: nil,
+			},
+			wants: wants{
+				result: nil,
+				err:    Err,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := cache_memory.NewStore(0)
+			cacheMiddleware := middlewares.NewCacheMiddleware(store,
+				middlewares.WithCacheTTL(CachedQuery{}, time.Minute),
+				middlewares.WithCacheKeyScope(func(ctx context.Context) (string, error) {
+					principal, ok := cqrs.GetPrincipal(ctx)
+					if !ok {
+						return "", Err
+					}
+
+					return principal.ID(), nil
+				}),
+			)
+			handler := cacheMiddleware.QueryMiddleware()(func(ctx context.Context, query interface{}) (interface{}, error) {
+				principal, _ := cqrs.GetPrincipal(ctx)
+				return principal.ID(), nil
+			})
+
+			_, err := handler(cqrs.WithPrincipal(context.Background(), principal("alice")), CachedQuery{ID: 1})
+			assert.NoError(t, err)
+
+			ctx := context.Background()
+			if tt.args.principal != nil {
+				ctx = cqrs.WithPrincipal(ctx, tt.args.principal)
+			}
+
+			result, err := handler(ctx, CachedQuery{ID: 1})
+			assert.ErrorIs(t, err, tt.wants.err)
+			assert.Equal(t, tt.wants.result, result)
+		})
+	}
+}
@@ -0,0 +1,380 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/vulpes-ferrilata/cqrs"
+)
+
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (c CircuitState) String() string {
+	switch c {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("CircuitState(%d)", int(c))
+	}
+}
+
+type CircuitOpenError struct {
+	MessageType string
+	RetryAfter  time.Duration
+}
+
+func (c CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit of %s is open, retry after %s", c.MessageType, c.RetryAfter)
+}
+
+func (c CircuitOpenError) Unwrap() error {
+	return cqrs.ErrCircuitOpen
+}
+
+type QueryFallbackFunc func(ctx context.Context, query any, err error) (interface{}, error)
+
+type CircuitStateChangeFunc func(messageType string, from CircuitState, to CircuitState)
+
+type CircuitBreakerOption func(c *CircuitBreakerMiddleware)
+
+func WithCircuitConsecutiveFailures(threshold int) CircuitBreakerOption {
+	return func(c *CircuitBreakerMiddleware) {
+		c.consecutiveFailures = threshold
+	}
+}
+
+func WithCircuitFailureRate(rate float64, window int) CircuitBreakerOption {
+	return func(c *CircuitBreakerMiddleware) {
+		c.failureRate = rate
+		c.window = window
+	}
+}
+
+func WithCircuitCoolDown(coolDown time.Duration) CircuitBreakerOption {
+	return func(c *CircuitBreakerMiddleware) {
+		c.coolDown = coolDown
+	}
+}
+
+func WithCircuitHalfOpenRequests(requests int) CircuitBreakerOption {
+	return func(c *CircuitBreakerMiddleware) {
+		c.halfOpenRequests = requests
+	}
+}
+
+func WithCircuitFailureClassifier(classifier func(err error) bool) CircuitBreakerOption {
+	return func(c *CircuitBreakerMiddleware) {
+		c.isFailure = classifier
+	}
+}
+
+// WithQueryFallback answers the query while its circuit is open, other errors are returned as is.
+func WithQueryFallback(query interface{}, fallback QueryFallbackFunc) CircuitBreakerOption {
+	return func(c *CircuitBreakerMiddleware) {
+		c.fallbacks[reflect.TypeOf(query)] = fallback
+	}
+}
+
+func WithCircuitStateChange(onStateChange CircuitStateChangeFunc) CircuitBreakerOption {
+	return func(c *CircuitBreakerMiddleware) {
+		c.onStateChange = append(c.onStateChange, onStateChange)
+	}
+}
+
+// NewCircuitBreakerMiddleware raises half-open requests lower than 1 to 1, such circuits would never close,
+// and disables the failure rate of negative windows.
+func NewCircuitBreakerMiddleware(opts ...CircuitBreakerOption) *CircuitBreakerMiddleware {
+	circuitBreakerMiddleware := &CircuitBreakerMiddleware{
+		consecutiveFailures: 5,
+		coolDown:            30 * time.Second,
+		halfOpenRequests:    1,
+		isFailure: func(err error) bool {
+			return true
+		},
+		fallbacks: make(map[reflect.Type]QueryFallbackFunc),
+		circuits:  make(map[string]*circuit),
+	}
+
+	for _, opt := range opts {
+		opt(circuitBreakerMiddleware)
+	}
+
+	if circuitBreakerMiddleware.halfOpenRequests < 1 {
+		circuitBreakerMiddleware.halfOpenRequests = 1
+	}
+
+	if circuitBreakerMiddleware.window < 0 {
+		circuitBreakerMiddleware.window = 0
+	}
+
+	return circuitBreakerMiddleware
+}
+
+// CircuitBreakerMiddleware keeps one circuit per message type.
+type CircuitBreakerMiddleware struct {
+	consecutiveFailures int
+	failureRate         float64
+	window              int
+	coolDown            time.Duration
+	halfOpenRequests    int
+	isFailure           func(err error) bool
+	fallbacks           map[reflect.Type]QueryFallbackFunc
+	onStateChange       []CircuitStateChangeFunc
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	mu                  sync.Mutex
+	state               CircuitState
+	generation          uint64
+	openedAt            time.Time
+	consecutiveFailures int
+	outcomes            []bool
+	next                int
+	count               int
+	failures            int
+	trials              int
+	successes           int
+}
+
+type transition struct {
+	from CircuitState
+	to   CircuitState
+}
+
+func (c *CircuitBreakerMiddleware) circuit(messageType string) *circuit {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, ok := c.circuits[messageType]
+	if !ok {
+		item = &circuit{
+			outcomes: make([]bool, c.window),
+		}
+		c.circuits[messageType] = item
+	}
+
+	return item
+}
+
+func (c *CircuitBreakerMiddleware) notify(messageType string, transitions []transition) {
+	for _, transition := range transitions {
+		for _, onStateChange := range c.onStateChange {
+			onStateChange(messageType, transition.from, transition.to)
+		}
+	}
+}
+
+func (c *CircuitBreakerMiddleware) allow(messageType string, circuit *circuit) (uint64, error) {
+	circuit.mu.Lock()
+
+	transitions := make([]transition, 0)
+
+	if circuit.state == CircuitOpen {
+		if elapsed := time.Since(circuit.openedAt); elapsed < c.coolDown {
+			circuit.mu.Unlock()
+
+			return 0, CircuitOpenError{
+				MessageType: messageType,
+				RetryAfter:  c.coolDown - elapsed,
+			}
+		}
+
+		transitions = append(transitions, circuit.transit(CircuitHalfOpen))
+	}
+
+	if circuit.state == CircuitHalfOpen {
+		if circuit.trials >= c.halfOpenRequests {
+			circuit.mu.Unlock()
+			c.notify(messageType, transitions)
+
+			return 0, CircuitOpenError{
+				MessageType: messageType,
+			}
+		}
+
+		circuit.trials++
+	}
+
+	generation := circuit.generation
+	circuit.mu.Unlock()
+	c.notify(messageType, transitions)
+
+	return generation, nil
+}
+
+func (c *CircuitBreakerMiddleware) record(messageType string, circuit *circuit, generation uint64, err error) {
+	failed := err != nil && c.isFailure(err)
+
+	circuit.mu.Lock()
+
+	// the outcome belongs to a previous state.
+	if circuit.generation != generation {
+		circuit.mu.Unlock()
+		return
+	}
+
+	// a canceled call gives its half-open trial back.
+	if errors.Is(err, context.Canceled) {
+		if circuit.state == CircuitHalfOpen {
+			circuit.trials--
+		}
+
+		circuit.mu.Unlock()
+		return
+	}
+
+	transitions := make([]transition, 0)
+
+	switch circuit.state {
+	case CircuitClosed:
+		if failed {
+			circuit.consecutiveFailures++
+		} else {
+			circuit.consecutiveFailures = 0
+		}
+
+		if c.window > 0 {
+			if circuit.count == c.window {
+				if circuit.outcomes[circuit.next] {
+					circuit.failures--
+				}
+			} else {
+				circuit.count++
+			}
+
+			circuit.outcomes[circuit.next] = failed
+			circuit.next = (circuit.next + 1) % c.window
+
+			if failed {
+				circuit.failures++
+			}
+		}
+
+		if c.shouldOpen(circuit) {
+			transitions = append(transitions, circuit.transit(CircuitOpen))
+		}
+	case CircuitHalfOpen:
+		if failed {
+			transitions = append(transitions, circuit.transit(CircuitOpen))
+			break
+		}
+
+		circuit.successes++
+		if circuit.successes >= c.halfOpenRequests {
+			transitions = append(transitions, circuit.transit(CircuitClosed))
+		}
+	}
+
+	circuit.mu.Unlock()
+	c.notify(messageType, transitions)
+}
+
+func (c *CircuitBreakerMiddleware) shouldOpen(circuit *circuit) bool {
+	if c.consecutiveFailures > 0 && circuit.consecutiveFailures >= c.consecutiveFailures {
+		return true
+	}
+
+	if c.window > 0 && c.failureRate > 0 && circuit.count == c.window {
+		return float64(circuit.failures)/float64(circuit.count) >= c.failureRate
+	}
+
+	return false
+}
+
+func (c *circuit) transit(state CircuitState) transition {
+	transition := transition{
+		from: c.state,
+		to:   state,
+	}
+
+	c.state = state
+	c.generation++
+	c.consecutiveFailures = 0
+	c.next = 0
+	c.count = 0
+	c.failures = 0
+	c.trials = 0
+	c.successes = 0
+
+	if state == CircuitOpen {
+		c.openedAt = time.Now()
+	}
+
+	return transition
+}
+
+func (c *CircuitBreakerMiddleware) execute(ctx context.Context, message interface{}, fn func(ctx context.Context) error) error {
+	messageType := messageType(message)
+	circuit := c.circuit(messageType)
+
+	generation, err := c.allow(messageType, circuit)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			c.record(messageType, circuit, generation, fmt.Errorf("%s panicked: %v", messageType, r))
+
+			panic(r)
+		}
+	}()
+
+	err = fn(ctx)
+	c.record(messageType, circuit, generation, err)
+
+	return err
+}
+
+func (c *CircuitBreakerMiddleware) CommandMiddleware() cqrs.CommandMiddlewareFunc {
+	return func(handler cqrs.CommandHandlerFunc[any]) cqrs.CommandHandlerFunc[any] {
+		return func(ctx context.Context, command any) error {
+			return c.execute(ctx, command, func(ctx context.Context) error {
+				return handler(ctx, command)
+			})
+		}
+	}
+}
+
+func (c *CircuitBreakerMiddleware) QueryMiddleware() cqrs.QueryMiddlewareFunc {
+	return func(handler cqrs.QueryHandlerFunc[any, any]) cqrs.QueryHandlerFunc[any, any] {
+		return func(ctx context.Context, query any) (interface{}, error) {
+			var result interface{}
+
+			err := c.execute(ctx, query, func(ctx context.Context) error {
+				var err error
+
+				result, err = handler(ctx, query)
+				return err
+			})
+			if err != nil {
+				// circuits of downstream messages opening do not trigger the fallback of the query.
+				circuitOpenError := CircuitOpenError{}
+				if fallback, ok := c.fallbacks[reflect.TypeOf(query)]; ok && errors.As(err, &circuitOpenError) && circuitOpenError.MessageType == messageType(query) {
+					return fallback(ctx, query, err)
+				}
+
+				return nil, err
+			}
+
+			return result, nil
+		}
+	}
+}
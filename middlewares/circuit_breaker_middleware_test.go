@@ -0,0 +1,446 @@
+package middlewares_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vulpes-ferrilata/cqrs"
+	"github.com/vulpes-ferrilata/cqrs/middlewares"
+)
+
+type (
+	GuardedCommand struct{}
+	GuardedQuery   struct{}
+)
+
+func TestCircuitBreakerMiddleware_CommandMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		Err = errors.New("error")
+	)
+
+	type step struct {
+		sleep   time.Duration
+		err     error
+		panic   bool
+		wantErr error
+		called  bool
+	}
+	type args struct {
+		opts  []middlewares.CircuitBreakerOption
+		steps []step
+	}
+	type wants struct {
+		transitions []string
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "success keeps circuit closed",
+			args: args{
+				opts: []middlewares.CircuitBreakerOption{
+					middlewares.WithCircuitConsecutiveFailures(2),
+				},
+				steps: []step{
+					{err: nil, wantErr: nil, called: true},
+					{err: Err, wantErr: Err, called: true},
+					{err: nil, wantErr: nil, called: true},
+					{err: Err, wantErr: Err, called: true},
+					{err: nil, wantErr: nil, called: true},
+				},
+			},
+			wants: wants{
+				transitions: []string{},
+			},
+		},
+		{
+			name: "consecutive failures open circuit",
+			args: args{
+				opts: []middlewares.CircuitBreakerOption{
+					middlewares.WithCircuitConsecutiveFailures(2),
+					middlewares.WithCircuitCoolDown(time.Hour),
+				},
+				steps: []step{
+					{err: Err, wantErr: Err, called: true},
+					{err: Err, wantErr: Err, called: true},
+					{err: nil, wantErr: cqrs.ErrCircuitOpen, called: false},
+				},
+			},
+			wants: wants{
+				transitions: []string{"closed->open"},
+			},
+		},
+		{
+			name: "failure rate opens circuit",
+			args: args{
+				opts: []middlewares.CircuitBreakerOption{
+					middlewares.WithCircuitConsecutiveFailures(0),
+					middlewares.WithCircuitFailureRate(0.5, 4),
+					middlewares.WithCircuitCoolDown(time.Hour),
+				},
+				steps: []step{
+					{err: Err, wantErr: Err, called: true},
+					{err: nil, wantErr: nil, called: true},
+					{err: Err, wantErr: Err, called: true},
+					{err: nil, wantErr: nil, called: true},
+					{err: nil, wantErr: cqrs.ErrCircuitOpen, called: false},
+				},
+			},
+			wants: wants{
+				transitions: []string{"closed->open"},
+			},
+		},
+		{
+			name: "failure rate below threshold",
+			args: args{
+				opts: []middlewares.CircuitBreakerOption{
+					middlewares.WithCircuitConsecutiveFailures(0),
+					middlewares.WithCircuitFailureRate(0.5, 4),
+				},
+				steps: []step{
+					{err: Err, wantErr: Err, called: true},
+					{err: nil, wantErr: nil, called: true},
+					{err: nil, wantErr: nil, called: true},
+					{err: nil, wantErr: nil, called: true},
+					{err: Err, wantErr: Err, called: true},
+					{err: nil, wantErr: nil, called: true},
+				},
+			},
+			wants: wants{
+				transitions: []string{},
+			},
+		},
+		{
+			name: "half-open trial success closes circuit",
+			args: args{
+				opts: []middlewares.CircuitBreakerOption{
+					middlewares.WithCircuitConsecutiveFailures(1),
+					middlewares.WithCircuitCoolDown(10 * time.Millisecond),
+				},
+				steps: []step{
+					{err: Err, wantErr: Err, called: true},
+					{err: nil, wantErr: cqrs.ErrCircuitOpen, called: false},
+					{sleep: 20 * time.Millisecond, err: nil, wantErr: nil, called: true},
+					{err: nil, wantErr: nil, called: true},
+				},
+			},
+			wants: wants{
+				transitions: []string{"closed->open", "open->half-open", "half-open->closed"},
+			},
+		},
+		{
+			name: "half-open requests lower than 1 close circuit",
+			args: args{
+				opts: []middlewares.CircuitBreakerOption{
+					middlewares.WithCircuitConsecutiveFailures(1),
+					middlewares.WithCircuitCoolDown(10 * time.Millisecond),
+					middlewares.WithCircuitHalfOpenRequests(0),
+				},
+				steps: []step{
+					{err: Err, wantErr: Err, called: true},
+					{sleep: 20 * time.Millisecond, err: nil, wantErr: nil, called: true},
+					{err: nil, wantErr: nil, called: true},
+				},
+			},
+			wants: wants{
+				transitions: []string{"closed->open", "open->half-open", "half-open->closed"},
+			},
+		},
+		{
+			name: "negative window disables failure rate",
+			args: args{
+				opts: []middlewares.CircuitBreakerOption{
+					middlewares.WithCircuitConsecutiveFailures(0),
+					middlewares.WithCircuitFailureRate(0.5, -1),
+				},
+				steps: []step{
+					{err: Err, wantErr: Err, called: true},
+					{err: Err, wantErr: Err, called: true},
+				},
+			},
+			wants: wants{
+				transitions: []string{},
+			},
+		},
+		{
+			name: "half-open trial failure reopens circuit",
+			args: args{
+				opts: []middlewares.CircuitBreakerOption{
+					middlewares.WithCircuitConsecutiveFailures(1),
+					middlewares.WithCircuitCoolDown(10 * time.Millisecond),
+				},
+				steps: []step{
+					{err: Err, wantErr: Err, called: true},
+					{sleep: 20 * time.Millisecond, err: Err, wantErr: Err, called: true},
+					{err: nil, wantErr: cqrs.ErrCircuitOpen, called: false},
+				},
+			},
+			wants: wants{
+				transitions: []string{"closed->open", "open->half-open", "half-open->open"},
+			},
+		},
+		{
+			name: "half-open trial panic reopens circuit",
+			args: args{
+				opts: []middlewares.CircuitBreakerOption{
+					middlewares.WithCircuitConsecutiveFailures(1),
+					middlewares.WithCircuitCoolDown(10 * time.Millisecond),
+				},
+				steps: []step{
+					{err: Err, wantErr: Err, called: true},
+					{sleep: 20 * time.Millisecond, panic: true, called: true},
+					{err: nil, wantErr: cqrs.ErrCircuitOpen, called: false},
+				},
+			},
+			wants: wants{
+				transitions: []string{"closed->open", "open->half-open", "half-open->open"},
+			},
+		},
+		{
+			name: "canceled half-open trial releases trial",
+			args: args{
+				opts: []middlewares.CircuitBreakerOption{
+					middlewares.WithCircuitConsecutiveFailures(1),
+					middlewares.WithCircuitCoolDown(10 * time.Millisecond),
+				},
+				steps: []step{
+					{err: Err, wantErr: Err, called: true},
+					{sleep: 20 * time.Millisecond, err: context.Canceled, wantErr: context.Canceled, called: true},
+					{err: nil, wantErr: nil, called: true},
+				},
+			},
+			wants: wants{
+				transitions: []string{"closed->open", "open->half-open", "half-open->closed"},
+			},
+		},
+		{
+			name: "canceled call does not reset consecutive failures",
+			args: args{
+				opts: []middlewares.CircuitBreakerOption{
+					middlewares.WithCircuitConsecutiveFailures(2),
+					middlewares.WithCircuitCoolDown(time.Hour),
+				},
+				steps: []step{
+					{err: Err, wantErr: Err, called: true},
+					{err: context.Canceled, wantErr: context.Canceled, called: true},
+					{err: Err, wantErr: Err, called: true},
+					{err: nil, wantErr: cqrs.ErrCircuitOpen, called: false},
+				},
+			},
+			wants: wants{
+				transitions: []string{"closed->open"},
+			},
+		},
+		{
+			name: "unclassified error is not failure",
+			args: args{
+				opts: []middlewares.CircuitBreakerOption{
+					middlewares.WithCircuitConsecutiveFailures(1),
+					middlewares.WithCircuitFailureClassifier(func(err error) bool {
+						return !errors.Is(err, Err)
+					}),
+				},
+				steps: []step{
+					{err: Err, wantErr: Err, called: true},
+					{err: Err, wantErr: Err, called: true},
+				},
+			},
+			wants: wants{
+				transitions: []string{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transitions := make([]string, 0)
+			opts := append(tt.args.opts, middlewares.WithCircuitStateChange(func(messageType string, from middlewares.CircuitState, to middlewares.CircuitState) {
+				assert.Equal(t, "github.com/vulpes-ferrilata/cqrs/middlewares_test.GuardedCommand", messageType)
+				transitions = append(transitions, from.String()+"->"+to.String())
+			}))
+
+			circuitBreakerMiddleware := middlewares.NewCircuitBreakerMiddleware(opts...)
+			commandMiddleware := circuitBreakerMiddleware.CommandMiddleware()
+
+			for _, step := range tt.args.steps {
+				time.Sleep(step.sleep)
+
+				called := false
+				handler := commandMiddleware(func(ctx context.Context, command interface{}) error {
+					called = true
+					if step.panic {
+						panic("panic")
+					}
+
+					return step.err
+				})
+				if step.panic {
+					assert.Panics(t, func() {
+						handler(context.Background(), GuardedCommand{})
+					})
+				} else {
+					err := handler(context.Background(), GuardedCommand{})
+					assert.ErrorIs(t, err, step.wantErr)
+				}
+				assert.Equal(t, step.called, called)
+			}
+
+			assert.Equal(t, tt.wants.transitions, transitions)
+		})
+	}
+}
+
+func TestCircuitBreakerMiddleware_QueryMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		result   = struct{}{}
+		fallback = "fallback"
+		Err      = errors.New("error")
+	)
+
+	type args struct {
+		opts []middlewares.CircuitBreakerOption
+	}
+	type wants struct {
+		result interface{}
+		err    error
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "open circuit without fallback",
+			args: args{
+				opts: []middlewares.CircuitBreakerOption{},
+			},
+			wants: wants{
+				result: nil,
+				err: middlewares.CircuitOpenError{
+					MessageType: "github.com/vulpes-ferrilata/cqrs/middlewares_test.GuardedQuery",
+				},
+			},
+		},
+		{
+			name: "open circuit with fallback",
+			args: args{
+				opts: []middlewares.CircuitBreakerOption{
+					middlewares.WithQueryFallback(GuardedQuery{}, func(ctx context.Context, query any, err error) (interface{}, error) {
+						return fallback, nil
+					}),
+				},
+			},
+			wants: wants{
+				result: fallback,
+				err:    nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]middlewares.CircuitBreakerOption{
+				middlewares.WithCircuitConsecutiveFailures(1),
+				middlewares.WithCircuitCoolDown(time.Hour),
+			}, tt.args.opts...)
+
+			circuitBreakerMiddleware := middlewares.NewCircuitBreakerMiddleware(opts...)
+			queryMiddleware := circuitBreakerMiddleware.QueryMiddleware()
+
+			failing := queryMiddleware(func(ctx context.Context, query interface{}) (interface{}, error) {
+				return nil, Err
+			})
+			_, err := failing(context.Background(), GuardedQuery{})
+			assert.ErrorIs(t, err, Err)
+
+			handler := queryMiddleware(func(ctx context.Context, query interface{}) (interface{}, error) {
+				return result, nil
+			})
+			result, err := handler(context.Background(), GuardedQuery{})
+			if openErr := (middlewares.CircuitOpenError{}); errors.As(err, &openErr) {
+				assert.Greater(t, openErr.RetryAfter, time.Duration(0))
+				openErr.RetryAfter = 0
+				err = openErr
+			}
+			assert.Equal(t, tt.wants.err, err)
+			assert.Equal(t, tt.wants.result, result)
+		})
+	}
+}
+
+func TestCircuitBreakerMiddleware_DownstreamCircuitOpen(t *testing.T) {
+	t.Parallel()
+
+	circuitBreakerMiddleware := middlewares.NewCircuitBreakerMiddleware(
+		middlewares.WithQueryFallback(GuardedQuery{}, func(ctx context.Context, query any, err error) (interface{}, error) {
+			return "fallback", nil
+		}),
+	)
+	queryMiddleware := circuitBreakerMiddleware.QueryMiddleware()
+
+	downstreamErr := middlewares.CircuitOpenError{
+		MessageType: "github.com/vulpes-ferrilata/cqrs/middlewares_test.GuardedCommand",
+	}
+	handler := queryMiddleware(func(ctx context.Context, query interface{}) (interface{}, error) {
+		return nil, downstreamErr
+	})
+	result, err := handler(context.Background(), GuardedQuery{})
+	assert.Equal(t, downstreamErr, err)
+	assert.Nil(t, result)
+}
+
+func TestCircuitBreakerMiddleware_HalfOpenRequests(t *testing.T) {
+	t.Parallel()
+
+	circuitBreakerMiddleware := middlewares.NewCircuitBreakerMiddleware(
+		middlewares.WithCircuitConsecutiveFailures(1),
+		middlewares.WithCircuitCoolDown(10*time.Millisecond),
+		middlewares.WithCircuitHalfOpenRequests(1),
+	)
+	commandMiddleware := circuitBreakerMiddleware.CommandMiddleware()
+
+	err := commandMiddleware(func(ctx context.Context, command interface{}) error {
+		return errors.New("error")
+	})(context.Background(), GuardedCommand{})
+	assert.Error(t, err)
+
+	time.Sleep(20 * time.Millisecond)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		err := commandMiddleware(func(ctx context.Context, command interface{}) error {
+			close(started)
+			<-release
+			return nil
+		})(context.Background(), GuardedCommand{})
+		assert.NoError(t, err)
+	}()
+
+	<-started
+	err = commandMiddleware(func(ctx context.Context, command interface{}) error {
+		return nil
+	})(context.Background(), GuardedCommand{})
+	assert.ErrorIs(t, err, cqrs.ErrCircuitOpen)
+
+	close(release)
+	wg.Wait()
+
+	err = commandMiddleware(func(ctx context.Context, command interface{}) error {
+		return nil
+	})(context.Background(), GuardedCommand{})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,150 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/vulpes-ferrilata/cqrs"
+)
+
+type ProjectionQuery interface {
+	Projection() string
+}
+
+type ConsistencyOption func(c *ConsistencyMiddleware)
+
+func WithProjection(projection string, events ...interface{}) ConsistencyOption {
+	return func(c *ConsistencyMiddleware) {
+		for _, event := range events {
+			eventType := reflect.TypeOf(event)
+			c.projections[eventType] = append(c.projections[eventType], projection)
+		}
+	}
+}
+
+func WithQueryProjection(query interface{}, projection string) ConsistencyOption {
+	return func(c *ConsistencyMiddleware) {
+		c.queryProjections[reflect.TypeOf(query)] = projection
+	}
+}
+
+func WithConsistencyTimeout(timeout time.Duration) ConsistencyOption {
+	return func(c *ConsistencyMiddleware) {
+		c.timeout = timeout
+	}
+}
+
+func NewConsistencyMiddleware(checkpointTracker cqrs.CheckpointTracker, opts ...ConsistencyOption) *ConsistencyMiddleware {
+	consistencyMiddleware := &ConsistencyMiddleware{
+		checkpointTracker: checkpointTracker,
+		timeout:           5 * time.Second,
+		projections:       make(map[reflect.Type][]string),
+		queryProjections:  make(map[reflect.Type]string),
+	}
+
+	for _, opt := range opts {
+		opt(consistencyMiddleware)
+	}
+
+	return consistencyMiddleware
+}
+
+// ConsistencyMiddleware must be used after EventProviderMiddleware and before EventDispatcherMiddleware,
+// projections of an event are reported once EventDispatcherMiddleware has dispatched it to every handler.
+type ConsistencyMiddleware struct {
+	checkpointTracker cqrs.CheckpointTracker
+	timeout           time.Duration
+	projections       map[reflect.Type][]string
+	queryProjections  map[reflect.Type]string
+}
+
+func (c ConsistencyMiddleware) projection(query interface{}) (string, bool) {
+	if projectionQuery, ok := query.(ProjectionQuery); ok {
+		return projectionQuery.Projection(), true
+	}
+
+	projection, ok := c.queryProjections[reflect.TypeOf(query)]
+	return projection, ok
+}
+
+func (c ConsistencyMiddleware) CommandMiddleware() cqrs.CommandMiddlewareFunc {
+	return func(handler cqrs.CommandHandlerFunc[any]) cqrs.CommandHandlerFunc[any] {
+		return func(ctx context.Context, command any) error {
+			position := c.checkpointTracker.Begin()
+			// failed dispatches are reported as well.
+			ctx = withDispatchReporter(ctx, func(events []interface{}) {
+				for _, event := range events {
+					for _, projection := range c.projections[reflect.TypeOf(event)] {
+						c.checkpointTracker.Report(projection, position)
+					}
+				}
+			})
+
+			if err := handler(ctx, command); err != nil {
+				c.checkpointTracker.Complete(position)
+
+				return err
+			}
+
+			projections := make([]string, 0)
+			if eventProvider, ok := cqrs.GetEventProvider(ctx); ok {
+				for _, event := range eventProvider.GetEvents() {
+					projections = append(projections, c.projections[reflect.TypeOf(event)]...)
+				}
+			}
+
+			c.checkpointTracker.Complete(position, projections...)
+
+			if consistencyToken, ok := cqrs.GetConsistencyToken(ctx); ok {
+				consistencyToken.Observe(position)
+			}
+
+			return nil
+		}
+	}
+}
+
+func (c ConsistencyMiddleware) QueryMiddleware() cqrs.QueryMiddlewareFunc {
+	return func(handler cqrs.QueryHandlerFunc[any, any]) cqrs.QueryHandlerFunc[any, any] {
+		return func(ctx context.Context, query any) (interface{}, error) {
+			consistencyToken, ok := cqrs.GetConsistencyToken(ctx)
+			if !ok {
+				return handler(ctx, query)
+			}
+
+			projection, ok := c.projection(query)
+			if !ok {
+				return handler(ctx, query)
+			}
+
+			waitCtx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			if err := c.checkpointTracker.Wait(waitCtx, projection, consistencyToken.Version()); err != nil {
+				if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+					return nil, cqrs.ErrProjectionNotCaughtUp
+				}
+
+				return nil, err
+			}
+
+			result, err := handler(ctx, query)
+			if err != nil {
+				return nil, err
+			}
+
+			return result, nil
+		}
+	}
+}
+
+func (c ConsistencyMiddleware) EventMiddleware() cqrs.EventMiddlewareFunc {
+	return func(handler cqrs.EventHandlerFunc[any]) cqrs.EventHandlerFunc[any] {
+		return func(ctx context.Context, event any) error {
+			// commands executed by the handler report the events of their own position.
+			return handler(withDispatchReporter(ctx, nil), event)
+		}
+	}
+}
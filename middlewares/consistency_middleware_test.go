@@ -0,0 +1,419 @@
+package middlewares_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vulpes-ferrilata/cqrs"
+	"github.com/vulpes-ferrilata/cqrs/middlewares"
+	db_memory "github.com/vulpes-ferrilata/cqrs/pkg/db/memory"
+)
+
+type (
+	ProjectingCommand struct{}
+	ProjectedEvent    struct{}
+	ProjectedQuery    struct{}
+)
+
+func (p ProjectedQuery) Projection() string {
+	return "projection"
+}
+
+func TestConsistencyMiddleware_CommandMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		Err = errors.New("error")
+	)
+
+	type args struct {
+		handler cqrs.CommandHandlerFunc[any]
+		command interface{}
+	}
+	type wants struct {
+		version    uint64
+		checkpoint uint64
+		err        error
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "handler return error",
+			args: args{
+				handler: func(ctx context.Context, command interface{}) error {
+					return Err
+				},
+				command: struct{}{},
+			},
+			wants: wants{
+				version:    0,
+				checkpoint: 1,
+				err:        Err,
+			},
+		},
+		{
+			name: "events not handled by projection yet",
+			args: args{
+				handler: func(ctx context.Context, command interface{}) error {
+					eventProvider, ok := cqrs.GetEventProvider(ctx)
+					if ok {
+						eventProvider.CollectEvents(ProjectedEvent{})
+					}
+
+					return nil
+				},
+				command: struct{}{},
+			},
+			wants: wants{
+				version:    1,
+				checkpoint: 0,
+				err:        nil,
+			},
+		},
+		{
+			name: "success",
+			args: args{
+				handler: func(ctx context.Context, command interface{}) error {
+					eventProvider, ok := cqrs.GetEventProvider(ctx)
+					if ok {
+						eventProvider.CollectEvents(struct{}{})
+					}
+
+					return nil
+				},
+				command: struct{}{},
+			},
+			wants: wants{
+				version:    1,
+				checkpoint: 1,
+				err:        nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkpointTracker := cqrs.NewCheckpointTracker()
+			consistencyToken := cqrs.NewConsistencyToken(0)
+
+			ctx := context.Background()
+			ctx = cqrs.WithEventProvider(ctx, cqrs.NewEventProvider())
+			ctx = cqrs.WithConsistencyToken(ctx, consistencyToken)
+
+			consistencyMiddleware := middlewares.NewConsistencyMiddleware(checkpointTracker,
+				middlewares.WithProjection("projection", ProjectedEvent{}),
+			)
+			commandMiddleware := consistencyMiddleware.CommandMiddleware()
+			handler := commandMiddleware(tt.args.handler)
+			err := handler(ctx, tt.args.command)
+			assert.ErrorIs(t, err, tt.wants.err)
+			assert.Equal(t, tt.wants.version, consistencyToken.Version())
+			assert.Equal(t, tt.wants.checkpoint, checkpointTracker.Checkpoint("projection"))
+		})
+	}
+}
+
+func TestConsistencyMiddleware_QueryMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		result = struct{}{}
+		Err    = errors.New("error")
+	)
+
+	type args struct {
+		handler cqrs.QueryHandlerFunc[any, any]
+		ctx     context.Context
+		query   interface{}
+	}
+	type wants struct {
+		result interface{}
+		err    error
+	}
+	tests := []struct {
+		name    string
+		prepare func(checkpointTracker cqrs.CheckpointTracker)
+		args    args
+		wants   wants
+	}{
+		{
+			name:    "no consistency token",
+			prepare: func(checkpointTracker cqrs.CheckpointTracker) {},
+			args: args{
+				handler: func(ctx context.Context, query interface{}) (interface{}, error) {
+					return result, nil
+				},
+				ctx:   context.Background(),
+				query: ProjectedQuery{},
+			},
+			wants: wants{
+				result: result,
+				err:    nil,
+			},
+		},
+		{
+			name:    "query without projection",
+			prepare: func(checkpointTracker cqrs.CheckpointTracker) {},
+			args: args{
+				handler: func(ctx context.Context, query interface{}) (interface{}, error) {
+					return result, nil
+				},
+				ctx:   cqrs.WithConsistencyToken(context.Background(), cqrs.NewConsistencyToken(1)),
+				query: struct{}{},
+			},
+			wants: wants{
+				result: result,
+				err:    nil,
+			},
+		},
+		{
+			name: "projection not caught up",
+			prepare: func(checkpointTracker cqrs.CheckpointTracker) {
+				position := checkpointTracker.Begin()
+				checkpointTracker.Complete(position, "projection")
+			},
+			args: args{
+				handler: func(ctx context.Context, query interface{}) (interface{}, error) {
+					return result, nil
+				},
+				ctx:   cqrs.WithConsistencyToken(context.Background(), cqrs.NewConsistencyToken(1)),
+				query: ProjectedQuery{},
+			},
+			wants: wants{
+				result: nil,
+				err:    cqrs.ErrProjectionNotCaughtUp,
+			},
+		},
+		{
+			name: "handler return error",
+			prepare: func(checkpointTracker cqrs.CheckpointTracker) {
+				position := checkpointTracker.Begin()
+				checkpointTracker.Complete(position)
+			},
+			args: args{
+				handler: func(ctx context.Context, query interface{}) (interface{}, error) {
+					return nil, Err
+				},
+				ctx:   cqrs.WithConsistencyToken(context.Background(), cqrs.NewConsistencyToken(1)),
+				query: ProjectedQuery{},
+			},
+			wants: wants{
+				result: nil,
+				err:    Err,
+			},
+		},
+		{
+			name: "success",
+			prepare: func(checkpointTracker cqrs.CheckpointTracker) {
+				position := checkpointTracker.Begin()
+				checkpointTracker.Complete(position, "projection")
+
+				go func() {
+					time.Sleep(10 * time.Millisecond)
+					checkpointTracker.Report("projection", position)
+				}()
+			},
+			args: args{
+				handler: func(ctx context.Context, query interface{}) (interface{}, error) {
+					return result, nil
+				},
+				ctx:   cqrs.WithConsistencyToken(context.Background(), cqrs.NewConsistencyToken(1)),
+				query: ProjectedQuery{},
+			},
+			wants: wants{
+				result: result,
+				err:    nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkpointTracker := cqrs.NewCheckpointTracker()
+
+			tt.prepare(checkpointTracker)
+
+			consistencyMiddleware := middlewares.NewConsistencyMiddleware(checkpointTracker,
+				middlewares.WithConsistencyTimeout(100*time.Millisecond),
+			)
+			queryMiddleware := consistencyMiddleware.QueryMiddleware()
+			handler := queryMiddleware(tt.args.handler)
+			result, err := handler(tt.args.ctx, tt.args.query)
+			assert.ErrorIs(t, err, tt.wants.err)
+			assert.Equal(t, tt.wants.result, result)
+		})
+	}
+}
+
+func TestConsistencyMiddleware_EventMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		Err = errors.New("error")
+	)
+
+	type args struct {
+		handlers []cqrs.EventHandlerFunc[ProjectedEvent]
+	}
+	type wants struct {
+		checkpoint uint64
+		err        error
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "handler return error",
+			args: args{
+				handlers: []cqrs.EventHandlerFunc[ProjectedEvent]{
+					func(ctx context.Context, event ProjectedEvent) error {
+						return Err
+					},
+				},
+			},
+			wants: wants{
+				checkpoint: 1,
+				err:        Err,
+			},
+		},
+		{
+			name: "event without handler",
+			args: args{
+				handlers: []cqrs.EventHandlerFunc[ProjectedEvent]{},
+			},
+			wants: wants{
+				checkpoint: 1,
+				err:        nil,
+			},
+		},
+		{
+			name: "success",
+			args: args{
+				handlers: []cqrs.EventHandlerFunc[ProjectedEvent]{
+					func(ctx context.Context, event ProjectedEvent) error {
+						return nil
+					},
+					func(ctx context.Context, event ProjectedEvent) error {
+						return nil
+					},
+				},
+			},
+			wants: wants{
+				checkpoint: 1,
+				err:        nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkpointTracker := cqrs.NewCheckpointTracker()
+			consistencyMiddleware := middlewares.NewConsistencyMiddleware(checkpointTracker,
+				middlewares.WithProjection("projection", ProjectedEvent{}),
+			)
+
+			eventBus := cqrs.NewEventBus()
+			eventBus.Use(consistencyMiddleware.EventMiddleware())
+			for _, handler := range tt.args.handlers {
+				err := cqrs.RegisterEventHandler(eventBus, handler)
+				assert.NoError(t, err)
+			}
+
+			commandBus := cqrs.NewCommandBus()
+			commandBus.Use(
+				middlewares.NewEventProviderMiddleware().CommandMiddleware(),
+				consistencyMiddleware.CommandMiddleware(),
+				middlewares.NewEventDispatcherMiddleware(eventBus).CommandMiddleware(),
+			)
+			err := cqrs.RegisterCommandHandler(commandBus, func(ctx context.Context, command ProjectingCommand) error {
+				eventProvider, _ := cqrs.GetEventProvider(ctx)
+				eventProvider.CollectEvents(ProjectedEvent{})
+
+				return nil
+			})
+			assert.NoError(t, err)
+
+			err = commandBus.Execute(context.Background(), ProjectingCommand{})
+			assert.ErrorIs(t, err, tt.wants.err)
+			assert.Equal(t, tt.wants.checkpoint, checkpointTracker.Checkpoint("projection"))
+		})
+	}
+}
+
+func TestConsistencyMiddleware_HandlersOfOneEvent(t *testing.T) {
+	t.Parallel()
+
+	checkpointTracker := cqrs.NewCheckpointTracker()
+	consistencyMiddleware := middlewares.NewConsistencyMiddleware(checkpointTracker,
+		middlewares.WithProjection("projection", ProjectedEvent{}),
+	)
+	transactionManager := db_memory.NewTransactionManager(db_memory.NewStore())
+
+	projected := make(chan struct{})
+	var projectedAt, readAt time.Time
+
+	eventBus := cqrs.NewEventBus()
+	eventBus.Use(consistencyMiddleware.EventMiddleware())
+	// the handler of another projection returns first.
+	err := cqrs.RegisterEventHandler(eventBus, func(ctx context.Context, event ProjectedEvent) error {
+		close(projected)
+		return nil
+	})
+	assert.NoError(t, err)
+	err = cqrs.RegisterEventHandler(eventBus, func(ctx context.Context, event ProjectedEvent) error {
+		time.Sleep(50 * time.Millisecond)
+		projectedAt = time.Now()
+		return nil
+	})
+	assert.NoError(t, err)
+
+	commandBus := cqrs.NewCommandBus()
+	commandBus.Use(
+		// events dispatched after commit are reported after the command has completed its position.
+		middlewares.NewTransactionMiddleware(transactionManager).CommandMiddleware(),
+		middlewares.NewEventProviderMiddleware().CommandMiddleware(),
+		consistencyMiddleware.CommandMiddleware(),
+		middlewares.NewEventDispatcherMiddleware(eventBus,
+			middlewares.WithDefaultDispatchTiming(middlewares.DispatchAfterCommit),
+			middlewares.WithAfterCommitErrorHandler(func(ctx context.Context, err error) {
+				assert.NoError(t, err)
+			}),
+		).CommandMiddleware(),
+	)
+	err = cqrs.RegisterCommandHandler(commandBus, func(ctx context.Context, command ProjectingCommand) error {
+		eventProvider, _ := cqrs.GetEventProvider(ctx)
+		eventProvider.CollectEvents(ProjectedEvent{})
+
+		return nil
+	})
+	assert.NoError(t, err)
+
+	queryBus := cqrs.NewQueryBus()
+	queryBus.Use(consistencyMiddleware.QueryMiddleware())
+	err = cqrs.RegisterQueryHandler(queryBus, func(ctx context.Context, query ProjectedQuery) (interface{}, error) {
+		readAt = time.Now()
+		return nil, nil
+	})
+	assert.NoError(t, err)
+
+	consistencyToken := cqrs.NewConsistencyToken(0)
+	ctx := cqrs.WithConsistencyToken(context.Background(), consistencyToken)
+
+	done := make(chan error)
+	go func() {
+		<-projected
+		_, err := queryBus.Execute(ctx, ProjectedQuery{})
+		done <- err
+	}()
+
+	err = commandBus.Execute(ctx, ProjectingCommand{})
+	assert.NoError(t, err)
+	assert.NoError(t, <-done)
+	assert.False(t, readAt.Before(projectedAt))
+}
@@ -0,0 +1,51 @@
+package middlewares
+
+import (
+	"context"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/tracing"
+)
+
+type dispatchReporterKey struct{}
+
+func withDispatchReporter(ctx context.Context, reporter func(events []interface{})) context.Context {
+	return context.WithValue(ctx, dispatchReporterKey{}, reporter)
+}
+
+func getDispatchReporter(ctx context.Context) (func(events []interface{}), bool) {
+	reporter, ok := ctx.Value(dispatchReporterKey{}).(func(events []interface{}))
+	return reporter, ok && reporter != nil
+}
+
+type retryingKey struct{}
+
+func withRetrying(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryingKey{}, true)
+}
+
+func isRetrying(ctx context.Context) bool {
+	_, ok := ctx.Value(retryingKey{}).(bool)
+	return ok
+}
+
+type commandSpanKey struct{}
+
+func withCommandSpan(ctx context.Context, span tracing.Span) context.Context {
+	return context.WithValue(ctx, commandSpanKey{}, span)
+}
+
+func getCommandSpan(ctx context.Context) (tracing.Span, bool) {
+	span, ok := ctx.Value(commandSpanKey{}).(tracing.Span)
+	return span, ok
+}
+
+type eventDispatchBinderKey struct{}
+
+func withEventDispatchBinder(ctx context.Context, binder *eventDispatchBinder) context.Context {
+	return context.WithValue(ctx, eventDispatchBinderKey{}, binder)
+}
+
+func getEventDispatchBinder(ctx context.Context) (*eventDispatchBinder, bool) {
+	binder, ok := ctx.Value(eventDispatchBinderKey{}).(*eventDispatchBinder)
+	return binder, ok && binder != nil
+}
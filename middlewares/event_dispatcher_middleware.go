@@ -2,33 +2,232 @@ package middlewares
 
 import (
 	"context"
+	"errors"
+	"reflect"
+	"sync"
 
 	"github.com/vulpes-ferrilata/cqrs"
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+	"github.com/vulpes-ferrilata/cqrs/pkg/metrics"
+	"github.com/vulpes-ferrilata/cqrs/pkg/tracing"
 )
 
-func NewEventDispatcherMiddleware(eventBus cqrs.EventBus) *EventDispatcherMiddleware {
-	return &EventDispatcherMiddleware{
-		eventBus: eventBus,
+type DispatchTiming int
+
+const (
+	DispatchInTransaction DispatchTiming = iota
+	// DispatchAfterCommit dispatches events once the outermost transaction has committed.
+	DispatchAfterCommit
+)
+
+type EventDispatcherOption func(e *EventDispatcherMiddleware)
+
+func WithEventDispatcherTracer(tracer tracing.Tracer) EventDispatcherOption {
+	return func(e *EventDispatcherMiddleware) {
+		e.tracer = tracer
+	}
+}
+
+// WithEventDispatcherMetrics counts the dispatched events per command type and event type in EventsDispatchedTotal.
+func WithEventDispatcherMetrics(sink metrics.Sink) EventDispatcherOption {
+	return func(e *EventDispatcherMiddleware) {
+		e.sink = sink
+	}
+}
+
+func WithDefaultDispatchTiming(timing DispatchTiming) EventDispatcherOption {
+	return func(e *EventDispatcherMiddleware) {
+		e.defaultTiming = timing
+	}
+}
+
+func WithDispatchTiming(event interface{}, timing DispatchTiming) EventDispatcherOption {
+	return func(e *EventDispatcherMiddleware) {
+		e.timings[reflect.TypeOf(event)] = timing
 	}
 }
 
+// WithAfterCommitErrorHandler receives errors of events dispatched after commit, it is required by DispatchAfterCommit.
+func WithAfterCommitErrorHandler(errorHandler func(ctx context.Context, err error)) EventDispatcherOption {
+	return func(e *EventDispatcherMiddleware) {
+		e.afterCommitErrorHandler = errorHandler
+	}
+}
+
+func NewEventDispatcherMiddleware(eventBus cqrs.EventBus, opts ...EventDispatcherOption) *EventDispatcherMiddleware {
+	eventDispatcherMiddleware := &EventDispatcherMiddleware{
+		eventBus:      eventBus,
+		defaultTiming: DispatchInTransaction,
+		timings:       make(map[reflect.Type]DispatchTiming),
+	}
+
+	for _, opt := range opts {
+		opt(eventDispatcherMiddleware)
+	}
+
+	if eventDispatcherMiddleware.afterCommitErrorHandler == nil && eventDispatcherMiddleware.dispatchesAfterCommit() {
+		panic("dispatching events after commit requires an after commit error handler")
+	}
+
+	return eventDispatcherMiddleware
+}
+
 type EventDispatcherMiddleware struct {
-	eventBus cqrs.EventBus
+	eventBus                cqrs.EventBus
+	tracer                  tracing.Tracer
+	sink                    metrics.Sink
+	defaultTiming           DispatchTiming
+	timings                 map[reflect.Type]DispatchTiming
+	afterCommitErrorHandler func(ctx context.Context, err error)
+}
+
+func (e EventDispatcherMiddleware) dispatchesAfterCommit() bool {
+	if e.defaultTiming == DispatchAfterCommit {
+		return true
+	}
+
+	for _, timing := range e.timings {
+		if timing == DispatchAfterCommit {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (e EventDispatcherMiddleware) dispatch(ctx context.Context, command interface{}, events []interface{}) error {
+	err := e.trace(ctx, events)
+
+	if report, ok := getDispatchReporter(ctx); ok {
+		report(events)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if e.sink != nil {
+		for _, event := range events {
+			e.sink.IncCounter(EventsDispatchedTotal, metrics.Labels{
+				"command_type": messageType(command),
+				"event_type":   messageType(event),
+			}, 1)
+		}
+	}
+
+	return nil
+}
+
+func (e EventDispatcherMiddleware) trace(ctx context.Context, events []interface{}) error {
+	if e.tracer == nil {
+		return e.eventBus.Dispatch(ctx, events)
+	}
+
+	ctx, span := e.tracer.Start(ctx, "dispatch events", tracing.WithAttributes(
+		tracing.Int("cqrs.event_count", len(events)),
+	))
+	defer span.End()
+
+	if err := e.eventBus.Dispatch(ctx, events); err != nil {
+		span.RecordError(err)
+
+		return err
+	}
+
+	return nil
+}
+
+func (e EventDispatcherMiddleware) split(events []interface{}) ([]interface{}, []interface{}) {
+	if e.defaultTiming == DispatchInTransaction && len(e.timings) == 0 {
+		return events, nil
+	}
+
+	inTransactionEvents := make([]interface{}, 0, len(events))
+	afterCommitEvents := make([]interface{}, 0)
+
+	for _, event := range events {
+		timing, ok := e.timings[reflect.TypeOf(event)]
+		if !ok {
+			timing = e.defaultTiming
+		}
+
+		if timing == DispatchAfterCommit {
+			afterCommitEvents = append(afterCommitEvents, event)
+			continue
+		}
+
+		inTransactionEvents = append(inTransactionEvents, event)
+	}
+
+	return inTransactionEvents, afterCommitEvents
+}
+
+func (e EventDispatcherMiddleware) dispatchAfterCommit(ctx context.Context, command interface{}, events []interface{}) {
+	if err := e.dispatch(ctx, command, events); err != nil {
+		e.afterCommitErrorHandler(ctx, err)
+	}
+}
+
+func (e EventDispatcherMiddleware) dispatchEvents(ctx context.Context, command interface{}, events []interface{}) error {
+	inTransactionEvents, afterCommitEvents := e.split(events)
+
+	inTransaction := true
+
+	if len(afterCommitEvents) > 0 {
+		// hooks run with the context of the transaction, which may have started before the command.
+		report, _ := getDispatchReporter(ctx)
+		err := db.OnAfterCommit(ctx, func(ctx context.Context) {
+			e.dispatchAfterCommit(withDispatchReporter(ctx, report), command, afterCommitEvents)
+		})
+		if errors.Is(err, db.ErrNoActiveTransaction) {
+			inTransaction = false
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if err := e.dispatch(ctx, command, inTransactionEvents); err != nil {
+		return err
+	}
+
+	// without a transaction, the command is complete and can still fail.
+	if !inTransaction {
+		if err := e.dispatch(ctx, command, afterCommitEvents); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (e EventDispatcherMiddleware) CommandMiddleware() cqrs.CommandMiddlewareFunc {
 	return func(handler cqrs.CommandHandlerFunc[any]) cqrs.CommandHandlerFunc[any] {
 		return func(ctx context.Context, command any) error {
-			if err := handler(ctx, command); err != nil {
-				return err
-			}
-
 			eventProvider, ok := cqrs.GetEventProvider(ctx)
 			if !ok {
+				if err := handler(ctx, command); err != nil {
+					return err
+				}
+
 				return cqrs.ErrEventProviderNotFound
 			}
 
-			if err := e.eventBus.Dispatch(ctx, eventProvider.GetEvents()); err != nil {
+			binder := &eventDispatchBinder{
+				dispatcher:    e,
+				command:       command,
+				eventProvider: eventProvider,
+			}
+
+			if err := handler(withEventDispatchBinder(ctx, binder), command); err != nil {
+				return err
+			}
+
+			// the transaction started after this middleware has already dispatched the events.
+			if binder.isBound() {
+				return nil
+			}
+
+			if err := e.dispatchEvents(ctx, command, eventProvider.GetEvents()); err != nil {
 				return err
 			}
 
@@ -36,3 +235,49 @@ func (e EventDispatcherMiddleware) CommandMiddleware() cqrs.CommandMiddlewareFun
 		}
 	}
 }
+
+// eventDispatchBinder dispatches the events in the transaction started after EventDispatcherMiddleware.
+type eventDispatchBinder struct {
+	mu            sync.Mutex
+	bound         bool
+	dispatcher    EventDispatcherMiddleware
+	command       interface{}
+	eventProvider cqrs.EventProvider
+}
+
+func (e *eventDispatchBinder) isBound() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.bound
+}
+
+func (e *eventDispatchBinder) markBound() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.bound = true
+}
+
+func (e *eventDispatchBinder) bind(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.bound {
+		return nil
+	}
+
+	err := db.OnBeforeCommit(ctx, func(ctx context.Context) error {
+		return e.dispatcher.dispatchEvents(ctx, e.command, e.eventProvider.GetEvents())
+	})
+	if errors.Is(err, db.ErrNoActiveTransaction) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	e.bound = true
+
+	return nil
+}
@@ -3,14 +3,21 @@ package middlewares_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 
 	"github.com/vulpes-ferrilata/cqrs"
+	"github.com/vulpes-ferrilata/cqrs/internal/testdb"
 	"github.com/vulpes-ferrilata/cqrs/middlewares"
 	mock_cqrs "github.com/vulpes-ferrilata/cqrs/mocks"
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+	db_gorm "github.com/vulpes-ferrilata/cqrs/pkg/db/gorm"
+	db_memory "github.com/vulpes-ferrilata/cqrs/pkg/db/memory"
+	"github.com/vulpes-ferrilata/cqrs/pkg/metrics"
+	metrics_memory "github.com/vulpes-ferrilata/cqrs/pkg/metrics/memory"
 )
 
 func TestEventDispatcherMiddleware_CommandMiddleware(t *testing.T) {
@@ -122,3 +129,417 @@ func TestEventDispatcherMiddleware_CommandMiddleware(t *testing.T) {
 		})
 	}
 }
+
+type (
+	AccountOpened    struct{}
+	WelcomeEmailSent struct{}
+	OpenAccount      struct {
+		Nested bool
+		Fail   bool
+	}
+)
+
+func TestEventDispatcherMiddleware_DispatchTiming(t *testing.T) {
+	t.Parallel()
+
+	var (
+		Err = errors.New("error")
+	)
+
+	type args struct {
+		transaction     bool
+		dispatcherFirst bool
+		command         OpenAccount
+	}
+	type wants struct {
+		calls []string
+		err   error
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "no transaction",
+			args: args{
+				transaction: false,
+				command:     OpenAccount{},
+			},
+			wants: wants{
+				calls: []string{"in transaction: false", "after commit: false"},
+				err:   nil,
+			},
+		},
+		{
+			name: "dispatcher used after transaction middleware",
+			args: args{
+				transaction:     true,
+				dispatcherFirst: false,
+				command:         OpenAccount{},
+			},
+			wants: wants{
+				calls: []string{"in transaction: true", "after commit: false"},
+				err:   nil,
+			},
+		},
+		{
+			name: "dispatcher used before transaction middleware",
+			args: args{
+				transaction:     true,
+				dispatcherFirst: true,
+				command:         OpenAccount{},
+			},
+			wants: wants{
+				calls: []string{"in transaction: true", "after commit: false"},
+				err:   nil,
+			},
+		},
+		{
+			name: "nested command",
+			args: args{
+				transaction:     true,
+				dispatcherFirst: true,
+				command:         OpenAccount{Nested: true},
+			},
+			wants: wants{
+				calls: []string{"in transaction: true", "after commit: false"},
+				err:   nil,
+			},
+		},
+		{
+			name: "nested command rolled back by outer command",
+			args: args{
+				transaction:     true,
+				dispatcherFirst: false,
+				command:         OpenAccount{Nested: true, Fail: true},
+			},
+			wants: wants{
+				calls: []string{"in transaction: true"},
+				err:   Err,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gormDB := testdb.NewGorm(t, &Note{})
+
+			transactionManager := db_gorm.NewTransactionManager(gormDB, nil)
+
+			calls := make([]string, 0)
+
+			eventBus := cqrs.NewEventBus()
+			err := cqrs.RegisterEventHandler(eventBus, func(ctx context.Context, event AccountOpened) error {
+				calls = append(calls, fmt.Sprintf("in transaction: %t", db.InTransaction(ctx)))
+				return nil
+			})
+			assert.NoError(t, err)
+			err = cqrs.RegisterEventHandler(eventBus, func(ctx context.Context, event WelcomeEmailSent) error {
+				calls = append(calls, fmt.Sprintf("after commit: %t", db.InTransaction(ctx)))
+				return nil
+			})
+			assert.NoError(t, err)
+
+			eventDispatcherMiddleware := middlewares.NewEventDispatcherMiddleware(eventBus,
+				middlewares.WithDispatchTiming(WelcomeEmailSent{}, middlewares.DispatchAfterCommit),
+				middlewares.WithAfterCommitErrorHandler(func(ctx context.Context, err error) {
+					assert.NoError(t, err)
+				}),
+			)
+
+			commandBus := cqrs.NewCommandBus()
+			switch {
+			case !tt.args.transaction:
+				commandBus.Use(
+					middlewares.NewEventProviderMiddleware().CommandMiddleware(),
+					eventDispatcherMiddleware.CommandMiddleware(),
+				)
+			case tt.args.dispatcherFirst:
+				commandBus.Use(
+					middlewares.NewEventProviderMiddleware().CommandMiddleware(),
+					eventDispatcherMiddleware.CommandMiddleware(),
+					middlewares.NewTransactionMiddleware(transactionManager).CommandMiddleware(),
+				)
+			default:
+				commandBus.Use(
+					middlewares.NewTransactionMiddleware(transactionManager).CommandMiddleware(),
+					middlewares.NewEventProviderMiddleware().CommandMiddleware(),
+					eventDispatcherMiddleware.CommandMiddleware(),
+				)
+			}
+			err = cqrs.RegisterCommandHandler(commandBus, func(ctx context.Context, command OpenAccount) error {
+				if command.Nested {
+					if err := commandBus.Execute(ctx, OpenAccount{}); err != nil {
+						return err
+					}
+
+					// events of the nested command dispatched after commit wait for the outer transaction.
+					assert.Equal(t, []string{"in transaction: true"}, calls)
+
+					if command.Fail {
+						return Err
+					}
+
+					return nil
+				}
+
+				eventProvider, _ := cqrs.GetEventProvider(ctx)
+				eventProvider.CollectEvents(WelcomeEmailSent{}, AccountOpened{})
+
+				return nil
+			})
+			assert.NoError(t, err)
+
+			err = commandBus.Execute(context.Background(), tt.args.command)
+			assert.ErrorIs(t, err, tt.wants.err)
+			assert.Equal(t, tt.wants.calls, calls)
+		})
+	}
+}
+
+func TestEventDispatcherMiddleware_AfterCommitErrorHandler(t *testing.T) {
+	t.Parallel()
+
+	var (
+		Err = errors.New("error")
+	)
+
+	type wants struct {
+		err  error
+		errs []error
+	}
+	tests := []struct {
+		name          string
+		inTransaction bool
+		wants         wants
+	}{
+		{
+			name:          "without transaction error is returned",
+			inTransaction: false,
+			wants: wants{
+				err:  Err,
+				errs: []error{},
+			},
+		},
+		{
+			name:          "after commit error is handled",
+			inTransaction: true,
+			wants: wants{
+				err:  nil,
+				errs: []error{Err},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			eventBus := mock_cqrs.NewMockEventBus(mockCtrl)
+			gomock.InOrder(
+				eventBus.EXPECT().Dispatch(gomock.Any(), []interface{}{}).Return(nil),
+				eventBus.EXPECT().Dispatch(gomock.Any(), []interface{}{WelcomeEmailSent{}}).Return(Err),
+			)
+
+			errs := make([]error, 0)
+
+			eventDispatcherMiddleware := middlewares.NewEventDispatcherMiddleware(eventBus,
+				middlewares.WithDefaultDispatchTiming(middlewares.DispatchAfterCommit),
+				middlewares.WithAfterCommitErrorHandler(func(ctx context.Context, err error) {
+					errs = append(errs, err)
+				}),
+			)
+			handler := eventDispatcherMiddleware.CommandMiddleware()(func(ctx context.Context, command interface{}) error {
+				eventProvider, _ := cqrs.GetEventProvider(ctx)
+				eventProvider.CollectEvents(WelcomeEmailSent{})
+				return nil
+			})
+
+			ctx := cqrs.WithEventProvider(context.Background(), cqrs.NewEventProvider())
+
+			if !tt.inTransaction {
+				err := handler(ctx, struct{}{})
+				assert.ErrorIs(t, err, tt.wants.err)
+				assert.Equal(t, tt.wants.errs, errs)
+				return
+			}
+
+			transactionManager := db_memory.NewTransactionManager(db_memory.NewStore())
+			committer, txCtx, err := transactionManager.StartTransaction(ctx)
+			assert.NoError(t, err)
+			err = handler(txCtx, struct{}{})
+			assert.ErrorIs(t, err, tt.wants.err)
+			err = committer.CommitTransaction(txCtx)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wants.errs, errs)
+		})
+	}
+}
+
+func TestEventDispatcherMiddleware_Metrics(t *testing.T) {
+	t.Parallel()
+
+	var (
+		events = []interface{}{
+			AccountOpened{},
+			AccountOpened{},
+			WelcomeEmailSent{},
+		}
+		Err = errors.New("error")
+	)
+
+	type wants struct {
+		accountOpened    float64
+		welcomeEmailSent float64
+		err              error
+	}
+	tests := []struct {
+		name        string
+		dispatchErr error
+		wants       wants
+	}{
+		{
+			name:        "dispatch event fail",
+			dispatchErr: Err,
+			wants: wants{
+				accountOpened:    0,
+				welcomeEmailSent: 0,
+				err:              Err,
+			},
+		},
+		{
+			name:        "success",
+			dispatchErr: nil,
+			wants: wants{
+				accountOpened:    2,
+				welcomeEmailSent: 1,
+				err:              nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			eventBus := mock_cqrs.NewMockEventBus(mockCtrl)
+			eventBus.EXPECT().Dispatch(gomock.Any(), events).Return(tt.dispatchErr)
+
+			sink := metrics_memory.NewSink()
+
+			eventDispatcherMiddleware := middlewares.NewEventDispatcherMiddleware(eventBus,
+				middlewares.WithEventDispatcherMetrics(sink),
+			)
+			handler := eventDispatcherMiddleware.CommandMiddleware()(func(ctx context.Context, command interface{}) error {
+				eventProvider, _ := cqrs.GetEventProvider(ctx)
+				eventProvider.CollectEvents(events...)
+				return nil
+			})
+			err := handler(cqrs.WithEventProvider(context.Background(), cqrs.NewEventProvider()), OpenAccount{})
+			assert.ErrorIs(t, err, tt.wants.err)
+			assert.Equal(t, tt.wants.accountOpened, sink.Counter(middlewares.EventsDispatchedTotal, metrics.Labels{
+				"command_type": "github.com/vulpes-ferrilata/cqrs/middlewares_test.OpenAccount",
+				"event_type":   "github.com/vulpes-ferrilata/cqrs/middlewares_test.AccountOpened",
+			}))
+			assert.Equal(t, tt.wants.welcomeEmailSent, sink.Counter(middlewares.EventsDispatchedTotal, metrics.Labels{
+				"command_type": "github.com/vulpes-ferrilata/cqrs/middlewares_test.OpenAccount",
+				"event_type":   "github.com/vulpes-ferrilata/cqrs/middlewares_test.WelcomeEmailSent",
+			}))
+		})
+	}
+}
+
+type OpenIndependentAccount struct{}
+
+func TestEventDispatcherMiddleware_RequiresNew(t *testing.T) {
+	t.Parallel()
+
+	Err := errors.New("error")
+
+	transactionManager := db_memory.NewTransactionManager(db_memory.NewStore())
+
+	calls := make([]string, 0)
+
+	eventBus := cqrs.NewEventBus()
+	err := cqrs.RegisterEventHandler(eventBus, func(ctx context.Context, event AccountOpened) error {
+		err := transactionManager.GetTransaction(ctx).Put("accounts", "1", "opened")
+		assert.NoError(t, err)
+
+		calls = append(calls, fmt.Sprintf("in transaction: %t", db.InTransaction(ctx)))
+		return nil
+	})
+	assert.NoError(t, err)
+	err = cqrs.RegisterEventHandler(eventBus, func(ctx context.Context, event WelcomeEmailSent) error {
+		calls = append(calls, fmt.Sprintf("after commit: %t", db.InTransaction(ctx)))
+		return nil
+	})
+	assert.NoError(t, err)
+
+	commandBus := cqrs.NewCommandBus()
+	commandBus.Use(
+		middlewares.NewEventProviderMiddleware().CommandMiddleware(),
+		middlewares.NewEventDispatcherMiddleware(eventBus,
+			middlewares.WithDispatchTiming(WelcomeEmailSent{}, middlewares.DispatchAfterCommit),
+			middlewares.WithAfterCommitErrorHandler(func(ctx context.Context, err error) {
+				assert.NoError(t, err)
+			}),
+		).CommandMiddleware(),
+		middlewares.NewTransactionMiddleware(transactionManager,
+			middlewares.WithPropagation(OpenIndependentAccount{}, middlewares.PropagationRequiresNew),
+		).CommandMiddleware(),
+	)
+	err = cqrs.RegisterCommandHandler(commandBus, func(ctx context.Context, command OpenAccount) error {
+		if err := commandBus.Execute(ctx, OpenIndependentAccount{}); err != nil {
+			return err
+		}
+
+		// the events of the independent command are dispatched with its own transaction.
+		assert.Equal(t, []string{"in transaction: true", "after commit: false"}, calls)
+
+		return Err
+	})
+	assert.NoError(t, err)
+	err = cqrs.RegisterCommandHandler(commandBus, func(ctx context.Context, command OpenIndependentAccount) error {
+		eventProvider, _ := cqrs.GetEventProvider(ctx)
+		eventProvider.CollectEvents(WelcomeEmailSent{}, AccountOpened{})
+
+		return nil
+	})
+	assert.NoError(t, err)
+
+	err = commandBus.Execute(context.Background(), OpenAccount{})
+	assert.ErrorIs(t, err, Err)
+	assert.Equal(t, []string{"in transaction: true", "after commit: false"}, calls)
+
+	// the changes of the in transaction handler are committed with the independent command.
+	_, ok, err := transactionManager.GetTransaction(context.Background()).Get("accounts", "1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestNewEventDispatcherMiddleware(t *testing.T) {
+	t.Parallel()
+
+	eventBus := cqrs.NewEventBus()
+	errorHandler := func(ctx context.Context, err error) {}
+
+	assert.NotPanics(t, func() {
+		middlewares.NewEventDispatcherMiddleware(eventBus)
+	})
+	assert.NotPanics(t, func() {
+		middlewares.NewEventDispatcherMiddleware(eventBus,
+			middlewares.WithDefaultDispatchTiming(middlewares.DispatchAfterCommit),
+			middlewares.WithAfterCommitErrorHandler(errorHandler),
+		)
+	})
+	assert.PanicsWithValue(t, "dispatching events after commit requires an after commit error handler", func() {
+		middlewares.NewEventDispatcherMiddleware(eventBus,
+			middlewares.WithDefaultDispatchTiming(middlewares.DispatchAfterCommit),
+		)
+	})
+	assert.PanicsWithValue(t, "dispatching events after commit requires an after commit error handler", func() {
+		middlewares.NewEventDispatcherMiddleware(eventBus,
+			middlewares.WithDispatchTiming(WelcomeEmailSent{}, middlewares.DispatchAfterCommit),
+		)
+	})
+}
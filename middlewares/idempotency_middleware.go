@@ -0,0 +1,171 @@
+package middlewares
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/vulpes-ferrilata/cqrs"
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+	"github.com/vulpes-ferrilata/cqrs/pkg/idempotency"
+)
+
+type IdempotentCommand interface {
+	IdempotencyKey() string
+}
+
+type IdempotencyConflictError struct {
+	MessageType string
+	Key         string
+}
+
+func (i IdempotencyConflictError) Error() string {
+	return fmt.Sprintf("idempotency key %q of %s is already used by another command", i.Key, i.MessageType)
+}
+
+func (i IdempotencyConflictError) Unwrap() error {
+	return cqrs.ErrIdempotencyKeyConflict
+}
+
+// IdempotencyInProgressError is a concurrency conflict with an execution of the same key.
+type IdempotencyInProgressError struct {
+	MessageType string
+	Key         string
+}
+
+func (i IdempotencyInProgressError) Error() string {
+	return fmt.Sprintf("idempotency key %q of %s is used by a concurrent command", i.Key, i.MessageType)
+}
+
+func (i IdempotencyInProgressError) Unwrap() error {
+	return cqrs.ErrConcurrencyConflict
+}
+
+func NewIdempotencyMiddleware(store idempotency.Store) *IdempotencyMiddleware {
+	return &IdempotencyMiddleware{
+		store: store,
+	}
+}
+
+// IdempotencyMiddleware must be used after TransactionMiddleware, commands with a key fail with
+// db.ErrNoActiveTransaction otherwise. The key is taken from the IdempotencyKey method,
+// a string field tagged with `cqrs:"idempotency_key"` or the context, in that order.
+type IdempotencyMiddleware struct {
+	store idempotency.Store
+}
+
+func idempotencyKey(ctx context.Context, command interface{}) (string, bool) {
+	if idempotentCommand, ok := command.(IdempotentCommand); ok {
+		key := idempotentCommand.IdempotencyKey()
+		return key, key != ""
+	}
+
+	value := reflect.ValueOf(command)
+	for value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+
+	if value.Kind() == reflect.Struct {
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			if hasTagOption(field, "idempotency_key") && field.Type.Kind() == reflect.String {
+				key := value.Field(i).String()
+				return key, key != ""
+			}
+		}
+	}
+
+	return cqrs.GetIdempotencyKey(ctx)
+}
+
+func fingerprint(command interface{}) (string, error) {
+	key, err := messageKey(command)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (i IdempotencyMiddleware) CommandMiddleware() cqrs.CommandMiddlewareFunc {
+	return func(handler cqrs.CommandHandlerFunc[any]) cqrs.CommandHandlerFunc[any] {
+		return func(ctx context.Context, command any) error {
+			key, ok := idempotencyKey(ctx, command)
+			outcome, hasOutcome := cqrs.GetIdempotencyOutcome(ctx)
+
+			// nested commands must not inherit the key and the outcome.
+			ctx = cqrs.WithIdempotencyKey(ctx, "")
+			ctx = cqrs.WithIdempotencyOutcome(ctx, nil)
+
+			if !ok {
+				return handler(ctx, command)
+			}
+
+			// the record is only atomic with the command in its transaction.
+			if !db.InTransaction(ctx) {
+				return db.ErrNoActiveTransaction
+			}
+
+			messageType := messageType(command)
+
+			fingerprint, err := fingerprint(command)
+			if err != nil {
+				return err
+			}
+
+			record, ok, err := i.store.Find(ctx, messageType, key)
+			if err != nil {
+				return err
+			}
+
+			if ok {
+				if record.Fingerprint != fingerprint {
+					return IdempotencyConflictError{
+						MessageType: messageType,
+						Key:         key,
+					}
+				}
+
+				if hasOutcome {
+					outcome.Observe(true, record.CreatedAt)
+				}
+
+				return nil
+			}
+
+			if err := handler(ctx, command); err != nil {
+				return err
+			}
+
+			executedAt := time.Now()
+
+			err = i.store.Save(ctx, idempotency.Record{
+				MessageType: messageType,
+				Key:         key,
+				Fingerprint: fingerprint,
+				CreatedAt:   executedAt,
+			})
+			if errors.Is(err, idempotency.ErrRecordAlreadyExists) {
+				return IdempotencyInProgressError{
+					MessageType: messageType,
+					Key:         key,
+				}
+			}
+			if err != nil {
+				return err
+			}
+
+			if hasOutcome {
+				outcome.Observe(false, executedAt)
+			}
+
+			return nil
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package middlewares_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/vulpes-ferrilata/cqrs"
+	"github.com/vulpes-ferrilata/cqrs/middlewares"
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+	db_memory "github.com/vulpes-ferrilata/cqrs/pkg/db/memory"
+	"github.com/vulpes-ferrilata/cqrs/pkg/idempotency"
+	mock_idempotency "github.com/vulpes-ferrilata/cqrs/pkg/idempotency/mocks"
+)
+
+type (
+	PlaceOrderCommand struct {
+		RequestID string `cqrs:"idempotency_key"`
+		Amount    int
+	}
+	CancelOrderCommand struct {
+		OrderID string
+	}
+)
+
+func (c CancelOrderCommand) IdempotencyKey() string {
+	return "cancel-" + c.OrderID
+}
+
+func TestIdempotencyMiddleware_CommandMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		placeOrderType  = "github.com/vulpes-ferrilata/cqrs/middlewares_test.PlaceOrderCommand"
+		cancelOrderType = "github.com/vulpes-ferrilata/cqrs/middlewares_test.CancelOrderCommand"
+		placeOrder      = PlaceOrderCommand{RequestID: "request-id", Amount: 1}
+		cancelOrder     = CancelOrderCommand{OrderID: "order-id"}
+		placeOrderHash  = sha256.Sum256([]byte(`{"RequestID":"request-id","Amount":1}`))
+		executedAt      = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+		Err             = errors.New("error")
+	)
+
+	_, transactionCtx, err := db_memory.NewTransactionManager(db_memory.NewStore()).StartTransaction(context.Background())
+	assert.NoError(t, err)
+
+	type mocks struct {
+		store *mock_idempotency.MockStore
+	}
+	type args struct {
+		ctx     context.Context
+		command interface{}
+		handler cqrs.CommandHandlerFunc[any]
+	}
+	type wants struct {
+		replayed bool
+		err      error
+	}
+	tests := []struct {
+		name    string
+		prepare func(mocks mocks)
+		args    args
+		wants   wants
+	}{
+		{
+			name:    "command without idempotency key",
+			prepare: func(mocks mocks) {},
+			args: args{
+				ctx:     context.Background(),
+				command: struct{}{},
+				handler: func(ctx context.Context, command interface{}) error {
+					return nil
+				},
+			},
+			wants: wants{
+				replayed: false,
+				err:      nil,
+			},
+		},
+		{
+			name:    "no active transaction",
+			prepare: func(mocks mocks) {},
+			args: args{
+				ctx:     context.Background(),
+				command: placeOrder,
+				handler: func(ctx context.Context, command interface{}) error {
+					return nil
+				},
+			},
+			wants: wants{
+				replayed: false,
+				err:      db.ErrNoActiveTransaction,
+			},
+		},
+		{
+			name: "find fail",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().Find(gomock.Any(), placeOrderType, "request-id").Return(idempotency.Record{}, false, Err)
+			},
+			args: args{
+				ctx:     transactionCtx,
+				command: placeOrder,
+				handler: func(ctx context.Context, command interface{}) error {
+					return nil
+				},
+			},
+			wants: wants{
+				replayed: false,
+				err:      Err,
+			},
+		},
+		{
+			name: "replayed command",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().Find(gomock.Any(), placeOrderType, "request-id").Return(idempotency.Record{
+					MessageType: placeOrderType,
+					Key:         "request-id",
+					Fingerprint: hex.EncodeToString(placeOrderHash[:]),
+					CreatedAt:   executedAt,
+				}, true, nil)
+			},
+			args: args{
+				ctx:     transactionCtx,
+				command: placeOrder,
+				handler: func(ctx context.Context, command interface{}) error {
+					return Err
+				},
+			},
+			wants: wants{
+				replayed: true,
+				err:      nil,
+			},
+		},
+		{
+			name: "key reused with different payload",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().Find(gomock.Any(), placeOrderType, "request-id").Return(idempotency.Record{
+					MessageType: placeOrderType,
+					Key:         "request-id",
+					Fingerprint: "other",
+				}, true, nil)
+			},
+			args: args{
+				ctx:     transactionCtx,
+				command: placeOrder,
+				handler: func(ctx context.Context, command interface{}) error {
+					return nil
+				},
+			},
+			wants: wants{
+				replayed: false,
+				err: middlewares.IdempotencyConflictError{
+					MessageType: placeOrderType,
+					Key:         "request-id",
+				},
+			},
+		},
+		{
+			name: "handler return error",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().Find(gomock.Any(), cancelOrderType, "cancel-order-id").Return(idempotency.Record{}, false, nil)
+			},
+			args: args{
+				ctx:     transactionCtx,
+				command: cancelOrder,
+				handler: func(ctx context.Context, command interface{}) error {
+					return Err
+				},
+			},
+			wants: wants{
+				replayed: false,
+				err:      Err,
+			},
+		},
+		{
+			name: "concurrent execution saved first",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().Find(gomock.Any(), cancelOrderType, "cancel-order-id").Return(idempotency.Record{}, false, nil)
+				mocks.store.EXPECT().Save(gomock.Any(), gomock.Any()).Return(idempotency.ErrRecordAlreadyExists)
+			},
+			args: args{
+				ctx:     transactionCtx,
+				command: cancelOrder,
+				handler: func(ctx context.Context, command interface{}) error {
+					return nil
+				},
+			},
+			wants: wants{
+				replayed: false,
+				err: middlewares.IdempotencyInProgressError{
+					MessageType: cancelOrderType,
+					Key:         "cancel-order-id",
+				},
+			},
+		},
+		{
+			name: "save fail",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().Find(gomock.Any(), cancelOrderType, "cancel-order-id").Return(idempotency.Record{}, false, nil)
+				mocks.store.EXPECT().Save(gomock.Any(), gomock.Any()).Return(Err)
+			},
+			args: args{
+				ctx:     transactionCtx,
+				command: cancelOrder,
+				handler: func(ctx context.Context, command interface{}) error {
+					return nil
+				},
+			},
+			wants: wants{
+				replayed: false,
+				err:      Err,
+			},
+		},
+		{
+			name: "key from context is not inherited by nested commands",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().Find(gomock.Any(), "struct {}", "context-key").Return(idempotency.Record{}, false, nil)
+				mocks.store.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, record idempotency.Record) error {
+					assert.Equal(t, "struct {}", record.MessageType)
+					assert.Equal(t, "context-key", record.Key)
+					assert.NotEmpty(t, record.Fingerprint)
+					assert.False(t, record.CreatedAt.IsZero())
+					return nil
+				})
+			},
+			args: args{
+				ctx:     cqrs.WithIdempotencyKey(transactionCtx, "context-key"),
+				command: struct{}{},
+				handler: func(ctx context.Context, command interface{}) error {
+					_, ok := cqrs.GetIdempotencyKey(ctx)
+					assert.False(t, ok)
+					_, ok = cqrs.GetIdempotencyOutcome(ctx)
+					assert.False(t, ok)
+					return nil
+				},
+			},
+			wants: wants{
+				replayed: false,
+				err:      nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := mocks{
+				store: mock_idempotency.NewMockStore(mockCtrl),
+			}
+
+			tt.prepare(mocks)
+
+			idempotencyMiddleware := middlewares.NewIdempotencyMiddleware(mocks.store)
+			commandMiddleware := idempotencyMiddleware.CommandMiddleware()
+			handler := commandMiddleware(tt.args.handler)
+			outcome := cqrs.NewIdempotencyOutcome()
+			err := handler(cqrs.WithIdempotencyOutcome(tt.args.ctx, outcome), tt.args.command)
+			assert.ErrorIs(t, err, tt.wants.err)
+			assert.Equal(t, tt.wants.replayed, outcome.Replayed())
+			if tt.wants.replayed {
+				assert.Equal(t, executedAt, outcome.ExecutedAt())
+			}
+		})
+	}
+}
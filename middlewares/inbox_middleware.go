@@ -0,0 +1,95 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vulpes-ferrilata/cqrs"
+	"github.com/vulpes-ferrilata/cqrs/pkg/inbox"
+)
+
+type IdentifiableEvent interface {
+	EventID() string
+}
+
+// InboxInProgressError is a concurrency conflict with a delivery of the same event to the handler.
+type InboxInProgressError struct {
+	HandlerName string
+	EventID     string
+}
+
+func (i InboxInProgressError) Error() string {
+	return fmt.Sprintf("event %q is handled by a concurrent delivery to %s", i.EventID, i.HandlerName)
+}
+
+func (i InboxInProgressError) Unwrap() error {
+	return cqrs.ErrConcurrencyConflict
+}
+
+func NewInboxMiddleware(store inbox.Store) *InboxMiddleware {
+	return &InboxMiddleware{
+		store: store,
+	}
+}
+
+// InboxMiddleware must be used after TransactionMiddleware.
+// Only handlers registered with cqrs.RegisterNamedEventHandler are deduplicated, other handlers run on every delivery.
+type InboxMiddleware struct {
+	store inbox.Store
+}
+
+func eventID(ctx context.Context, event interface{}) (string, bool) {
+	if identifiableEvent, ok := event.(IdentifiableEvent); ok {
+		eventID := identifiableEvent.EventID()
+		return eventID, eventID != ""
+	}
+
+	return cqrs.GetEventID(ctx)
+}
+
+func (i InboxMiddleware) EventMiddleware() cqrs.EventMiddlewareFunc {
+	return func(handler cqrs.EventHandlerFunc[any]) cqrs.EventHandlerFunc[any] {
+		return func(ctx context.Context, event any) error {
+			eventID, ok := eventID(ctx, event)
+			if !ok {
+				return handler(ctx, event)
+			}
+
+			handlerName, ok := cqrs.GetEventHandlerName(ctx)
+			if !ok {
+				return handler(ctx, event)
+			}
+
+			// nested commands must not inherit the event id.
+			ctx = cqrs.WithEventID(ctx, "")
+
+			isProcessed, err := i.store.Exists(ctx, handlerName, eventID)
+			if err != nil {
+				return err
+			}
+
+			if isProcessed {
+				return nil
+			}
+
+			if err := handler(ctx, event); err != nil {
+				return err
+			}
+
+			err = i.store.Save(ctx, handlerName, eventID, time.Now())
+			if errors.Is(err, inbox.ErrRecordAlreadyExists) {
+				return InboxInProgressError{
+					HandlerName: handlerName,
+					EventID:     eventID,
+				}
+			}
+			if err != nil {
+				return err
+			}
+
+			return nil
+		}
+	}
+}
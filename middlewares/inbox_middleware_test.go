@@ -0,0 +1,247 @@
+package middlewares_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/vulpes-ferrilata/cqrs"
+	"github.com/vulpes-ferrilata/cqrs/middlewares"
+	"github.com/vulpes-ferrilata/cqrs/pkg/inbox"
+	mock_inbox "github.com/vulpes-ferrilata/cqrs/pkg/inbox/mocks"
+)
+
+type (
+	DeliveredEvent  struct{}
+	UnnamedEvent    struct{}
+	IdentifiedEvent struct {
+		ID string
+	}
+)
+
+func (i IdentifiedEvent) EventID() string {
+	return i.ID
+}
+
+func TestInboxMiddleware_EventMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		handlerName = "handler"
+		Err         = errors.New("error")
+	)
+
+	type mocks struct {
+		store *mock_inbox.MockStore
+	}
+	type args struct {
+		ctx     context.Context
+		event   interface{}
+		handler func(ctx context.Context, event interface{}) error
+	}
+	type wants struct {
+		called bool
+		err    error
+	}
+	tests := []struct {
+		name    string
+		prepare func(mocks mocks)
+		args    args
+		wants   wants
+	}{
+		{
+			name:    "event without id",
+			prepare: func(mocks mocks) {},
+			args: args{
+				ctx:   context.Background(),
+				event: DeliveredEvent{},
+				handler: func(ctx context.Context, event interface{}) error {
+					return nil
+				},
+			},
+			wants: wants{
+				called: true,
+				err:    nil,
+			},
+		},
+		{
+			name:    "handler without name",
+			prepare: func(mocks mocks) {},
+			args: args{
+				ctx:   cqrs.WithEventID(context.Background(), "event-id"),
+				event: UnnamedEvent{},
+				handler: func(ctx context.Context, event interface{}) error {
+					return nil
+				},
+			},
+			wants: wants{
+				called: true,
+				err:    nil,
+			},
+		},
+		{
+			name: "exists fail",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().Exists(gomock.Any(), handlerName, "event-id").Return(false, Err)
+			},
+			args: args{
+				ctx:   cqrs.WithEventID(context.Background(), "event-id"),
+				event: DeliveredEvent{},
+				handler: func(ctx context.Context, event interface{}) error {
+					return nil
+				},
+			},
+			wants: wants{
+				called: false,
+				err:    Err,
+			},
+		},
+		{
+			name: "duplicated event",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().Exists(gomock.Any(), handlerName, "event-id").Return(true, nil)
+			},
+			args: args{
+				ctx:   cqrs.WithEventID(context.Background(), "event-id"),
+				event: DeliveredEvent{},
+				handler: func(ctx context.Context, event interface{}) error {
+					return nil
+				},
+			},
+			wants: wants{
+				called: false,
+				err:    nil,
+			},
+		},
+		{
+			name: "handler return error",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().Exists(gomock.Any(), handlerName, "event-id").Return(false, nil)
+			},
+			args: args{
+				ctx:   cqrs.WithEventID(context.Background(), "event-id"),
+				event: DeliveredEvent{},
+				handler: func(ctx context.Context, event interface{}) error {
+					return Err
+				},
+			},
+			wants: wants{
+				called: true,
+				err:    Err,
+			},
+		},
+		{
+			name: "save fail",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().Exists(gomock.Any(), handlerName, "event-id").Return(false, nil)
+				mocks.store.EXPECT().Save(gomock.Any(), handlerName, "event-id", gomock.Any()).Return(Err)
+			},
+			args: args{
+				ctx:   cqrs.WithEventID(context.Background(), "event-id"),
+				event: DeliveredEvent{},
+				handler: func(ctx context.Context, event interface{}) error {
+					return nil
+				},
+			},
+			wants: wants{
+				called: true,
+				err:    Err,
+			},
+		},
+		{
+			name: "concurrent delivery",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().Exists(gomock.Any(), handlerName, "event-id").Return(false, nil)
+				mocks.store.EXPECT().Save(gomock.Any(), handlerName, "event-id", gomock.Any()).Return(inbox.ErrRecordAlreadyExists)
+			},
+			args: args{
+				ctx:   cqrs.WithEventID(context.Background(), "event-id"),
+				event: DeliveredEvent{},
+				handler: func(ctx context.Context, event interface{}) error {
+					return nil
+				},
+			},
+			wants: wants{
+				called: true,
+				err:    middlewares.InboxInProgressError{HandlerName: handlerName, EventID: "event-id"},
+			},
+		},
+		{
+			name: "success",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().Exists(gomock.Any(), handlerName, "event-id").Return(false, nil)
+				mocks.store.EXPECT().Save(gomock.Any(), handlerName, "event-id", gomock.Any()).Return(nil)
+			},
+			args: args{
+				ctx:   cqrs.WithEventID(context.Background(), "event-id"),
+				event: DeliveredEvent{},
+				handler: func(ctx context.Context, event interface{}) error {
+					_, ok := cqrs.GetEventID(ctx)
+					assert.False(t, ok)
+					return nil
+				},
+			},
+			wants: wants{
+				called: true,
+				err:    nil,
+			},
+		},
+		{
+			name: "identifiable event",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().Exists(gomock.Any(), handlerName, "identified").Return(false, nil)
+				mocks.store.EXPECT().Save(gomock.Any(), handlerName, "identified", gomock.Any()).Return(nil)
+			},
+			args: args{
+				ctx:   cqrs.WithEventID(context.Background(), "event-id"),
+				event: IdentifiedEvent{ID: "identified"},
+				handler: func(ctx context.Context, event interface{}) error {
+					return nil
+				},
+			},
+			wants: wants{
+				called: true,
+				err:    nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := mocks{
+				store: mock_inbox.NewMockStore(mockCtrl),
+			}
+
+			tt.prepare(mocks)
+
+			called := false
+
+			eventBus := cqrs.NewEventBus()
+			eventBus.Use(middlewares.NewInboxMiddleware(mocks.store).EventMiddleware())
+			err := cqrs.RegisterNamedEventHandler(eventBus, handlerName, func(ctx context.Context, event DeliveredEvent) error {
+				called = true
+				return tt.args.handler(ctx, event)
+			})
+			assert.NoError(t, err)
+			err = cqrs.RegisterNamedEventHandler(eventBus, handlerName, func(ctx context.Context, event IdentifiedEvent) error {
+				called = true
+				return tt.args.handler(ctx, event)
+			})
+			assert.NoError(t, err)
+			err = cqrs.RegisterEventHandler(eventBus, func(ctx context.Context, event UnnamedEvent) error {
+				called = true
+				return tt.args.handler(ctx, event)
+			})
+			assert.NoError(t, err)
+
+			err = eventBus.Dispatch(tt.args.ctx, []interface{}{tt.args.event})
+			assert.ErrorIs(t, err, tt.wants.err)
+			assert.Equal(t, tt.wants.called, called)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package middlewares
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/vulpes-ferrilata/cqrs"
+)
+
+type LoggingOption func(l *LoggingMiddleware)
+
+func WithLoggingLevels(successLevel slog.Level, failureLevel slog.Level) LoggingOption {
+	return func(l *LoggingMiddleware) {
+		l.successLevel = successLevel
+		l.failureLevel = failureLevel
+	}
+}
+
+func WithSlowThreshold(slowThreshold time.Duration) LoggingOption {
+	return func(l *LoggingMiddleware) {
+		l.slowThreshold = slowThreshold
+	}
+}
+
+func WithoutMessagePayload() LoggingOption {
+	return func(l *LoggingMiddleware) {
+		l.logPayload = false
+	}
+}
+
+func NewLoggingMiddleware(logger *slog.Logger, opts ...LoggingOption) *LoggingMiddleware {
+	loggingMiddleware := &LoggingMiddleware{
+		logger:       logger,
+		successLevel: slog.LevelInfo,
+		failureLevel: slog.LevelError,
+		logPayload:   true,
+	}
+
+	for _, opt := range opts {
+		opt(loggingMiddleware)
+	}
+
+	return loggingMiddleware
+}
+
+type LoggingMiddleware struct {
+	logger        *slog.Logger
+	successLevel  slog.Level
+	failureLevel  slog.Level
+	slowThreshold time.Duration
+	logPayload    bool
+}
+
+func (l LoggingMiddleware) log(ctx context.Context, kind string, message interface{}, duration time.Duration, err error) {
+	level := l.successLevel
+	outcome := "success"
+	if err != nil {
+		level = l.failureLevel
+		outcome = "failure"
+	} else if l.slowThreshold > 0 && duration >= l.slowThreshold {
+		level = slog.LevelWarn
+		outcome = "slow"
+	}
+
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("message_type", messageType(message)),
+		slog.Duration("duration", duration),
+		slog.String("outcome", outcome),
+	}
+
+	if correlationID, ok := cqrs.GetCorrelationID(ctx); ok {
+		attrs = append(attrs, slog.String("correlation_id", correlationID))
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+
+	if l.logPayload {
+		attrs = append(attrs, slog.Any("payload", redactedMessage{message}))
+	}
+
+	l.logger.LogAttrs(ctx, level, kind+" "+outcome, attrs...)
+}
+
+func (l LoggingMiddleware) CommandMiddleware() cqrs.CommandMiddlewareFunc {
+	return func(handler cqrs.CommandHandlerFunc[any]) cqrs.CommandHandlerFunc[any] {
+		return func(ctx context.Context, command any) error {
+			start := time.Now()
+
+			err := handler(ctx, command)
+
+			l.log(ctx, "command", command, time.Since(start), err)
+
+			return err
+		}
+	}
+}
+
+func (l LoggingMiddleware) QueryMiddleware() cqrs.QueryMiddlewareFunc {
+	return func(handler cqrs.QueryHandlerFunc[any, any]) cqrs.QueryHandlerFunc[any, any] {
+		return func(ctx context.Context, query any) (interface{}, error) {
+			start := time.Now()
+
+			result, err := handler(ctx, query)
+
+			l.log(ctx, "query", query, time.Since(start), err)
+
+			if err != nil {
+				return nil, err
+			}
+
+			return result, nil
+		}
+	}
+}
+
+func (l LoggingMiddleware) EventMiddleware() cqrs.EventMiddlewareFunc {
+	return func(handler cqrs.EventHandlerFunc[any]) cqrs.EventHandlerFunc[any] {
+		return func(ctx context.Context, event any) error {
+			start := time.Now()
+
+			err := handler(ctx, event)
+
+			l.log(ctx, "event", event, time.Since(start), err)
+
+			return err
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package middlewares_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vulpes-ferrilata/cqrs"
+	"github.com/vulpes-ferrilata/cqrs/middlewares"
+)
+
+type (
+	Credentials struct {
+		Username string
+		Password string `cqrs:"sensitive"`
+	}
+	LoginCommand struct {
+		Credentials Credentials
+		Token       string `cqrs:"sensitive"`
+	}
+	NodeCommand struct {
+		Name     string
+		Secret   string `cqrs:"sensitive"`
+		Next     *NodeCommand
+		Children []*NodeCommand
+	}
+)
+
+func TestLoggingMiddleware_CommandMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		command = LoginCommand{
+			Credentials: Credentials{
+				Username: "user",
+				Password: "secret",
+			},
+			Token: "token",
+		}
+		Err = errors.New("error")
+	)
+
+	type args struct {
+		handler cqrs.CommandHandlerFunc[any]
+		ctx     context.Context
+	}
+	type wants struct {
+		record map[string]interface{}
+		err    error
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "handler return error",
+			args: args{
+				handler: func(ctx context.Context, command interface{}) error {
+					return Err
+				},
+				ctx: context.Background(),
+			},
+			wants: wants{
+				record: map[string]interface{}{
+					"level":        "ERROR",
+					"msg":          "command failure",
+					"message_type": "github.com/vulpes-ferrilata/cqrs/middlewares_test.LoginCommand",
+					"outcome":      "failure",
+					"error":        "error",
+				},
+				err: Err,
+			},
+		},
+		{
+			name: "slow execution",
+			args: args{
+				handler: func(ctx context.Context, command interface{}) error {
+					time.Sleep(20 * time.Millisecond)
+					return nil
+				},
+				ctx: context.Background(),
+			},
+			wants: wants{
+				record: map[string]interface{}{
+					"level":        "WARN",
+					"msg":          "command slow",
+					"message_type": "github.com/vulpes-ferrilata/cqrs/middlewares_test.LoginCommand",
+					"outcome":      "slow",
+				},
+				err: nil,
+			},
+		},
+		{
+			name: "success",
+			args: args{
+				handler: func(ctx context.Context, command interface{}) error {
+					return nil
+				},
+				ctx: cqrs.WithCorrelationID(context.Background(), "correlation-id"),
+			},
+			wants: wants{
+				record: map[string]interface{}{
+					"level":          "INFO",
+					"msg":            "command success",
+					"message_type":   "github.com/vulpes-ferrilata/cqrs/middlewares_test.LoginCommand",
+					"outcome":        "success",
+					"correlation_id": "correlation-id",
+				},
+				err: nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+			logger := slog.New(slog.NewJSONHandler(buffer, nil))
+
+			loggingMiddleware := middlewares.NewLoggingMiddleware(logger,
+				middlewares.WithSlowThreshold(10*time.Millisecond),
+			)
+			commandMiddleware := loggingMiddleware.CommandMiddleware()
+			handler := commandMiddleware(tt.args.handler)
+			err := handler(tt.args.ctx, command)
+			assert.ErrorIs(t, err, tt.wants.err)
+
+			record := make(map[string]interface{})
+			assert.NoError(t, json.Unmarshal(buffer.Bytes(), &record))
+			for key, value := range tt.wants.record {
+				assert.Equal(t, value, record[key])
+			}
+			assert.Equal(t, map[string]interface{}{
+				"Credentials": map[string]interface{}{
+					"Username": "user",
+					"Password": "[REDACTED]",
+				},
+				"Token": "[REDACTED]",
+			}, record["payload"])
+			assert.NotContains(t, buffer.String(), "secret")
+		})
+	}
+}
+
+func TestLoggingMiddleware_QueryMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		result = struct{}{}
+		Err    = errors.New("error")
+	)
+
+	type args struct {
+		handler cqrs.QueryHandlerFunc[any, any]
+	}
+	type wants struct {
+		level  string
+		result interface{}
+		err    error
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "handler return error",
+			args: args{
+				handler: func(ctx context.Context, query interface{}) (interface{}, error) {
+					return nil, Err
+				},
+			},
+			wants: wants{
+				level:  "WARN",
+				result: nil,
+				err:    Err,
+			},
+		},
+		{
+			name: "success",
+			args: args{
+				handler: func(ctx context.Context, query interface{}) (interface{}, error) {
+					return result, nil
+				},
+			},
+			wants: wants{
+				level:  "DEBUG",
+				result: result,
+				err:    nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+			logger := slog.New(slog.NewJSONHandler(buffer, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+			loggingMiddleware := middlewares.NewLoggingMiddleware(logger,
+				middlewares.WithLoggingLevels(slog.LevelDebug, slog.LevelWarn),
+				middlewares.WithoutMessagePayload(),
+			)
+			queryMiddleware := loggingMiddleware.QueryMiddleware()
+			handler := queryMiddleware(tt.args.handler)
+			result, err := handler(context.Background(), struct{}{})
+			assert.ErrorIs(t, err, tt.wants.err)
+			assert.Equal(t, tt.wants.result, result)
+
+			record := make(map[string]interface{})
+			assert.NoError(t, json.Unmarshal(buffer.Bytes(), &record))
+			assert.Equal(t, tt.wants.level, record["level"])
+			assert.NotContains(t, record, "payload")
+		})
+	}
+}
+
+func TestLoggingMiddleware_EventMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		Err = errors.New("error")
+	)
+
+	type args struct {
+		handler cqrs.EventHandlerFunc[any]
+	}
+	type wants struct {
+		msg string
+		err error
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "handler return error",
+			args: args{
+				handler: func(ctx context.Context, event interface{}) error {
+					return Err
+				},
+			},
+			wants: wants{
+				msg: "event failure",
+				err: Err,
+			},
+		},
+		{
+			name: "success",
+			args: args{
+				handler: func(ctx context.Context, event interface{}) error {
+					return nil
+				},
+			},
+			wants: wants{
+				msg: "event success",
+				err: nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+			logger := slog.New(slog.NewJSONHandler(buffer, nil))
+
+			loggingMiddleware := middlewares.NewLoggingMiddleware(logger)
+			eventMiddleware := loggingMiddleware.EventMiddleware()
+			handler := eventMiddleware(tt.args.handler)
+			err := handler(context.Background(), struct{}{})
+			assert.ErrorIs(t, err, tt.wants.err)
+
+			record := make(map[string]interface{})
+			assert.NoError(t, json.Unmarshal(buffer.Bytes(), &record))
+			assert.Equal(t, tt.wants.msg, record["msg"])
+		})
+	}
+}
+
+func TestLoggingMiddleware_CommandMiddleware_CyclicCommand(t *testing.T) {
+	t.Parallel()
+
+	shared := &NodeCommand{Name: "shared", Secret: "secret"}
+	command := &NodeCommand{Name: "root", Secret: "secret", Children: []*NodeCommand{shared, shared}}
+	command.Next = command
+
+	buffer := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buffer, nil))
+
+	loggingMiddleware := middlewares.NewLoggingMiddleware(logger)
+	commandMiddleware := loggingMiddleware.CommandMiddleware()
+	handler := commandMiddleware(func(ctx context.Context, command interface{}) error {
+		return nil
+	})
+	err := handler(context.Background(), command)
+	assert.NoError(t, err)
+
+	sharedRecord := map[string]interface{}{
+		"Name":     "shared",
+		"Secret":   "[REDACTED]",
+		"Next":     nil,
+		"Children": nil,
+	}
+	record := make(map[string]interface{})
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &record))
+	assert.Equal(t, map[string]interface{}{
+		"Name":   "root",
+		"Secret": "[REDACTED]",
+		"Next":   "[CYCLIC]",
+		"Children": map[string]interface{}{
+			"0": sharedRecord,
+			"1": sharedRecord,
+		},
+	}, record["payload"])
+	assert.NotContains(t, buffer.String(), "secret")
+}
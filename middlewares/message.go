@@ -0,0 +1,35 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+func messageType(message interface{}) string {
+	return typeName(reflect.TypeOf(message))
+}
+
+func typeName(t reflect.Type) string {
+	if t == nil {
+		return "<nil>"
+	}
+
+	if t.Kind() == reflect.Pointer {
+		return "*" + typeName(t.Elem())
+	}
+
+	if t.PkgPath() == "" || t.Name() == "" {
+		return t.String()
+	}
+
+	return t.PkgPath() + "." + t.Name()
+}
+
+func messageKey(message interface{}) (string, error) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,96 @@
+package middlewares
+
+import (
+	"context"
+	"time"
+
+	"github.com/vulpes-ferrilata/cqrs"
+	"github.com/vulpes-ferrilata/cqrs/pkg/metrics"
+)
+
+const (
+	MessagesTotal             = "cqrs_messages_total"
+	MessageDurationSeconds    = "cqrs_message_duration_seconds"
+	MessagesInFlight          = "cqrs_messages_in_flight"
+	EventsDispatchedTotal     = "cqrs_events_dispatched_total"
+	EventHandlerFailuresTotal = "cqrs_event_handler_failures_total"
+)
+
+func NewMetricsMiddleware(sink metrics.Sink) *MetricsMiddleware {
+	return &MetricsMiddleware{
+		sink: sink,
+	}
+}
+
+type MetricsMiddleware struct {
+	sink metrics.Sink
+}
+
+func (m MetricsMiddleware) observe(kind string, message interface{}, fn func() error) error {
+	messageType := messageType(message)
+	inFlightLabels := metrics.Labels{"kind": kind, "message_type": messageType}
+
+	m.sink.AddGauge(MessagesInFlight, inFlightLabels, 1)
+	defer m.sink.AddGauge(MessagesInFlight, inFlightLabels, -1)
+
+	start := time.Now()
+
+	err := fn()
+
+	outcome := "success"
+	if err != nil {
+		outcome = "failure"
+	}
+
+	labels := metrics.Labels{"kind": kind, "message_type": messageType, "outcome": outcome}
+	m.sink.IncCounter(MessagesTotal, labels, 1)
+	m.sink.ObserveHistogram(MessageDurationSeconds, labels, time.Since(start).Seconds())
+
+	return err
+}
+
+func (m MetricsMiddleware) CommandMiddleware() cqrs.CommandMiddlewareFunc {
+	return func(handler cqrs.CommandHandlerFunc[any]) cqrs.CommandHandlerFunc[any] {
+		return func(ctx context.Context, command any) error {
+			return m.observe("command", command, func() error {
+				return handler(ctx, command)
+			})
+		}
+	}
+}
+
+func (m MetricsMiddleware) QueryMiddleware() cqrs.QueryMiddlewareFunc {
+	return func(handler cqrs.QueryHandlerFunc[any, any]) cqrs.QueryHandlerFunc[any, any] {
+		return func(ctx context.Context, query any) (interface{}, error) {
+			var result interface{}
+
+			err := m.observe("query", query, func() error {
+				var err error
+
+				result, err = handler(ctx, query)
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			return result, nil
+		}
+	}
+}
+
+func (m MetricsMiddleware) EventMiddleware() cqrs.EventMiddlewareFunc {
+	return func(handler cqrs.EventHandlerFunc[any]) cqrs.EventHandlerFunc[any] {
+		return func(ctx context.Context, event any) error {
+			return m.observe("event", event, func() error {
+				if err := handler(ctx, event); err != nil {
+					m.sink.IncCounter(EventHandlerFailuresTotal, metrics.Labels{"message_type": messageType(event)}, 1)
+
+					return err
+				}
+
+				return nil
+			})
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package middlewares_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vulpes-ferrilata/cqrs"
+	"github.com/vulpes-ferrilata/cqrs/middlewares"
+	"github.com/vulpes-ferrilata/cqrs/pkg/metrics"
+	"github.com/vulpes-ferrilata/cqrs/pkg/metrics/memory"
+)
+
+func TestMetricsMiddleware_CommandMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		Err = errors.New("error")
+	)
+
+	type args struct {
+		handler cqrs.CommandHandlerFunc[any]
+	}
+	type wants struct {
+		outcome string
+		err     error
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "handler return error",
+			args: args{
+				handler: func(ctx context.Context, command interface{}) error {
+					return Err
+				},
+			},
+			wants: wants{
+				outcome: "failure",
+				err:     Err,
+			},
+		},
+		{
+			name: "success",
+			args: args{
+				handler: func(ctx context.Context, command interface{}) error {
+					return nil
+				},
+			},
+			wants: wants{
+				outcome: "success",
+				err:     nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := memory.NewSink()
+
+			metricsMiddleware := middlewares.NewMetricsMiddleware(sink)
+			commandMiddleware := metricsMiddleware.CommandMiddleware()
+			handler := commandMiddleware(func(ctx context.Context, command interface{}) error {
+				assert.Equal(t, float64(1), sink.Gauge(middlewares.MessagesInFlight, metrics.Labels{"kind": "command", "message_type": "struct {}"}))
+
+				return tt.args.handler(ctx, command)
+			})
+			err := handler(context.Background(), struct{}{})
+			assert.ErrorIs(t, err, tt.wants.err)
+
+			labels := metrics.Labels{"kind": "command", "message_type": "struct {}", "outcome": tt.wants.outcome}
+			assert.Equal(t, float64(1), sink.Counter(middlewares.MessagesTotal, labels))
+			assert.Equal(t, uint64(1), sink.Histogram(middlewares.MessageDurationSeconds, labels).Count)
+			assert.Equal(t, float64(0), sink.Gauge(middlewares.MessagesInFlight, metrics.Labels{"kind": "command", "message_type": "struct {}"}))
+		})
+	}
+}
+
+func TestMetricsMiddleware_QueryMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		result = struct{}{}
+		Err    = errors.New("error")
+	)
+
+	type args struct {
+		handler cqrs.QueryHandlerFunc[any, any]
+	}
+	type wants struct {
+		outcome string
+		result  interface{}
+		err     error
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "handler return error",
+			args: args{
+				handler: func(ctx context.Context, query interface{}) (interface{}, error) {
+					return nil, Err
+				},
+			},
+			wants: wants{
+				outcome: "failure",
+				result:  nil,
+				err:     Err,
+			},
+		},
+		{
+			name: "success",
+			args: args{
+				handler: func(ctx context.Context, query interface{}) (interface{}, error) {
+					return result, nil
+				},
+			},
+			wants: wants{
+				outcome: "success",
+				result:  result,
+				err:     nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := memory.NewSink()
+
+			metricsMiddleware := middlewares.NewMetricsMiddleware(sink)
+			queryMiddleware := metricsMiddleware.QueryMiddleware()
+			handler := queryMiddleware(tt.args.handler)
+			result, err := handler(context.Background(), struct{}{})
+			assert.ErrorIs(t, err, tt.wants.err)
+			assert.Equal(t, tt.wants.result, result)
+
+			labels := metrics.Labels{"kind": "query", "message_type": "struct {}", "outcome": tt.wants.outcome}
+			assert.Equal(t, float64(1), sink.Counter(middlewares.MessagesTotal, labels))
+		})
+	}
+}
+
+func TestMetricsMiddleware_EventMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		Err = errors.New("error")
+	)
+
+	type args struct {
+		handler cqrs.EventHandlerFunc[any]
+	}
+	type wants struct {
+		outcome  string
+		failures float64
+		err      error
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "handler return error",
+			args: args{
+				handler: func(ctx context.Context, event interface{}) error {
+					return Err
+				},
+			},
+			wants: wants{
+				outcome:  "failure",
+				failures: 1,
+				err:      Err,
+			},
+		},
+		{
+			name: "success",
+			args: args{
+				handler: func(ctx context.Context, event interface{}) error {
+					return nil
+				},
+			},
+			wants: wants{
+				outcome:  "success",
+				failures: 0,
+				err:      nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := memory.NewSink()
+
+			metricsMiddleware := middlewares.NewMetricsMiddleware(sink)
+			eventMiddleware := metricsMiddleware.EventMiddleware()
+			handler := eventMiddleware(tt.args.handler)
+			err := handler(context.Background(), struct{}{})
+			assert.ErrorIs(t, err, tt.wants.err)
+
+			labels := metrics.Labels{"kind": "event", "message_type": "struct {}", "outcome": tt.wants.outcome}
+			assert.Equal(t, float64(1), sink.Counter(middlewares.MessagesTotal, labels))
+			assert.Equal(t, tt.wants.failures, sink.Counter(middlewares.EventHandlerFailuresTotal, metrics.Labels{"message_type": "struct {}"}))
+		})
+	}
+}
@@ -2,55 +2,355 @@ package middlewares
 
 import (
 	"context"
+	"errors"
+	"reflect"
+	"time"
 
 	"github.com/vulpes-ferrilata/cqrs"
 	"github.com/vulpes-ferrilata/cqrs/pkg/db"
 )
 
-func NewTransactionMiddleware[DB any](transactionManager db.TransactionManager[DB]) *TransactionMiddleware[DB] {
+type Propagation int
+
+const (
+	// PropagationRequired joins the current transaction or starts a new one.
+	PropagationRequired Propagation = iota
+	// PropagationRequiresNew suspends the current transaction and starts an independent one.
+	PropagationRequiresNew
+	// PropagationNested runs in a savepoint of the current transaction or starts a new one.
+	PropagationNested
+	// PropagationSupports joins the current transaction or runs without one.
+	PropagationSupports
+	// PropagationNotSupported suspends the current transaction and runs without one.
+	PropagationNotSupported
+	// PropagationMandatory joins the current transaction and fails without one.
+	PropagationMandatory
+	// PropagationNever runs without a transaction and fails within one.
+	PropagationNever
+)
+
+// TransactionalMessage takes precedence over WithPropagation.
+type TransactionalMessage interface {
+	Propagation() Propagation
+}
+
+// TransactionOptionsMessage takes precedence over WithTransactionOptions.
+type TransactionOptionsMessage interface {
+	TransactionOptions() interface{}
+}
+
+type TransactionOption func(t *transactionOptions)
+
+type transactionOptions struct {
+	defaultPropagation Propagation
+	propagations       map[reflect.Type]Propagation
+	options            map[reflect.Type]interface{}
+}
+
+func WithDefaultPropagation(propagation Propagation) TransactionOption {
+	return func(t *transactionOptions) {
+		t.defaultPropagation = propagation
+	}
+}
+
+func WithPropagation(message interface{}, propagation Propagation) TransactionOption {
+	return func(t *transactionOptions) {
+		t.propagations[reflect.TypeOf(message)] = propagation
+	}
+}
+
+// WithTransactionOptions accepts the options type of the TransactionManager: *sql.TxOptions for GORM and SQL,
+// *options.TransactionOptions for Mongo. Options of another type fail with db.ErrTransactionOptionsNotSupported
+// when the message starts a transaction, they are ignored when it joins one or runs in a savepoint.
+func WithTransactionOptions(message interface{}, opts interface{}) TransactionOption {
+	return func(t *transactionOptions) {
+		t.options[reflect.TypeOf(message)] = opts
+	}
+}
+
+func NewTransactionMiddleware[DB any](transactionManager db.TransactionManager[DB], opts ...TransactionOption) *TransactionMiddleware[DB] {
+	transactionOptions := transactionOptions{
+		defaultPropagation: PropagationRequired,
+		propagations:       make(map[reflect.Type]Propagation),
+		options:            make(map[reflect.Type]interface{}),
+	}
+
+	for _, opt := range opts {
+		opt(&transactionOptions)
+	}
+
 	return &TransactionMiddleware[DB]{
 		transactionManager: transactionManager,
+		transactionOptions: transactionOptions,
 	}
 }
 
 type TransactionMiddleware[DB any] struct {
 	transactionManager db.TransactionManager[DB]
+	transactionOptions transactionOptions
+}
+
+func (m TransactionMiddleware[DB]) propagation(message interface{}) Propagation {
+	if transactionalMessage, ok := message.(TransactionalMessage); ok {
+		return transactionalMessage.Propagation()
+	}
+
+	if propagation, ok := m.transactionOptions.propagations[reflect.TypeOf(message)]; ok {
+		return propagation
+	}
+
+	return m.transactionOptions.defaultPropagation
+}
+
+func (m TransactionMiddleware[DB]) options(message interface{}) (interface{}, bool) {
+	if transactionOptionsMessage, ok := message.(TransactionOptionsMessage); ok {
+		return transactionOptionsMessage.TransactionOptions(), true
+	}
+
+	opts, ok := m.transactionOptions.options[reflect.TypeOf(message)]
+	return opts, ok
+}
+
+func (m TransactionMiddleware[DB]) startTransaction(message interface{}) func(ctx context.Context) (db.Committer, context.Context, error) {
+	opts, ok := m.options(message)
+	if !ok {
+		return m.transactionManager.StartTransaction
+	}
+
+	return func(ctx context.Context) (db.Committer, context.Context, error) {
+		configurableTransactionManager, ok := m.transactionManager.(db.ConfigurableTransactionManager)
+		if !ok {
+			return nil, ctx, db.ErrTransactionOptionsNotSupported
+		}
+
+		return configurableTransactionManager.StartTransactionWithOptions(ctx, opts)
+	}
+}
+
+func (m TransactionMiddleware[DB]) suspend(ctx context.Context) (context.Context, error) {
+	transactionSuspender, ok := m.transactionManager.(db.TransactionSuspender)
+	if !ok {
+		return nil, db.ErrTransactionSuspensionNotSupported
+	}
+
+	return transactionSuspender.SuspendTransaction(ctx), nil
+}
+
+func (m TransactionMiddleware[DB]) startReadOnlyTransaction(ctx context.Context) (db.Committer, context.Context, error) {
+	readOnlyTransactionManager, ok := m.transactionManager.(db.ReadOnlyTransactionManager)
+	if !ok {
+		return m.transactionManager.StartTransaction(ctx)
+	}
+
+	return readOnlyTransactionManager.StartReadOnlyTransaction(ctx)
+}
+
+func (m TransactionMiddleware[DB]) withPropagation(ctx context.Context,
+	message interface{},
+	startTransaction func(ctx context.Context) (db.Committer, context.Context, error),
+	fn func(ctx context.Context) error) error {
+	isTransactionStarted := m.transactionManager.IsTransactionStarted(ctx)
+
+	switch m.propagation(message) {
+	case PropagationRequiresNew:
+		if isTransactionStarted {
+			ctx, err := m.suspend(ctx)
+			if err != nil {
+				return err
+			}
+
+			return m.retryTransaction(ctx, startTransaction, fn)
+		}
+	case PropagationNested:
+		if isTransactionStarted {
+			nestedTransactionManager, ok := m.transactionManager.(db.NestedTransactionManager)
+			if !ok {
+				return db.ErrNestedTransactionNotSupported
+			}
+
+			return m.withTransaction(ctx, nestedTransactionManager.StartNestedTransaction, fn)
+		}
+	case PropagationSupports:
+		return fn(ctx)
+	case PropagationNotSupported:
+		if isTransactionStarted {
+			suspendedCtx, err := m.suspend(ctx)
+			if err != nil {
+				return err
+			}
+
+			ctx = suspendedCtx
+		}
+
+		return fn(ctx)
+	case PropagationMandatory:
+		if !isTransactionStarted {
+			return db.ErrNoActiveTransaction
+		}
+
+		return fn(ctx)
+	case PropagationNever:
+		if isTransactionStarted {
+			return db.ErrTransactionAlreadyStarted
+		}
+
+		return fn(ctx)
+	default:
+		if isTransactionStarted {
+			return fn(ctx)
+		}
+	}
+
+	return m.retryTransaction(ctx, startTransaction, fn)
+}
+
+func (m TransactionMiddleware[DB]) retryTransaction(ctx context.Context,
+	startTransaction func(ctx context.Context) (db.Committer, context.Context, error),
+	fn func(ctx context.Context) error) error {
+	transactionRetrier, ok := m.transactionManager.(db.TransactionRetrier)
+	if !ok {
+		return m.withTransaction(ctx, startTransaction, fn)
+	}
+
+	eventProvider, hasEventProvider := cqrs.GetEventProvider(ctx)
+	binder, hasBinder := getEventDispatchBinder(ctx)
+	startedAt := time.Now()
+
+	for attempts := 1; ; attempts++ {
+		attemptCtx := ctx
+		attemptEventProvider := cqrs.NewEventProvider()
+		if hasEventProvider {
+			attemptCtx = cqrs.WithEventProvider(attemptCtx, attemptEventProvider)
+		}
+
+		var attemptBinder *eventDispatchBinder
+		if hasBinder {
+			attemptBinder = &eventDispatchBinder{
+				dispatcher:    binder.dispatcher,
+				command:       binder.command,
+				eventProvider: attemptEventProvider,
+			}
+			attemptCtx = withEventDispatchBinder(attemptCtx, attemptBinder)
+		}
+
+		err := m.withTransaction(attemptCtx, startTransaction, fn)
+		if err == nil {
+			if hasEventProvider {
+				eventProvider.CollectEvents(attemptEventProvider.GetEvents()...)
+			}
+
+			if attemptBinder != nil && attemptBinder.isBound() {
+				binder.markBound()
+			}
+
+			return nil
+		}
+
+		backoff, ok := transactionRetrier.RetryTransaction(err, attempts, startedAt)
+		if !ok {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+
+			return err
+		}
+	}
+}
+
+func (m TransactionMiddleware[DB]) withTransaction(ctx context.Context,
+	startTransaction func(ctx context.Context) (db.Committer, context.Context, error),
+	fn func(ctx context.Context) error) error {
+	committer, ctx, err := startTransaction(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			committer.RollbackTransaction(context.WithoutCancel(ctx))
+
+			panic(r)
+		}
+	}()
+
+	if binder, ok := getEventDispatchBinder(ctx); ok {
+		if err := binder.bind(ctx); err != nil {
+			return rollbackTransaction(ctx, committer, err)
+		}
+
+		ctx = withEventDispatchBinder(ctx, nil)
+	}
+
+	if err := fn(ctx); err != nil {
+		return rollbackTransaction(ctx, committer, err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return rollbackTransaction(ctx, committer, err)
+	}
+
+	if err := committer.CommitTransaction(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func rollbackTransaction(ctx context.Context, committer db.Committer, err error) error {
+	if rollbackErr := committer.RollbackTransaction(context.WithoutCancel(ctx)); rollbackErr != nil {
+		return errors.Join(err, rollbackErr)
+	}
+
+	return err
 }
 
 func (m TransactionMiddleware[DB]) CommandMiddleware() cqrs.CommandMiddlewareFunc {
 	return func(handler cqrs.CommandHandlerFunc[any]) cqrs.CommandHandlerFunc[any] {
 		return func(ctx context.Context, command any) error {
-			if isTransactionStarted := m.transactionManager.IsTransactionStarted(ctx); !isTransactionStarted {
-				committer, ctx, err := m.transactionManager.StartTransaction(ctx)
-				if err != nil {
-					return err
-				}
-				defer func() {
-					if r := recover(); r != nil {
-						committer.RollbackTransaction(ctx)
-
-						panic(r)
-					}
-				}()
-
-				if err := handler(ctx, command); err != nil {
-					committer.RollbackTransaction(ctx)
-
-					return err
-				}
-
-				if err := committer.CommitTransaction(ctx); err != nil {
-					return err
-				}
-
-				return nil
-			}
+			ctx = db.ReadFromPrimary(ctx)
+
+			return m.withPropagation(ctx, command, m.startTransaction(command), func(ctx context.Context) error {
+				return handler(ctx, command)
+			})
+		}
+	}
+}
+
+// QueryMiddleware reads from the replica unless the query is executed by a command or an event handler.
+func (m TransactionMiddleware[DB]) QueryMiddleware() cqrs.QueryMiddlewareFunc {
+	return func(handler cqrs.QueryHandlerFunc[any, any]) cqrs.QueryHandlerFunc[any, any] {
+		return func(ctx context.Context, query any) (interface{}, error) {
+			var result interface{}
+
+			ctx = db.ReadFromReplica(ctx)
 
-			if err := handler(ctx, command); err != nil {
+			err := m.withPropagation(ctx, query, m.startReadOnlyTransaction, func(ctx context.Context) error {
+				var err error
+
+				result, err = handler(ctx, query)
 				return err
+			})
+			if err != nil {
+				return nil, err
 			}
 
-			return nil
+			return result, nil
+		}
+	}
+}
+
+func (m TransactionMiddleware[DB]) EventMiddleware() cqrs.EventMiddlewareFunc {
+	return func(handler cqrs.EventHandlerFunc[any]) cqrs.EventHandlerFunc[any] {
+		return func(ctx context.Context, event any) error {
+			ctx = db.ReadFromPrimary(ctx)
+
+			return m.withPropagation(ctx, event, m.startTransaction(event), func(ctx context.Context) error {
+				return handler(ctx, event)
+			})
 		}
 	}
 }
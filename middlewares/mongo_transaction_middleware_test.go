@@ -2,29 +2,47 @@ package middlewares_test
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/glebarez/sqlite"
 	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
 	"go.uber.org/mock/gomock"
 	"gorm.io/gorm"
 
 	"github.com/vulpes-ferrilata/cqrs"
+	"github.com/vulpes-ferrilata/cqrs/internal/testdb"
 	"github.com/vulpes-ferrilata/cqrs/middlewares"
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+	db_gorm "github.com/vulpes-ferrilata/cqrs/pkg/db/gorm"
+	db_memory "github.com/vulpes-ferrilata/cqrs/pkg/db/memory"
 	mock_db "github.com/vulpes-ferrilata/cqrs/pkg/db/mocks"
+	db_mongo "github.com/vulpes-ferrilata/cqrs/pkg/db/mongo"
+	"github.com/vulpes-ferrilata/cqrs/pkg/outbox"
+	outbox_memory "github.com/vulpes-ferrilata/cqrs/pkg/outbox/memory"
 )
 
 func TestTransactionMiddleware_CommandMiddleware(t *testing.T) {
 	t.Parallel()
 
 	var (
-		ctx     = context.Background()
+		ctx     = db.ReadFromPrimary(context.Background())
 		newCtx  = context.WithValue(ctx, "xxx", "yyy")
 		command = struct{}{}
 
-		Err = errors.New("error")
+		expiredCtx, cancel = context.WithDeadline(ctx, time.Now())
+
+		Err         = errors.New("error")
+		RollbackErr = errors.New("rollback error")
 	)
 
+	defer cancel()
+
 	type mocks struct {
 		transactionManager *mock_db.MockTransactionManager[*gorm.DB]
 		committer          *mock_db.MockCommitter
@@ -60,7 +78,7 @@ func TestTransactionMiddleware_CommandMiddleware(t *testing.T) {
 			prepare: func(mocks mocks) {
 				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(false)
 				mocks.transactionManager.EXPECT().StartTransaction(ctx).Return(mocks.committer, newCtx, nil)
-				mocks.committer.EXPECT().RollbackTransaction(newCtx).Return(nil)
+				mocks.committer.EXPECT().RollbackTransaction(context.WithoutCancel(newCtx)).Return(nil)
 			},
 			args: args{
 				handler: func(ctx context.Context, command interface{}) error {
@@ -71,12 +89,28 @@ func TestTransactionMiddleware_CommandMiddleware(t *testing.T) {
 			},
 			wantErr: Err,
 		},
+		{
+			name: "handler return error - rollback transaction fail",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(false)
+				mocks.transactionManager.EXPECT().StartTransaction(ctx).Return(mocks.committer, newCtx, nil)
+				mocks.committer.EXPECT().RollbackTransaction(context.WithoutCancel(newCtx)).Return(RollbackErr)
+			},
+			args: args{
+				handler: func(ctx context.Context, command interface{}) error {
+					return Err
+				},
+				ctx:     ctx,
+				command: command,
+			},
+			wantErr: RollbackErr,
+		},
 		{
 			name: "handler panic",
 			prepare: func(mocks mocks) {
 				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(false)
 				mocks.transactionManager.EXPECT().StartTransaction(ctx).Return(mocks.committer, newCtx, nil)
-				mocks.committer.EXPECT().RollbackTransaction(newCtx).Return(nil)
+				mocks.committer.EXPECT().RollbackTransaction(context.WithoutCancel(newCtx)).Return(nil)
 			},
 			args: args{
 				handler: func(ctx context.Context, command interface{}) error {
@@ -119,6 +153,22 @@ func TestTransactionMiddleware_CommandMiddleware(t *testing.T) {
 			},
 			wantErr: Err,
 		},
+		{
+			name: "context expired before commit",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(false)
+				mocks.transactionManager.EXPECT().StartTransaction(ctx).Return(mocks.committer, expiredCtx, nil)
+				mocks.committer.EXPECT().RollbackTransaction(gomock.Any()).Return(nil)
+			},
+			args: args{
+				handler: func(ctx context.Context, command interface{}) error {
+					return nil
+				},
+				ctx:     ctx,
+				command: command,
+			},
+			wantErr: context.DeadlineExceeded,
+		},
 		{
 			name: "success",
 			prepare: func(mocks mocks) {
@@ -188,3 +238,1359 @@ func TestTransactionMiddleware_CommandMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestTransactionMiddleware_EventMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx    = db.ReadFromPrimary(context.Background())
+		newCtx = context.WithValue(ctx, "xxx", "yyy")
+		event  = struct{}{}
+
+		Err = errors.New("error")
+	)
+
+	type mocks struct {
+		transactionManager *mock_db.MockTransactionManager[*gorm.DB]
+		committer          *mock_db.MockCommitter
+	}
+	type args struct {
+		handler cqrs.EventHandlerFunc[any]
+	}
+	tests := []struct {
+		name    string
+		prepare func(mocks mocks)
+		args    args
+		wantErr error
+	}{
+		{
+			name: "start transaction fail",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(false)
+				mocks.transactionManager.EXPECT().StartTransaction(ctx).Return(nil, nil, Err)
+			},
+			args: args{
+				handler: func(ctx context.Context, event interface{}) error {
+					return nil
+				},
+			},
+			wantErr: Err,
+		},
+		{
+			name: "handler return error",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(false)
+				mocks.transactionManager.EXPECT().StartTransaction(ctx).Return(mocks.committer, newCtx, nil)
+				mocks.committer.EXPECT().RollbackTransaction(context.WithoutCancel(newCtx)).Return(nil)
+			},
+			args: args{
+				handler: func(ctx context.Context, event interface{}) error {
+					return Err
+				},
+			},
+			wantErr: Err,
+		},
+		{
+			name: "success",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(false)
+				mocks.transactionManager.EXPECT().StartTransaction(ctx).Return(mocks.committer, newCtx, nil)
+				mocks.committer.EXPECT().CommitTransaction(newCtx).Return(nil)
+			},
+			args: args{
+				handler: func(ctx context.Context, event interface{}) error {
+					return nil
+				},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "transaction already started - success",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(true)
+			},
+			args: args{
+				handler: func(ctx context.Context, event interface{}) error {
+					return nil
+				},
+			},
+			wantErr: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := mocks{
+				transactionManager: mock_db.NewMockTransactionManager[*gorm.DB](mockCtrl),
+				committer:          mock_db.NewMockCommitter(mockCtrl),
+			}
+
+			tt.prepare(mocks)
+
+			transactionMiddleware := middlewares.NewTransactionMiddleware[*gorm.DB](mocks.transactionManager)
+			eventMiddleware := transactionMiddleware.EventMiddleware()
+			handler := eventMiddleware(tt.args.handler)
+			err := handler(ctx, event)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+type readOnlyTransactionManager struct {
+	*mock_db.MockTransactionManager[*gorm.DB]
+	*mock_db.MockReadOnlyTransactionManager
+}
+
+func TestTransactionMiddleware_QueryMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx    = db.ReadFromReplica(context.Background())
+		newCtx = context.WithValue(ctx, "xxx", "yyy")
+		query  = struct{}{}
+
+		Err = errors.New("error")
+	)
+
+	type mocks struct {
+		transactionManager         *mock_db.MockTransactionManager[*gorm.DB]
+		readOnlyTransactionManager *mock_db.MockReadOnlyTransactionManager
+		committer                  *mock_db.MockCommitter
+	}
+	type args struct {
+		handler     cqrs.QueryHandlerFunc[any, any]
+		unsupported bool
+	}
+	type wants struct {
+		result interface{}
+		err    error
+	}
+	tests := []struct {
+		name    string
+		prepare func(mocks mocks)
+		args    args
+		wants   wants
+	}{
+		{
+			name: "start read-only transaction fail",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(false)
+				mocks.readOnlyTransactionManager.EXPECT().StartReadOnlyTransaction(ctx).Return(nil, nil, Err)
+			},
+			args: args{
+				handler: func(ctx context.Context, query interface{}) (interface{}, error) {
+					return "result", nil
+				},
+			},
+			wants: wants{
+				result: nil,
+				err:    Err,
+			},
+		},
+		{
+			name: "handler return error",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(false)
+				mocks.readOnlyTransactionManager.EXPECT().StartReadOnlyTransaction(ctx).Return(mocks.committer, newCtx, nil)
+				mocks.committer.EXPECT().RollbackTransaction(context.WithoutCancel(newCtx)).Return(nil)
+			},
+			args: args{
+				handler: func(ctx context.Context, query interface{}) (interface{}, error) {
+					return nil, Err
+				},
+			},
+			wants: wants{
+				result: nil,
+				err:    Err,
+			},
+		},
+		{
+			name: "success",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(false)
+				mocks.readOnlyTransactionManager.EXPECT().StartReadOnlyTransaction(ctx).Return(mocks.committer, newCtx, nil)
+				mocks.committer.EXPECT().CommitTransaction(newCtx).Return(nil)
+			},
+			args: args{
+				handler: func(ctx context.Context, query interface{}) (interface{}, error) {
+					return ctx.Value("xxx"), nil
+				},
+			},
+			wants: wants{
+				result: "yyy",
+				err:    nil,
+			},
+		},
+		{
+			name: "transaction already started",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(true)
+			},
+			args: args{
+				handler: func(ctx context.Context, query interface{}) (interface{}, error) {
+					return "result", nil
+				},
+			},
+			wants: wants{
+				result: "result",
+				err:    nil,
+			},
+		},
+		{
+			name: "read-only transaction not supported",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(false)
+				mocks.transactionManager.EXPECT().StartTransaction(ctx).Return(mocks.committer, newCtx, nil)
+				mocks.committer.EXPECT().CommitTransaction(newCtx).Return(nil)
+			},
+			args: args{
+				handler: func(ctx context.Context, query interface{}) (interface{}, error) {
+					return ctx.Value("xxx"), nil
+				},
+				unsupported: true,
+			},
+			wants: wants{
+				result: "yyy",
+				err:    nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := mocks{
+				transactionManager:         mock_db.NewMockTransactionManager[*gorm.DB](mockCtrl),
+				readOnlyTransactionManager: mock_db.NewMockReadOnlyTransactionManager(mockCtrl),
+				committer:                  mock_db.NewMockCommitter(mockCtrl),
+			}
+
+			tt.prepare(mocks)
+
+			var transactionManager db.TransactionManager[*gorm.DB] = &readOnlyTransactionManager{
+				MockTransactionManager:         mocks.transactionManager,
+				MockReadOnlyTransactionManager: mocks.readOnlyTransactionManager,
+			}
+			if tt.args.unsupported {
+				transactionManager = mocks.transactionManager
+			}
+
+			transactionMiddleware := middlewares.NewTransactionMiddleware(transactionManager)
+			queryMiddleware := transactionMiddleware.QueryMiddleware()
+			handler := queryMiddleware(tt.args.handler)
+			result, err := handler(ctx, query)
+			assert.ErrorIs(t, err, tt.wants.err)
+			assert.Equal(t, tt.wants.result, result)
+		})
+	}
+}
+
+type (
+	OuterCommand struct {
+		Fail bool
+	}
+	InnerCommand struct{}
+	Note         struct {
+		ID   uint
+		Text string
+	}
+)
+
+func TestTransactionMiddleware_Hooks(t *testing.T) {
+	t.Parallel()
+
+	var (
+		Err = errors.New("error")
+	)
+
+	type wants struct {
+		calls []string
+		notes int64
+		err   error
+	}
+	tests := []struct {
+		name  string
+		fail  bool
+		wants wants
+	}{
+		{
+			name: "nested command hooks run when outer transaction commits",
+			fail: false,
+			wants: wants{
+				calls: []string{"inner before commit", "outer after commit", "inner after commit"},
+				notes: 2,
+				err:   nil,
+			},
+		},
+		{
+			name: "nested command hooks run when outer transaction rolls back",
+			fail: true,
+			wants: wants{
+				calls: []string{"inner after rollback"},
+				notes: 0,
+				err:   Err,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gormDB := testdb.NewGorm(t, &Note{})
+
+			transactionManager := db_gorm.NewTransactionManager(gormDB, nil)
+
+			calls := make([]string, 0)
+
+			commandBus := cqrs.NewCommandBus()
+			commandBus.Use(middlewares.NewTransactionMiddleware(transactionManager).CommandMiddleware())
+			err := cqrs.RegisterCommandHandler(commandBus, func(ctx context.Context, command InnerCommand) error {
+				if err := transactionManager.GetTransaction(ctx).Create(&Note{Text: "inner"}).Error; err != nil {
+					return err
+				}
+
+				return errors.Join(
+					db.OnBeforeCommit(ctx, func(ctx context.Context) error {
+						calls = append(calls, "inner before commit")
+						return nil
+					}),
+					db.OnAfterCommit(ctx, func(ctx context.Context) {
+						calls = append(calls, "inner after commit")
+					}),
+					db.OnAfterRollback(ctx, func(ctx context.Context) {
+						calls = append(calls, "inner after rollback")
+					}),
+				)
+			})
+			assert.NoError(t, err)
+			err = cqrs.RegisterCommandHandler(commandBus, func(ctx context.Context, command OuterCommand) error {
+				if err := db.OnAfterCommit(ctx, func(ctx context.Context) {
+					calls = append(calls, "outer after commit")
+				}); err != nil {
+					return err
+				}
+
+				if err := transactionManager.GetTransaction(ctx).Create(&Note{Text: "outer"}).Error; err != nil {
+					return err
+				}
+
+				if err := commandBus.Execute(ctx, InnerCommand{}); err != nil {
+					return err
+				}
+
+				// hooks of the nested command wait for the outer transaction.
+				assert.Empty(t, calls)
+
+				if command.Fail {
+					return Err
+				}
+
+				return nil
+			})
+			assert.NoError(t, err)
+
+			err = commandBus.Execute(context.Background(), OuterCommand{Fail: tt.fail})
+			assert.ErrorIs(t, err, tt.wants.err)
+			assert.Equal(t, tt.wants.calls, calls)
+
+			var notes int64
+			err = gormDB.Model(&Note{}).Count(&notes).Error
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wants.notes, notes)
+		})
+	}
+}
+
+type (
+	propagationTransactionManager struct {
+		*mock_db.MockTransactionManager[*gorm.DB]
+		*mock_db.MockTransactionSuspender
+		*mock_db.MockNestedTransactionManager
+	}
+	AuditCommand struct{}
+)
+
+func (a AuditCommand) Propagation() middlewares.Propagation {
+	return middlewares.PropagationRequiresNew
+}
+
+func TestTransactionMiddleware_Propagation(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx          = db.ReadFromPrimary(context.Background())
+		newCtx       = context.WithValue(ctx, "xxx", "yyy")
+		suspendedCtx = context.WithValue(ctx, "suspended", true)
+		command      = struct{}{}
+	)
+
+	type mocks struct {
+		transactionManager       *mock_db.MockTransactionManager[*gorm.DB]
+		transactionSuspender     *mock_db.MockTransactionSuspender
+		nestedTransactionManager *mock_db.MockNestedTransactionManager
+		committer                *mock_db.MockCommitter
+	}
+	type args struct {
+		propagation middlewares.Propagation
+		command     interface{}
+		unsupported bool
+	}
+	type wants struct {
+		ctx context.Context
+		err error
+	}
+	tests := []struct {
+		name    string
+		prepare func(mocks mocks)
+		args    args
+		wants   wants
+	}{
+		{
+			name: "required - transaction already started",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(true)
+			},
+			args: args{
+				propagation: middlewares.PropagationRequired,
+				command:     command,
+			},
+			wants: wants{
+				ctx: ctx,
+				err: nil,
+			},
+		},
+		{
+			name: "requires new - transaction already started",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(true)
+				mocks.transactionSuspender.EXPECT().SuspendTransaction(ctx).Return(suspendedCtx)
+				mocks.transactionManager.EXPECT().StartTransaction(suspendedCtx).Return(mocks.committer, newCtx, nil)
+				mocks.committer.EXPECT().CommitTransaction(newCtx).Return(nil)
+			},
+			args: args{
+				propagation: middlewares.PropagationRequiresNew,
+				command:     command,
+			},
+			wants: wants{
+				ctx: newCtx,
+				err: nil,
+			},
+		},
+		{
+			name: "requires new - suspension not supported",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(true)
+			},
+			args: args{
+				propagation: middlewares.PropagationRequiresNew,
+				command:     command,
+				unsupported: true,
+			},
+			wants: wants{
+				ctx: nil,
+				err: db.ErrTransactionSuspensionNotSupported,
+			},
+		},
+		{
+			name: "requires new - declared by command",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(true)
+				mocks.transactionSuspender.EXPECT().SuspendTransaction(ctx).Return(suspendedCtx)
+				mocks.transactionManager.EXPECT().StartTransaction(suspendedCtx).Return(mocks.committer, newCtx, nil)
+				mocks.committer.EXPECT().CommitTransaction(newCtx).Return(nil)
+			},
+			args: args{
+				propagation: middlewares.PropagationRequired,
+				command:     AuditCommand{},
+			},
+			wants: wants{
+				ctx: newCtx,
+				err: nil,
+			},
+		},
+		{
+			name: "nested - no transaction started",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(false)
+				mocks.transactionManager.EXPECT().StartTransaction(ctx).Return(mocks.committer, newCtx, nil)
+				mocks.committer.EXPECT().CommitTransaction(newCtx).Return(nil)
+			},
+			args: args{
+				propagation: middlewares.PropagationNested,
+				command:     command,
+			},
+			wants: wants{
+				ctx: newCtx,
+				err: nil,
+			},
+		},
+		{
+			name: "nested - transaction already started",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(true)
+				mocks.nestedTransactionManager.EXPECT().StartNestedTransaction(ctx).Return(mocks.committer, newCtx, nil)
+				mocks.committer.EXPECT().CommitTransaction(newCtx).Return(nil)
+			},
+			args: args{
+				propagation: middlewares.PropagationNested,
+				command:     command,
+			},
+			wants: wants{
+				ctx: newCtx,
+				err: nil,
+			},
+		},
+		{
+			name: "nested - nested transaction not supported",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(true)
+			},
+			args: args{
+				propagation: middlewares.PropagationNested,
+				command:     command,
+				unsupported: true,
+			},
+			wants: wants{
+				ctx: nil,
+				err: db.ErrNestedTransactionNotSupported,
+			},
+		},
+		{
+			name: "supports - no transaction started",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(false)
+			},
+			args: args{
+				propagation: middlewares.PropagationSupports,
+				command:     command,
+			},
+			wants: wants{
+				ctx: ctx,
+				err: nil,
+			},
+		},
+		{
+			name: "not supported - transaction already started",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(true)
+				mocks.transactionSuspender.EXPECT().SuspendTransaction(ctx).Return(suspendedCtx)
+			},
+			args: args{
+				propagation: middlewares.PropagationNotSupported,
+				command:     command,
+			},
+			wants: wants{
+				ctx: suspendedCtx,
+				err: nil,
+			},
+		},
+		{
+			name: "mandatory - no transaction started",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(false)
+			},
+			args: args{
+				propagation: middlewares.PropagationMandatory,
+				command:     command,
+			},
+			wants: wants{
+				ctx: nil,
+				err: db.ErrNoActiveTransaction,
+			},
+		},
+		{
+			name: "mandatory - transaction already started",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(true)
+			},
+			args: args{
+				propagation: middlewares.PropagationMandatory,
+				command:     command,
+			},
+			wants: wants{
+				ctx: ctx,
+				err: nil,
+			},
+		},
+		{
+			name: "never - transaction already started",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(true)
+			},
+			args: args{
+				propagation: middlewares.PropagationNever,
+				command:     command,
+			},
+			wants: wants{
+				ctx: nil,
+				err: db.ErrTransactionAlreadyStarted,
+			},
+		},
+		{
+			name: "never - no transaction started",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(false)
+			},
+			args: args{
+				propagation: middlewares.PropagationNever,
+				command:     command,
+			},
+			wants: wants{
+				ctx: ctx,
+				err: nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := mocks{
+				transactionManager:       mock_db.NewMockTransactionManager[*gorm.DB](mockCtrl),
+				transactionSuspender:     mock_db.NewMockTransactionSuspender(mockCtrl),
+				nestedTransactionManager: mock_db.NewMockNestedTransactionManager(mockCtrl),
+				committer:                mock_db.NewMockCommitter(mockCtrl),
+			}
+
+			tt.prepare(mocks)
+
+			var transactionManager db.TransactionManager[*gorm.DB] = &propagationTransactionManager{
+				MockTransactionManager:       mocks.transactionManager,
+				MockTransactionSuspender:     mocks.transactionSuspender,
+				MockNestedTransactionManager: mocks.nestedTransactionManager,
+			}
+			if tt.args.unsupported {
+				transactionManager = mocks.transactionManager
+			}
+
+			var got context.Context
+
+			transactionMiddleware := middlewares.NewTransactionMiddleware(transactionManager,
+				middlewares.WithPropagation(command, tt.args.propagation),
+			)
+			commandMiddleware := transactionMiddleware.CommandMiddleware()
+			handler := commandMiddleware(func(ctx context.Context, command interface{}) error {
+				got = ctx
+				return nil
+			})
+			err := handler(ctx, tt.args.command)
+			assert.ErrorIs(t, err, tt.wants.err)
+			assert.Equal(t, tt.wants.ctx, got)
+		})
+	}
+}
+
+type (
+	configurableTransactionManager struct {
+		*mock_db.MockTransactionManager[*gorm.DB]
+		*mock_db.MockConfigurableTransactionManager
+	}
+	TransferCommand struct{}
+)
+
+func (t TransferCommand) TransactionOptions() interface{} {
+	return &sql.TxOptions{Isolation: sql.LevelSerializable}
+}
+
+func TestTransactionMiddleware_TransactionOptions(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx     = db.ReadFromPrimary(context.Background())
+		newCtx  = context.WithValue(ctx, "xxx", "yyy")
+		command = struct{}{}
+		opts    = &sql.TxOptions{Isolation: sql.LevelReadCommitted}
+	)
+
+	type mocks struct {
+		transactionManager             *mock_db.MockTransactionManager[*gorm.DB]
+		configurableTransactionManager *mock_db.MockConfigurableTransactionManager
+		committer                      *mock_db.MockCommitter
+	}
+	type args struct {
+		command     interface{}
+		unsupported bool
+	}
+	tests := []struct {
+		name    string
+		prepare func(mocks mocks)
+		args    args
+		wantErr error
+	}{
+		{
+			name: "registered options",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(false)
+				mocks.configurableTransactionManager.EXPECT().StartTransactionWithOptions(ctx, opts).Return(mocks.committer, newCtx, nil)
+				mocks.committer.EXPECT().CommitTransaction(newCtx).Return(nil)
+			},
+			args: args{
+				command: command,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "options declared by command",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(false)
+				mocks.configurableTransactionManager.EXPECT().StartTransactionWithOptions(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable}).Return(mocks.committer, newCtx, nil)
+				mocks.committer.EXPECT().CommitTransaction(newCtx).Return(nil)
+			},
+			args: args{
+				command: TransferCommand{},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "transaction already started",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(true)
+			},
+			args: args{
+				command: command,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "transaction options not supported",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().IsTransactionStarted(ctx).Return(false)
+			},
+			args: args{
+				command:     command,
+				unsupported: true,
+			},
+			wantErr: db.ErrTransactionOptionsNotSupported,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := mocks{
+				transactionManager:             mock_db.NewMockTransactionManager[*gorm.DB](mockCtrl),
+				configurableTransactionManager: mock_db.NewMockConfigurableTransactionManager(mockCtrl),
+				committer:                      mock_db.NewMockCommitter(mockCtrl),
+			}
+
+			tt.prepare(mocks)
+
+			var transactionManager db.TransactionManager[*gorm.DB] = &configurableTransactionManager{
+				MockTransactionManager:             mocks.transactionManager,
+				MockConfigurableTransactionManager: mocks.configurableTransactionManager,
+			}
+			if tt.args.unsupported {
+				transactionManager = mocks.transactionManager
+			}
+
+			transactionMiddleware := middlewares.NewTransactionMiddleware(transactionManager,
+				middlewares.WithTransactionOptions(command, opts),
+			)
+			commandMiddleware := transactionMiddleware.CommandMiddleware()
+			handler := commandMiddleware(func(ctx context.Context, command interface{}) error {
+				return nil
+			})
+			err := handler(ctx, tt.args.command)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestTransactionMiddleware_RequiresNew(t *testing.T) {
+	t.Parallel()
+
+	Err := errors.New("error")
+
+	// an independent transaction needs its own connection, in-memory databases are not shared between them.
+	gormDB, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "cqrs.db")), &gorm.Config{})
+	assert.NoError(t, err)
+	err = gormDB.AutoMigrate(&Note{})
+	assert.NoError(t, err)
+
+	transactionManager := db_gorm.NewTransactionManager(gormDB, nil)
+
+	calls := make([]string, 0)
+
+	commandBus := cqrs.NewCommandBus()
+	commandBus.Use(middlewares.NewTransactionMiddleware(transactionManager,
+		middlewares.WithPropagation(InnerCommand{}, middlewares.PropagationRequiresNew),
+	).CommandMiddleware())
+	err = cqrs.RegisterCommandHandler(commandBus, func(ctx context.Context, command InnerCommand) error {
+		if err := db.OnAfterCommit(ctx, func(ctx context.Context) {
+			calls = append(calls, "inner after commit")
+		}); err != nil {
+			return err
+		}
+
+		return transactionManager.GetTransaction(ctx).Create(&Note{Text: "inner"}).Error
+	})
+	assert.NoError(t, err)
+	err = cqrs.RegisterCommandHandler(commandBus, func(ctx context.Context, command OuterCommand) error {
+		if err := db.OnAfterRollback(ctx, func(ctx context.Context) {
+			calls = append(calls, "outer after rollback")
+		}); err != nil {
+			return err
+		}
+
+		if err := commandBus.Execute(ctx, InnerCommand{}); err != nil {
+			return err
+		}
+
+		// the independent transaction has already committed.
+		assert.Equal(t, []string{"inner after commit"}, calls)
+
+		if err := transactionManager.GetTransaction(ctx).Create(&Note{Text: "outer"}).Error; err != nil {
+			return err
+		}
+
+		return Err
+	})
+	assert.NoError(t, err)
+
+	err = commandBus.Execute(context.Background(), OuterCommand{})
+	assert.ErrorIs(t, err, Err)
+	assert.Equal(t, []string{"inner after commit", "outer after rollback"}, calls)
+
+	notes := make([]Note, 0)
+	err = gormDB.Find(&notes).Error
+	assert.NoError(t, err)
+	if assert.Len(t, notes, 1) {
+		assert.Equal(t, "inner", notes[0].Text)
+	}
+}
+
+func TestTransactionMiddleware_Nested(t *testing.T) {
+	t.Parallel()
+
+	Err := errors.New("error")
+
+	gormDB := testdb.NewGorm(t, &Note{})
+
+	transactionManager := db_gorm.NewTransactionManager(gormDB, nil)
+
+	calls := make([]string, 0)
+
+	commandBus := cqrs.NewCommandBus()
+	commandBus.Use(middlewares.NewTransactionMiddleware(transactionManager,
+		middlewares.WithPropagation(InnerCommand{}, middlewares.PropagationNested),
+	).CommandMiddleware())
+	err := cqrs.RegisterCommandHandler(commandBus, func(ctx context.Context, command InnerCommand) error {
+		if err := errors.Join(
+			db.OnAfterCommit(ctx, func(ctx context.Context) {
+				calls = append(calls, "inner after commit")
+			}),
+			db.OnAfterRollback(ctx, func(ctx context.Context) {
+				calls = append(calls, "inner after rollback")
+			}),
+		); err != nil {
+			return err
+		}
+
+		if err := transactionManager.GetTransaction(ctx).Create(&Note{Text: "inner"}).Error; err != nil {
+			return err
+		}
+
+		return Err
+	})
+	assert.NoError(t, err)
+	err = cqrs.RegisterCommandHandler(commandBus, func(ctx context.Context, command OuterCommand) error {
+		if err := db.OnAfterCommit(ctx, func(ctx context.Context) {
+			calls = append(calls, "outer after commit")
+		}); err != nil {
+			return err
+		}
+
+		if err := transactionManager.GetTransaction(ctx).Create(&Note{Text: "outer"}).Error; err != nil {
+			return err
+		}
+
+		// a failing nested command only rolls back its own work.
+		err := commandBus.Execute(ctx, InnerCommand{})
+		assert.ErrorIs(t, err, Err)
+
+		return nil
+	})
+	assert.NoError(t, err)
+
+	err = commandBus.Execute(context.Background(), OuterCommand{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"inner after rollback", "outer after commit"}, calls)
+
+	texts := make([]string, 0)
+	err = gormDB.Model(&Note{}).Pluck("text", &texts).Error
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"outer"}, texts)
+}
+
+type (
+	CreateNote struct{}
+	CountNotes struct{}
+)
+
+func TestTransactionMiddleware_Replica(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		ctx     context.Context
+		execute func(ctx context.Context, commandBus cqrs.CommandBus, queryBus cqrs.QueryBus) (int64, error)
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantNotes int64
+	}{
+		{
+			name: "query reads from the replica",
+			args: args{
+				ctx: context.Background(),
+				execute: func(ctx context.Context, commandBus cqrs.CommandBus, queryBus cqrs.QueryBus) (int64, error) {
+					return cqrs.ExecuteQuery[CountNotes, int64](queryBus, ctx, CountNotes{})
+				},
+			},
+			wantNotes: 0,
+		},
+		{
+			name: "query forced to read from the primary",
+			args: args{
+				ctx: db.ReadFromPrimary(context.Background()),
+				execute: func(ctx context.Context, commandBus cqrs.CommandBus, queryBus cqrs.QueryBus) (int64, error) {
+					return cqrs.ExecuteQuery[CountNotes, int64](queryBus, ctx, CountNotes{})
+				},
+			},
+			wantNotes: 1,
+		},
+		{
+			name: "query executed by a command reads from the primary",
+			args: args{
+				ctx: context.Background(),
+				execute: func(ctx context.Context, commandBus cqrs.CommandBus, queryBus cqrs.QueryBus) (int64, error) {
+					var notes int64
+
+					err := cqrs.RegisterCommandHandler(commandBus, func(ctx context.Context, command CreateNote) error {
+						if err := commandBus.Execute(ctx, OuterCommand{}); err != nil {
+							return err
+						}
+
+						var err error
+
+						notes, err = cqrs.ExecuteQuery[CountNotes, int64](queryBus, ctx, CountNotes{})
+						return err
+					})
+					if err != nil {
+						return 0, err
+					}
+
+					if err := commandBus.Execute(ctx, CreateNote{}); err != nil {
+						return 0, err
+					}
+
+					return notes, nil
+				},
+			},
+			wantNotes: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := testdb.NewGorm(t, &Note{})
+			replica := testdb.NewGorm(t, &Note{})
+
+			transactionManager := db_gorm.NewTransactionManager(primary, nil, db_gorm.WithReplica(replica))
+			transactionMiddleware := middlewares.NewTransactionMiddleware(transactionManager)
+
+			commandBus := cqrs.NewCommandBus()
+			commandBus.Use(transactionMiddleware.CommandMiddleware())
+			err := cqrs.RegisterCommandHandler(commandBus, func(ctx context.Context, command OuterCommand) error {
+				return transactionManager.GetTransaction(ctx).Create(&Note{Text: "note"}).Error
+			})
+			assert.NoError(t, err)
+
+			queryBus := cqrs.NewQueryBus()
+			queryBus.Use(transactionMiddleware.QueryMiddleware())
+			err = cqrs.RegisterQueryHandler(queryBus, func(ctx context.Context, query CountNotes) (int64, error) {
+				var notes int64
+
+				err := transactionManager.GetTransaction(ctx).Model(&Note{}).Count(&notes).Error
+				return notes, err
+			})
+			assert.NoError(t, err)
+
+			err = commandBus.Execute(context.Background(), OuterCommand{})
+			assert.NoError(t, err)
+
+			notes, err := tt.args.execute(tt.args.ctx, commandBus, queryBus)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantNotes, notes)
+		})
+	}
+}
+
+func TestTransactionMiddleware_RetryTransaction(t *testing.T) {
+	t.Parallel()
+
+	var (
+		transientError = mtest.CreateCommandErrorResponse(mtest.CommandError{
+			Code:    112,
+			Name:    "WriteConflict",
+			Message: "write conflict",
+			Labels:  []string{"TransientTransactionError"},
+		})
+		duplicateKeyError = mtest.CreateCommandErrorResponse(mtest.CommandError{
+			Code:    11000,
+			Name:    "DuplicateKey",
+			Message: "duplicate key",
+		})
+	)
+
+	type wants struct {
+		attempts int
+		commands []string
+		events   int
+		err      bool
+	}
+	tests := []struct {
+		name      string
+		responses []bson.D
+		wants     wants
+	}{
+		{
+			name: "transient error re-executes the transaction",
+			responses: []bson.D{
+				transientError,
+				mtest.CreateSuccessResponse(),
+				mtest.CreateSuccessResponse(),
+				mtest.CreateSuccessResponse(),
+			},
+			wants: wants{
+				attempts: 2,
+				commands: []string{"insert", "abortTransaction", "insert", "commitTransaction"},
+				events:   1,
+				err:      false,
+			},
+		},
+		{
+			name: "other errors are returned",
+			responses: []bson.D{
+				duplicateKeyError,
+				mtest.CreateSuccessResponse(),
+			},
+			wants: wants{
+				attempts: 1,
+				commands: []string{"insert", "abortTransaction"},
+				events:   0,
+				err:      true,
+			},
+		},
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			mt.AddMockResponses(tt.responses...)
+
+			transactionManager := db_mongo.NewTransactionManager(mt.DB, nil, nil,
+				db_mongo.WithRetryMaxAttempts(3),
+			)
+
+			events := 0
+			eventBus := cqrs.NewEventBus()
+			err := cqrs.RegisterEventHandler(eventBus, func(ctx context.Context, event AccountOpened) error {
+				events++
+				return nil
+			})
+			assert.NoError(mt, err)
+
+			attempts := 0
+			commandBus := cqrs.NewCommandBus()
+			commandBus.Use(
+				middlewares.NewEventProviderMiddleware().CommandMiddleware(),
+				middlewares.NewEventDispatcherMiddleware(eventBus).CommandMiddleware(),
+				middlewares.NewTransactionMiddleware(transactionManager).CommandMiddleware(),
+			)
+			err = cqrs.RegisterCommandHandler(commandBus, func(ctx context.Context, command OpenAccount) error {
+				attempts++
+
+				eventProvider, _ := cqrs.GetEventProvider(ctx)
+				eventProvider.CollectEvents(AccountOpened{})
+
+				_, err := transactionManager.GetTransaction(ctx).Collection("accounts").InsertOne(ctx, bson.D{{Key: "name", Value: "account"}})
+				return err
+			})
+			assert.NoError(mt, err)
+
+			err = commandBus.Execute(context.Background(), OpenAccount{})
+			assert.Equal(mt, tt.wants.err, err != nil)
+			assert.Equal(mt, tt.wants.attempts, attempts)
+			assert.Equal(mt, tt.wants.events, events)
+
+			commands := make([]string, 0)
+			for _, event := range mt.GetAllStartedEvents() {
+				commands = append(commands, event.CommandName)
+			}
+			assert.Equal(mt, tt.wants.commands, commands)
+		})
+	}
+}
+
+func TestTransactionMiddleware_MongoReplica(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		ctx context.Context
+	}
+	type wants struct {
+		database      string
+		inTransaction bool
+		commands      []string
+	}
+	tests := []struct {
+		name      string
+		args      args
+		responses []bson.D
+		wants     wants
+	}{
+		{
+			name: "query reads from the replica without a transaction",
+			args: args{
+				ctx: context.Background(),
+			},
+			responses: []bson.D{
+				mtest.CreateCursorResponse(0, "replica.notes", mtest.FirstBatch, bson.D{{Key: "text", Value: "note"}}),
+			},
+			wants: wants{
+				database:      "replica",
+				inTransaction: false,
+				commands:      []string{"find"},
+			},
+		},
+		{
+			name: "query forced to read from the primary in a transaction",
+			args: args{
+				ctx: db.ReadFromPrimary(context.Background()),
+			},
+			responses: []bson.D{
+				mtest.CreateCursorResponse(0, "primary.notes", mtest.FirstBatch, bson.D{{Key: "text", Value: "note"}}),
+				mtest.CreateSuccessResponse(),
+			},
+			wants: wants{
+				database:      "primary",
+				inTransaction: true,
+				commands:      []string{"find", "commitTransaction"},
+			},
+		},
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			mt.AddMockResponses(tt.responses...)
+
+			primary := mt.Client.Database("primary")
+			replica := mt.Client.Database("replica")
+			transactionManager := db_mongo.NewTransactionManager(primary, nil, nil, db_mongo.WithReplica(replica))
+
+			queryBus := cqrs.NewQueryBus()
+			queryBus.Use(middlewares.NewTransactionMiddleware(transactionManager).QueryMiddleware())
+			err := cqrs.RegisterQueryHandler(queryBus, func(ctx context.Context, query CountNotes) (int64, error) {
+				cursor, err := transactionManager.GetTransaction(ctx).Collection("notes").Find(ctx, bson.D{})
+				if err != nil {
+					return 0, err
+				}
+
+				var notes []bson.D
+				if err := cursor.All(ctx, &notes); err != nil {
+					return 0, err
+				}
+
+				return int64(len(notes)), nil
+			})
+			assert.NoError(mt, err)
+
+			notes, err := cqrs.ExecuteQuery[CountNotes, int64](queryBus, tt.args.ctx, CountNotes{})
+			assert.NoError(mt, err)
+			assert.Equal(mt, int64(1), notes)
+
+			events := mt.GetAllStartedEvents()
+			commands := make([]string, 0)
+			for _, event := range events {
+				commands = append(commands, event.CommandName)
+			}
+			assert.Equal(mt, tt.wants.commands, commands)
+
+			find := events[0]
+			assert.Equal(mt, tt.wants.database, find.DatabaseName)
+			_, err = find.Command.LookupErr("startTransaction")
+			assert.Equal(mt, tt.wants.inTransaction, err == nil)
+		})
+	}
+}
+
+type serializationError struct{}
+
+func (s serializationError) Error() string {
+	return "could not serialize access due to concurrent update"
+}
+
+func (s serializationError) SQLState() string {
+	return "40001"
+}
+
+func TestTransactionMiddleware_RetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		opts []db_gorm.TransactionManagerOption
+	}
+	type wants struct {
+		attempts int
+		notes    int64
+		err      error
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "no retry policy",
+			args: args{
+				opts: nil,
+			},
+			wants: wants{
+				attempts: 1,
+				notes:    0,
+				err:      serializationError{},
+			},
+		},
+		{
+			name: "serialization failure re-executes the command",
+			args: args{
+				opts: []db_gorm.TransactionManagerOption{
+					db_gorm.WithRetryMaxAttempts(3),
+					db_gorm.WithRetryBackoff(0, 0),
+				},
+			},
+			wants: wants{
+				attempts: 2,
+				notes:    1,
+				err:      nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gormDB := testdb.NewGorm(t, &Note{})
+
+			transactionManager := db_gorm.NewTransactionManager(gormDB, nil, tt.args.opts...)
+
+			attempts := 0
+			commandBus := cqrs.NewCommandBus()
+			commandBus.Use(middlewares.NewTransactionMiddleware(transactionManager).CommandMiddleware())
+			err := cqrs.RegisterCommandHandler(commandBus, func(ctx context.Context, command OuterCommand) error {
+				attempts++
+
+				if err := transactionManager.GetTransaction(ctx).Create(&Note{Text: "note"}).Error; err != nil {
+					return err
+				}
+
+				if attempts == 1 {
+					return serializationError{}
+				}
+
+				return nil
+			})
+			assert.NoError(t, err)
+
+			err = commandBus.Execute(context.Background(), OuterCommand{})
+			assert.ErrorIs(t, err, tt.wants.err)
+			assert.Equal(t, tt.wants.attempts, attempts)
+
+			var notes int64
+			err = gormDB.Model(&Note{}).Count(&notes).Error
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wants.notes, notes)
+		})
+	}
+}
+
+func TestTransactionMiddleware_InMemory(t *testing.T) {
+	t.Parallel()
+
+	Err := errors.New("error")
+
+	type wants struct {
+		accounts []interface{}
+		messages int
+		err      error
+	}
+	tests := []struct {
+		name    string
+		command OpenAccount
+		wants   wants
+	}{
+		{
+			name:    "handler and outbox messages are committed together",
+			command: OpenAccount{},
+			wants: wants{
+				accounts: []interface{}{"account"},
+				messages: 1,
+				err:      nil,
+			},
+		},
+		{
+			name:    "handler and outbox messages are rolled back together",
+			command: OpenAccount{Fail: true},
+			wants: wants{
+				accounts: []interface{}{},
+				messages: 0,
+				err:      Err,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transactionManager := db_memory.NewTransactionManager(db_memory.NewStore())
+			outboxStore := outbox_memory.NewStore(transactionManager)
+
+			commandBus := cqrs.NewCommandBus()
+			commandBus.Use(
+				middlewares.NewTransactionMiddleware(transactionManager).CommandMiddleware(),
+				middlewares.NewEventProviderMiddleware().CommandMiddleware(),
+				middlewares.NewOutboxMiddleware(outboxStore, outbox.NewJSONCodec(AccountOpened{})).CommandMiddleware(),
+			)
+			err := cqrs.RegisterCommandHandler(commandBus, func(ctx context.Context, command OpenAccount) error {
+				if err := transactionManager.GetTransaction(ctx).Put("accounts", "1", "account"); err != nil {
+					return err
+				}
+
+				eventProvider, _ := cqrs.GetEventProvider(ctx)
+				eventProvider.CollectEvents(AccountOpened{})
+
+				if command.Fail {
+					return Err
+				}
+
+				return nil
+			})
+			assert.NoError(t, err)
+
+			err = commandBus.Execute(context.Background(), tt.command)
+			assert.ErrorIs(t, err, tt.wants.err)
+
+			accounts, err := transactionManager.GetTransaction(context.Background()).Find("accounts")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wants.accounts, accounts)
+
+			messages, err := outboxStore.FetchPending(context.Background(), 10)
+			assert.NoError(t, err)
+			assert.Len(t, messages, tt.wants.messages)
+		})
+	}
+}
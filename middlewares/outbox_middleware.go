@@ -0,0 +1,74 @@
+package middlewares
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vulpes-ferrilata/cqrs"
+	"github.com/vulpes-ferrilata/cqrs/pkg/outbox"
+)
+
+func NewOutboxMiddleware(store outbox.Store, codec outbox.Codec) *OutboxMiddleware {
+	return &OutboxMiddleware{
+		store: store,
+		codec: codec,
+	}
+}
+
+// OutboxMiddleware must be used after EventProviderMiddleware and TransactionMiddleware.
+type OutboxMiddleware struct {
+	store outbox.Store
+	codec outbox.Codec
+}
+
+func (o OutboxMiddleware) CommandMiddleware() cqrs.CommandMiddlewareFunc {
+	return func(handler cqrs.CommandHandlerFunc[any]) cqrs.CommandHandlerFunc[any] {
+		return func(ctx context.Context, command any) error {
+			if err := handler(ctx, command); err != nil {
+				return err
+			}
+
+			eventProvider, ok := cqrs.GetEventProvider(ctx)
+			if !ok {
+				return cqrs.ErrEventProviderNotFound
+			}
+
+			events := eventProvider.GetEvents()
+			if len(events) == 0 {
+				return nil
+			}
+
+			correlationID, _ := cqrs.GetCorrelationID(ctx)
+			occurredAt := time.Now()
+
+			messages := make([]outbox.Message, 0, len(events))
+			for _, event := range events {
+				eventType, payload, err := o.codec.Marshal(event)
+				if err != nil {
+					return err
+				}
+
+				id, err := uuid.NewV7()
+				if err != nil {
+					return err
+				}
+
+				messages = append(messages, outbox.Message{
+					ID:            id.String(),
+					EventType:     eventType,
+					Payload:       payload,
+					CorrelationID: correlationID,
+					OccurredAt:    occurredAt,
+				})
+			}
+
+			if err := o.store.Save(ctx, messages...); err != nil {
+				return err
+			}
+
+			return nil
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package middlewares_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/vulpes-ferrilata/cqrs"
+	"github.com/vulpes-ferrilata/cqrs/middlewares"
+	"github.com/vulpes-ferrilata/cqrs/pkg/outbox"
+	mock_outbox "github.com/vulpes-ferrilata/cqrs/pkg/outbox/mocks"
+)
+
+func TestOutboxMiddleware_CommandMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		event   = struct{}{}
+		payload = []byte("{}")
+		Err     = errors.New("error")
+	)
+
+	type mocks struct {
+		store *mock_outbox.MockStore
+		codec *mock_outbox.MockCodec
+	}
+	type args struct {
+		prepare func(ctx context.Context) context.Context
+		handler cqrs.CommandHandlerFunc[any]
+	}
+	tests := []struct {
+		name    string
+		prepare func(mocks mocks)
+		args    args
+		wantErr error
+	}{
+		{
+			name:    "handler return error",
+			prepare: func(mocks mocks) {},
+			args: args{
+				prepare: func(ctx context.Context) context.Context {
+					return cqrs.WithEventProvider(ctx, cqrs.NewEventProvider())
+				},
+				handler: func(ctx context.Context, command interface{}) error {
+					return Err
+				},
+			},
+			wantErr: Err,
+		},
+		{
+			name:    "event provider not found",
+			prepare: func(mocks mocks) {},
+			args: args{
+				prepare: func(ctx context.Context) context.Context {
+					return ctx
+				},
+				handler: func(ctx context.Context, command interface{}) error {
+					return nil
+				},
+			},
+			wantErr: cqrs.ErrEventProviderNotFound,
+		},
+		{
+			name:    "no events collected",
+			prepare: func(mocks mocks) {},
+			args: args{
+				prepare: func(ctx context.Context) context.Context {
+					return cqrs.WithEventProvider(ctx, cqrs.NewEventProvider())
+				},
+				handler: func(ctx context.Context, command interface{}) error {
+					return nil
+				},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "marshal fail",
+			prepare: func(mocks mocks) {
+				mocks.codec.EXPECT().Marshal(event).Return("", nil, Err)
+			},
+			args: args{
+				prepare: func(ctx context.Context) context.Context {
+					return cqrs.WithEventProvider(ctx, cqrs.NewEventProvider())
+				},
+				handler: func(ctx context.Context, command interface{}) error {
+					eventProvider, _ := cqrs.GetEventProvider(ctx)
+					eventProvider.CollectEvents(event)
+					return nil
+				},
+			},
+			wantErr: Err,
+		},
+		{
+			name: "save fail",
+			prepare: func(mocks mocks) {
+				mocks.codec.EXPECT().Marshal(event).Return("event", payload, nil)
+				mocks.store.EXPECT().Save(gomock.Any(), gomock.Any()).Return(Err)
+			},
+			args: args{
+				prepare: func(ctx context.Context) context.Context {
+					return cqrs.WithEventProvider(ctx, cqrs.NewEventProvider())
+				},
+				handler: func(ctx context.Context, command interface{}) error {
+					eventProvider, _ := cqrs.GetEventProvider(ctx)
+					eventProvider.CollectEvents(event)
+					return nil
+				},
+			},
+			wantErr: Err,
+		},
+		{
+			name: "success",
+			prepare: func(mocks mocks) {
+				mocks.codec.EXPECT().Marshal(event).Return("event", payload, nil).Times(2)
+				mocks.store.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, messages ...outbox.Message) error {
+					assert.Len(t, messages, 2)
+					for _, message := range messages {
+						assert.NotEmpty(t, message.ID)
+						assert.Equal(t, "event", message.EventType)
+						assert.Equal(t, payload, message.Payload)
+						assert.Equal(t, "correlation-id", message.CorrelationID)
+						assert.False(t, message.OccurredAt.IsZero())
+					}
+					assert.Less(t, messages[0].ID, messages[1].ID)
+					return nil
+				})
+			},
+			args: args{
+				prepare: func(ctx context.Context) context.Context {
+					ctx = cqrs.WithCorrelationID(ctx, "correlation-id")
+					return cqrs.WithEventProvider(ctx, cqrs.NewEventProvider())
+				},
+				handler: func(ctx context.Context, command interface{}) error {
+					eventProvider, _ := cqrs.GetEventProvider(ctx)
+					eventProvider.CollectEvents(event, event)
+					return nil
+				},
+			},
+			wantErr: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := mocks{
+				store: mock_outbox.NewMockStore(mockCtrl),
+				codec: mock_outbox.NewMockCodec(mockCtrl),
+			}
+
+			tt.prepare(mocks)
+
+			outboxMiddleware := middlewares.NewOutboxMiddleware(mocks.store, mocks.codec)
+			commandMiddleware := outboxMiddleware.CommandMiddleware()
+			handler := commandMiddleware(tt.args.handler)
+			err := handler(tt.args.prepare(context.Background()), struct{}{})
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/vulpes-ferrilata/cqrs"
+	"github.com/vulpes-ferrilata/cqrs/pkg/ratelimit"
+)
+
+type RateLimitError struct {
+	MessageType string
+	Key         string
+	RetryAfter  time.Duration
+}
+
+func (r RateLimitError) Error() string {
+	return fmt.Sprintf("%s rate limited, retry after %s", r.MessageType, r.RetryAfter)
+}
+
+func (r RateLimitError) Unwrap() error {
+	return cqrs.ErrRateLimited
+}
+
+type RateLimitOption func(r *RateLimitMiddleware)
+
+func WithDefaultRateLimit(limit ratelimit.Limit) RateLimitOption {
+	return func(r *RateLimitMiddleware) {
+		r.defaultLimit = &limit
+	}
+}
+
+func WithRateLimit(message interface{}, limit ratelimit.Limit) RateLimitOption {
+	return func(r *RateLimitMiddleware) {
+		r.limits[reflect.TypeOf(message)] = limit
+	}
+}
+
+func WithRateLimitKey(keyFunc func(ctx context.Context) string) RateLimitOption {
+	return func(r *RateLimitMiddleware) {
+		r.keyFunc = keyFunc
+	}
+}
+
+func WithRateLimitWait(maxWait time.Duration) RateLimitOption {
+	return func(r *RateLimitMiddleware) {
+		r.maxWait = maxWait
+	}
+}
+
+// NewRateLimitMiddleware raises the burst of limits lower than 1 to 1, such limits would reject every message.
+func NewRateLimitMiddleware(store ratelimit.Store, opts ...RateLimitOption) *RateLimitMiddleware {
+	rateLimitMiddleware := &RateLimitMiddleware{
+		store:  store,
+		limits: make(map[reflect.Type]ratelimit.Limit),
+	}
+
+	for _, opt := range opts {
+		opt(rateLimitMiddleware)
+	}
+
+	if rateLimitMiddleware.defaultLimit != nil && rateLimitMiddleware.defaultLimit.Burst < 1 {
+		rateLimitMiddleware.defaultLimit.Burst = 1
+	}
+
+	for messageType, limit := range rateLimitMiddleware.limits {
+		if limit.Burst < 1 {
+			limit.Burst = 1
+			rateLimitMiddleware.limits[messageType] = limit
+		}
+	}
+
+	return rateLimitMiddleware
+}
+
+type RateLimitMiddleware struct {
+	store        ratelimit.Store
+	defaultLimit *ratelimit.Limit
+	limits       map[reflect.Type]ratelimit.Limit
+	keyFunc      func(ctx context.Context) string
+	maxWait      time.Duration
+}
+
+func (r RateLimitMiddleware) limit(message interface{}) (ratelimit.Limit, bool) {
+	limit, ok := r.limits[reflect.TypeOf(message)]
+	if ok {
+		return limit, true
+	}
+
+	if r.defaultLimit != nil {
+		return *r.defaultLimit, true
+	}
+
+	return ratelimit.Limit{}, false
+}
+
+func (r RateLimitMiddleware) take(ctx context.Context, message interface{}) error {
+	limit, ok := r.limit(message)
+	if !ok {
+		return nil
+	}
+
+	messageType := messageType(message)
+
+	var key string
+	if r.keyFunc != nil {
+		key = r.keyFunc(ctx)
+	}
+
+	deadline := time.Now().Add(r.maxWait)
+
+	for {
+		allowed, retryAfter, err := r.store.Take(ctx, messageType+"\x00"+key, limit)
+		if err != nil {
+			return err
+		}
+
+		if allowed {
+			return nil
+		}
+
+		if time.Now().Add(retryAfter).After(deadline) {
+			return RateLimitError{
+				MessageType: messageType,
+				Key:         key,
+				RetryAfter:  retryAfter,
+			}
+		}
+
+		timer := time.NewTimer(retryAfter)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+
+			return ctx.Err()
+		}
+	}
+}
+
+func (r RateLimitMiddleware) CommandMiddleware() cqrs.CommandMiddlewareFunc {
+	return func(handler cqrs.CommandHandlerFunc[any]) cqrs.CommandHandlerFunc[any] {
+		return func(ctx context.Context, command any) error {
+			if err := r.take(ctx, command); err != nil {
+				return err
+			}
+
+			if err := handler(ctx, command); err != nil {
+				return err
+			}
+
+			return nil
+		}
+	}
+}
+
+func (r RateLimitMiddleware) QueryMiddleware() cqrs.QueryMiddlewareFunc {
+	return func(handler cqrs.QueryHandlerFunc[any, any]) cqrs.QueryHandlerFunc[any, any] {
+		return func(ctx context.Context, query any) (interface{}, error) {
+			if err := r.take(ctx, query); err != nil {
+				return nil, err
+			}
+
+			result, err := handler(ctx, query)
+			if err != nil {
+				return nil, err
+			}
+
+			return result, nil
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package middlewares_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/vulpes-ferrilata/cqrs"
+	"github.com/vulpes-ferrilata/cqrs/middlewares"
+	"github.com/vulpes-ferrilata/cqrs/pkg/ratelimit"
+	mock_ratelimit "github.com/vulpes-ferrilata/cqrs/pkg/ratelimit/mocks"
+)
+
+type (
+	LimitedCommand struct{}
+	tenantKey      struct{}
+)
+
+func TestRateLimitMiddleware_CommandMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx     = context.WithValue(context.Background(), tenantKey{}, "tenant")
+		key     = "github.com/vulpes-ferrilata/cqrs/middlewares_test.LimitedCommand\x00tenant"
+		limit   = ratelimit.Limit{Rate: 1, Burst: 1}
+		command = LimitedCommand{}
+		Err     = errors.New("error")
+	)
+
+	type mocks struct {
+		store *mock_ratelimit.MockStore
+	}
+	type args struct {
+		handler cqrs.CommandHandlerFunc[any]
+		command interface{}
+		maxWait time.Duration
+	}
+	tests := []struct {
+		name    string
+		prepare func(mocks mocks)
+		args    args
+		wantErr error
+	}{
+		{
+			name:    "message without limit",
+			prepare: func(mocks mocks) {},
+			args: args{
+				handler: func(ctx context.Context, command interface{}) error {
+					return nil
+				},
+				command: struct{}{},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "take fail",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().Take(ctx, key, limit).Return(false, time.Duration(0), Err)
+			},
+			args: args{
+				handler: func(ctx context.Context, command interface{}) error {
+					return nil
+				},
+				command: command,
+			},
+			wantErr: Err,
+		},
+		{
+			name: "rate limited",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().Take(ctx, key, limit).Return(false, time.Second, nil)
+			},
+			args: args{
+				handler: func(ctx context.Context, command interface{}) error {
+					return nil
+				},
+				command: command,
+			},
+			wantErr: middlewares.RateLimitError{
+				MessageType: "github.com/vulpes-ferrilata/cqrs/middlewares_test.LimitedCommand",
+				Key:         "tenant",
+				RetryAfter:  time.Second,
+			},
+		},
+		{
+			name: "rate limited beyond wait budget",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().Take(ctx, key, limit).Return(false, time.Second, nil)
+			},
+			args: args{
+				handler: func(ctx context.Context, command interface{}) error {
+					return nil
+				},
+				command: command,
+				maxWait: 100 * time.Millisecond,
+			},
+			wantErr: cqrs.ErrRateLimited,
+		},
+		{
+			name: "allowed after waiting",
+			prepare: func(mocks mocks) {
+				gomock.InOrder(
+					mocks.store.EXPECT().Take(ctx, key, limit).Return(false, 10*time.Millisecond, nil),
+					mocks.store.EXPECT().Take(ctx, key, limit).Return(true, time.Duration(0), nil),
+				)
+			},
+			args: args{
+				handler: func(ctx context.Context, command interface{}) error {
+					return nil
+				},
+				command: command,
+				maxWait: 100 * time.Millisecond,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "handler return error",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().Take(ctx, key, limit).Return(true, time.Duration(0), nil)
+			},
+			args: args{
+				handler: func(ctx context.Context, command interface{}) error {
+					return Err
+				},
+				command: command,
+			},
+			wantErr: Err,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := mocks{
+				store: mock_ratelimit.NewMockStore(mockCtrl),
+			}
+
+			tt.prepare(mocks)
+
+			rateLimitMiddleware := middlewares.NewRateLimitMiddleware(mocks.store,
+				middlewares.WithRateLimit(LimitedCommand{}, limit),
+				middlewares.WithRateLimitKey(func(ctx context.Context) string {
+					tenant, _ := ctx.Value(tenantKey{}).(string)
+					return tenant
+				}),
+				middlewares.WithRateLimitWait(tt.args.maxWait),
+			)
+			commandMiddleware := rateLimitMiddleware.CommandMiddleware()
+			handler := commandMiddleware(tt.args.handler)
+			err := handler(ctx, tt.args.command)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestRateLimitMiddleware_QueryMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx    = context.Background()
+		key    = "struct {}\x00"
+		limit  = ratelimit.Limit{Rate: 1, Burst: 1}
+		result = struct{}{}
+	)
+
+	type mocks struct {
+		store *mock_ratelimit.MockStore
+	}
+	type wants struct {
+		result interface{}
+		err    error
+	}
+	tests := []struct {
+		name    string
+		prepare func(mocks mocks)
+		wants   wants
+	}{
+		{
+			name: "rate limited",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().Take(ctx, key, limit).Return(false, time.Second, nil)
+			},
+			wants: wants{
+				result: nil,
+				err:    cqrs.ErrRateLimited,
+			},
+		},
+		{
+			name: "success",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().Take(ctx, key, limit).Return(true, time.Duration(0), nil)
+			},
+			wants: wants{
+				result: result,
+				err:    nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := mocks{
+				store: mock_ratelimit.NewMockStore(mockCtrl),
+			}
+
+			tt.prepare(mocks)
+
+			rateLimitMiddleware := middlewares.NewRateLimitMiddleware(mocks.store,
+				middlewares.WithDefaultRateLimit(limit),
+			)
+			queryMiddleware := rateLimitMiddleware.QueryMiddleware()
+			handler := queryMiddleware(func(ctx context.Context, query interface{}) (interface{}, error) {
+				return result, nil
+			})
+			result, err := handler(ctx, struct{}{})
+			assert.ErrorIs(t, err, tt.wants.err)
+			assert.Equal(t, tt.wants.result, result)
+		})
+	}
+}
+
+func TestNewRateLimitMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+		key = "github.com/vulpes-ferrilata/cqrs/middlewares_test.LimitedCommand\x00"
+	)
+
+	tests := []struct {
+		name      string
+		opts      []middlewares.RateLimitOption
+		wantLimit ratelimit.Limit
+	}{
+		{
+			name: "default limit without burst",
+			opts: []middlewares.RateLimitOption{
+				middlewares.WithDefaultRateLimit(ratelimit.Limit{Rate: 1, Burst: 0}),
+			},
+			wantLimit: ratelimit.Limit{Rate: 1, Burst: 1},
+		},
+		{
+			name: "message limit without burst",
+			opts: []middlewares.RateLimitOption{
+				middlewares.WithRateLimit(LimitedCommand{}, ratelimit.Limit{Rate: 1, Burst: -1}),
+			},
+			wantLimit: ratelimit.Limit{Rate: 1, Burst: 1},
+		},
+		{
+			name: "limit with burst",
+			opts: []middlewares.RateLimitOption{
+				middlewares.WithDefaultRateLimit(ratelimit.Limit{Rate: 1, Burst: 1}),
+				middlewares.WithRateLimit(LimitedCommand{}, ratelimit.Limit{Rate: 1, Burst: 3}),
+			},
+			wantLimit: ratelimit.Limit{Rate: 1, Burst: 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			store := mock_ratelimit.NewMockStore(mockCtrl)
+			store.EXPECT().Take(ctx, key, tt.wantLimit).Return(true, time.Duration(0), nil)
+
+			rateLimitMiddleware := middlewares.NewRateLimitMiddleware(store, tt.opts...)
+			commandMiddleware := rateLimitMiddleware.CommandMiddleware()
+			handler := commandMiddleware(func(ctx context.Context, command interface{}) error {
+				return nil
+			})
+			err := handler(ctx, LimitedCommand{})
+			assert.NoError(t, err)
+		})
+	}
+}
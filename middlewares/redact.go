@@ -0,0 +1,128 @@
+package middlewares
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+)
+
+const (
+	redactedValue = "[REDACTED]"
+	cyclicValue   = "[CYCLIC]"
+)
+
+type visit struct {
+	pointer   uintptr
+	valueType reflect.Type
+}
+
+type redactedMessage struct {
+	message interface{}
+}
+
+func (r redactedMessage) LogValue() slog.Value {
+	return redactValue(reflect.ValueOf(r.message), make(map[visit]struct{}))
+}
+
+func hasTagOption(field reflect.StructField, option string) bool {
+	for _, tagOption := range strings.Split(field.Tag.Get("cqrs"), ",") {
+		if strings.TrimSpace(tagOption) == option {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isSensitive(field reflect.StructField) bool {
+	return hasTagOption(field, "sensitive")
+}
+
+func hasExportedFields(structType reflect.Type) bool {
+	for i := 0; i < structType.NumField(); i++ {
+		if structType.Field(i).IsExported() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func redactValue(value reflect.Value, visited map[visit]struct{}) slog.Value {
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice:
+		if value.IsNil() {
+			return slog.AnyValue(nil)
+		}
+
+		visit := visit{value.Pointer(), value.Type()}
+		if _, ok := visited[visit]; ok {
+			return slog.StringValue(cyclicValue)
+		}
+
+		visited[visit] = struct{}{}
+		defer delete(visited, visit)
+	}
+
+	switch value.Kind() {
+	case reflect.Invalid:
+		return slog.AnyValue(nil)
+	case reflect.Pointer, reflect.Interface:
+		if value.IsNil() {
+			return slog.AnyValue(nil)
+		}
+
+		return redactValue(value.Elem(), visited)
+	case reflect.Struct:
+		if !hasExportedFields(value.Type()) {
+			return slog.AnyValue(value.Interface())
+		}
+
+		attrs := make([]slog.Attr, 0, value.NumField())
+
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			if isSensitive(field) {
+				attrs = append(attrs, slog.String(field.Name, redactedValue))
+				continue
+			}
+
+			attrs = append(attrs, slog.Attr{
+				Key:   field.Name,
+				Value: redactValue(value.Field(i), visited),
+			})
+		}
+
+		return slog.GroupValue(attrs...)
+	case reflect.Slice, reflect.Array:
+		attrs := make([]slog.Attr, 0, value.Len())
+
+		for i := 0; i < value.Len(); i++ {
+			attrs = append(attrs, slog.Attr{
+				Key:   fmt.Sprint(i),
+				Value: redactValue(value.Index(i), visited),
+			})
+		}
+
+		return slog.GroupValue(attrs...)
+	case reflect.Map:
+		attrs := make([]slog.Attr, 0, value.Len())
+
+		iter := value.MapRange()
+		for iter.Next() {
+			attrs = append(attrs, slog.Attr{
+				Key:   fmt.Sprint(iter.Key().Interface()),
+				Value: redactValue(iter.Value(), visited),
+			})
+		}
+
+		return slog.GroupValue(attrs...)
+	default:
+		return slog.AnyValue(value.Interface())
+	}
+}
@@ -0,0 +1,162 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/vulpes-ferrilata/cqrs"
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+)
+
+type RetryClassifier func(err error) bool
+
+type RetryOption func(r *RetryMiddleware)
+
+func WithRetryMaxAttempts(maxAttempts int) RetryOption {
+	return func(r *RetryMiddleware) {
+		r.maxAttempts = maxAttempts
+	}
+}
+
+func WithRetryBackoff(initialBackoff time.Duration, maxBackoff time.Duration) RetryOption {
+	return func(r *RetryMiddleware) {
+		r.initialBackoff = initialBackoff
+		r.maxBackoff = maxBackoff
+	}
+}
+
+// WithRetryDeadline bounds the attempts as a whole.
+func WithRetryDeadline(deadline time.Duration) RetryOption {
+	return func(r *RetryMiddleware) {
+		r.deadline = deadline
+	}
+}
+
+func WithRetryClassifier(classifiers ...RetryClassifier) RetryOption {
+	return func(r *RetryMiddleware) {
+		r.classifiers = append(r.classifiers, classifiers...)
+	}
+}
+
+func NewRetryMiddleware(opts ...RetryOption) *RetryMiddleware {
+	retryMiddleware := &RetryMiddleware{
+		maxAttempts:    3,
+		initialBackoff: 50 * time.Millisecond,
+		maxBackoff:     time.Second,
+		classifiers: []RetryClassifier{
+			func(err error) bool {
+				return errors.Is(err, cqrs.ErrConcurrencyConflict)
+			},
+		},
+	}
+
+	for _, opt := range opts {
+		opt(retryMiddleware)
+	}
+
+	return retryMiddleware
+}
+
+// RetryMiddleware must be used before EventProviderMiddleware and TransactionMiddleware.
+// Transient database errors are better retried by the db.TransactionRetrier of the TransactionManager.
+type RetryMiddleware struct {
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	deadline       time.Duration
+	classifiers    []RetryClassifier
+}
+
+func (r RetryMiddleware) isRetryable(err error) bool {
+	for _, classifier := range r.classifiers {
+		if classifier(err) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r RetryMiddleware) retry(ctx context.Context, fn func(ctx context.Context) error) error {
+	if isRetrying(ctx) {
+		return fn(ctx)
+	}
+
+	ctx = withRetrying(ctx)
+
+	if r.deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.deadline)
+		defer cancel()
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= r.maxAttempts || !r.isRetryable(err) {
+			return err
+		}
+
+		backoff := db.Backoff(attempt, r.initialBackoff, r.maxBackoff)
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(backoff).After(deadline) {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+
+			return err
+		}
+	}
+}
+
+func (r RetryMiddleware) CommandMiddleware() cqrs.CommandMiddlewareFunc {
+	return func(handler cqrs.CommandHandlerFunc[any]) cqrs.CommandHandlerFunc[any] {
+		return func(ctx context.Context, command any) error {
+			return r.retry(ctx, func(ctx context.Context) error {
+				eventProvider, ok := cqrs.GetEventProvider(ctx)
+				if !ok {
+					return handler(ctx, command)
+				}
+
+				attemptEventProvider := cqrs.NewEventProvider()
+				ctx = cqrs.WithEventProvider(ctx, attemptEventProvider)
+
+				if err := handler(ctx, command); err != nil {
+					return err
+				}
+
+				eventProvider.CollectEvents(attemptEventProvider.GetEvents()...)
+
+				return nil
+			})
+		}
+	}
+}
+
+func (r RetryMiddleware) QueryMiddleware() cqrs.QueryMiddlewareFunc {
+	return func(handler cqrs.QueryHandlerFunc[any, any]) cqrs.QueryHandlerFunc[any, any] {
+		return func(ctx context.Context, query any) (interface{}, error) {
+			var result interface{}
+
+			err := r.retry(ctx, func(ctx context.Context) error {
+				var err error
+
+				result, err = handler(ctx, query)
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			return result, nil
+		}
+	}
+}
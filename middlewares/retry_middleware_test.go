@@ -0,0 +1,304 @@
+package middlewares_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+
+	"github.com/vulpes-ferrilata/cqrs"
+	"github.com/vulpes-ferrilata/cqrs/middlewares"
+	mock_db "github.com/vulpes-ferrilata/cqrs/pkg/db/mocks"
+)
+
+func TestRetryMiddleware_CommandMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		Err         = errors.New("error")
+		ConflictErr = fmt.Errorf("update aggregate: %w", cqrs.ErrConcurrencyConflict)
+	)
+
+	type args struct {
+		errs []error
+	}
+	type wants struct {
+		attempts int
+		events   []interface{}
+		err      error
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "success",
+			args: args{
+				errs: []error{nil},
+			},
+			wants: wants{
+				attempts: 1,
+				events:   []interface{}{1},
+				err:      nil,
+			},
+		},
+		{
+			name: "non retryable error",
+			args: args{
+				errs: []error{Err},
+			},
+			wants: wants{
+				attempts: 1,
+				events:   nil,
+				err:      Err,
+			},
+		},
+		{
+			name: "success after retry",
+			args: args{
+				errs: []error{ConflictErr, nil},
+			},
+			wants: wants{
+				attempts: 2,
+				events:   []interface{}{2},
+				err:      nil,
+			},
+		},
+		{
+			name: "max attempts exceeded",
+			args: args{
+				errs: []error{ConflictErr, ConflictErr, ConflictErr, nil},
+			},
+			wants: wants{
+				attempts: 3,
+				events:   nil,
+				err:      cqrs.ErrConcurrencyConflict,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			eventProvider := cqrs.NewEventProvider()
+			ctx := cqrs.WithEventProvider(context.Background(), eventProvider)
+
+			retryMiddleware := middlewares.NewRetryMiddleware(
+				middlewares.WithRetryBackoff(time.Millisecond, time.Millisecond),
+			)
+			commandMiddleware := retryMiddleware.CommandMiddleware()
+			handler := commandMiddleware(func(ctx context.Context, command interface{}) error {
+				attempts++
+
+				eventProvider, ok := cqrs.GetEventProvider(ctx)
+				if ok {
+					eventProvider.CollectEvents(attempts)
+				}
+
+				return tt.args.errs[attempts-1]
+			})
+			err := handler(ctx, struct{}{})
+			assert.ErrorIs(t, err, tt.wants.err)
+			assert.Equal(t, tt.wants.attempts, attempts)
+			assert.Equal(t, tt.wants.events, eventProvider.GetEvents())
+		})
+	}
+}
+
+func TestRetryMiddleware_CommandMiddleware_NestedCommand(t *testing.T) {
+	t.Parallel()
+
+	innerAttempts := 0
+	outerAttempts := 0
+
+	retryMiddleware := middlewares.NewRetryMiddleware(
+		middlewares.WithRetryBackoff(time.Millisecond, time.Millisecond),
+	)
+	commandMiddleware := retryMiddleware.CommandMiddleware()
+	innerHandler := commandMiddleware(func(ctx context.Context, command interface{}) error {
+		innerAttempts++
+
+		return cqrs.ErrConcurrencyConflict
+	})
+	outerHandler := commandMiddleware(func(ctx context.Context, command interface{}) error {
+		outerAttempts++
+
+		return innerHandler(ctx, command)
+	})
+	err := outerHandler(context.Background(), struct{}{})
+	assert.ErrorIs(t, err, cqrs.ErrConcurrencyConflict)
+	assert.Equal(t, 3, outerAttempts)
+	assert.Equal(t, 3, innerAttempts)
+}
+
+func TestRetryMiddleware_CommandMiddleware_TransactionMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx    = context.Background()
+		txCtx1 = context.WithValue(ctx, "attempt", 1)
+		txCtx2 = context.WithValue(ctx, "attempt", 2)
+	)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	transactionManager := mock_db.NewMockTransactionManager[*gorm.DB](mockCtrl)
+	committer := mock_db.NewMockCommitter(mockCtrl)
+
+	gomock.InOrder(
+		transactionManager.EXPECT().IsTransactionStarted(gomock.Any()).Return(false),
+		transactionManager.EXPECT().StartTransaction(gomock.Any()).Return(committer, txCtx1, nil),
+		committer.EXPECT().RollbackTransaction(context.WithoutCancel(txCtx1)).Return(nil),
+		transactionManager.EXPECT().IsTransactionStarted(gomock.Any()).Return(false),
+		transactionManager.EXPECT().StartTransaction(gomock.Any()).Return(committer, txCtx2, nil),
+		committer.EXPECT().CommitTransaction(txCtx2).Return(nil),
+	)
+
+	retryMiddleware := middlewares.NewRetryMiddleware(
+		middlewares.WithRetryBackoff(time.Millisecond, time.Millisecond),
+	)
+	transactionMiddleware := middlewares.NewTransactionMiddleware[*gorm.DB](transactionManager)
+
+	attempts := 0
+	handler := retryMiddleware.CommandMiddleware()(transactionMiddleware.CommandMiddleware()(func(ctx context.Context, command interface{}) error {
+		attempts++
+		if attempts == 1 {
+			return cqrs.ErrConcurrencyConflict
+		}
+
+		return nil
+	}))
+	err := handler(ctx, struct{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+}
+
+func TestRetryMiddleware_QueryMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		result = struct{}{}
+		Err    = errors.New("error")
+	)
+
+	type args struct {
+		errs  []error
+		block bool
+	}
+	type wants struct {
+		attempts int
+		result   interface{}
+		err      error
+	}
+	tests := []struct {
+		name  string
+		opts  []middlewares.RetryOption
+		args  args
+		wants wants
+	}{
+		{
+			name: "non retryable error",
+			args: args{
+				errs: []error{Err},
+			},
+			wants: wants{
+				attempts: 1,
+				result:   nil,
+				err:      Err,
+			},
+		},
+		{
+			name: "custom classifier",
+			opts: []middlewares.RetryOption{
+				middlewares.WithRetryClassifier(func(err error) bool {
+					return errors.Is(err, Err)
+				}),
+			},
+			args: args{
+				errs: []error{Err, nil},
+			},
+			wants: wants{
+				attempts: 2,
+				result:   result,
+				err:      nil,
+			},
+		},
+		{
+			name: "deadline exceeded",
+			opts: []middlewares.RetryOption{
+				middlewares.WithRetryBackoff(time.Second, time.Second),
+				middlewares.WithRetryDeadline(100 * time.Millisecond),
+			},
+			args: args{
+				errs: []error{cqrs.ErrConcurrencyConflict, nil},
+			},
+			wants: wants{
+				attempts: 1,
+				result:   nil,
+				err:      cqrs.ErrConcurrencyConflict,
+			},
+		},
+		{
+			name: "slow attempt bounded by deadline",
+			opts: []middlewares.RetryOption{
+				middlewares.WithRetryDeadline(10 * time.Millisecond),
+			},
+			args: args{
+				errs:  []error{nil},
+				block: true,
+			},
+			wants: wants{
+				attempts: 1,
+				result:   nil,
+				err:      context.DeadlineExceeded,
+			},
+		},
+		{
+			name: "success after retry",
+			args: args{
+				errs: []error{cqrs.ErrConcurrencyConflict, nil},
+			},
+			wants: wants{
+				attempts: 2,
+				result:   result,
+				err:      nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+
+			opts := append([]middlewares.RetryOption{
+				middlewares.WithRetryBackoff(time.Millisecond, time.Millisecond),
+			}, tt.opts...)
+			retryMiddleware := middlewares.NewRetryMiddleware(opts...)
+			queryMiddleware := retryMiddleware.QueryMiddleware()
+			handler := queryMiddleware(func(ctx context.Context, query interface{}) (interface{}, error) {
+				attempts++
+
+				if tt.args.block {
+					<-ctx.Done()
+
+					return nil, ctx.Err()
+				}
+
+				if err := tt.args.errs[attempts-1]; err != nil {
+					return nil, err
+				}
+
+				return result, nil
+			})
+			result, err := handler(context.Background(), struct{}{})
+			assert.ErrorIs(t, err, tt.wants.err)
+			assert.Equal(t, tt.wants.attempts, attempts)
+			assert.Equal(t, tt.wants.result, result)
+		})
+	}
+}
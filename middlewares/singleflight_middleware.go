@@ -0,0 +1,168 @@
+package middlewares
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/vulpes-ferrilata/cqrs"
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+)
+
+type SingleflightOption func(s *SingleflightMiddleware)
+
+func WithSingleflightKey(keyFunc func(query interface{}) (string, error)) SingleflightOption {
+	return func(s *SingleflightMiddleware) {
+		s.keyFunc = keyFunc
+	}
+}
+
+// NewSingleflightMiddleware shares a handler call between identical queries of the same scope. The call runs
+// with the context values of the query starting it, so the scope must cover every value handlers read,
+// e.g. the principal or the tenant.
+func NewSingleflightMiddleware(scopeFunc func(ctx context.Context) string, opts ...SingleflightOption) *SingleflightMiddleware {
+	singleflightMiddleware := &SingleflightMiddleware{
+		keyFunc:   messageKey,
+		scopeFunc: scopeFunc,
+		calls:     make(map[string]*singleflightCall),
+	}
+
+	for _, opt := range opts {
+		opt(singleflightMiddleware)
+	}
+
+	return singleflightMiddleware
+}
+
+type SingleflightMiddleware struct {
+	keyFunc   func(query interface{}) (string, error)
+	scopeFunc func(ctx context.Context) string
+	calls     map[string]*singleflightCall
+	mu        sync.Mutex
+}
+
+type singleflightCall struct {
+	done     chan struct{}
+	cancel   context.CancelFunc
+	deadline time.Time
+	bounded  bool
+	waiters  int
+	result   interface{}
+	err      error
+	panic    interface{}
+}
+
+func (s *SingleflightMiddleware) key(ctx context.Context, query interface{}) (string, bool) {
+	key, err := s.keyFunc(query)
+	if err != nil {
+		return "", false
+	}
+
+	return s.scopeFunc(ctx) + "\x00" + messageType(query) + "\x00" + key, true
+}
+
+// outlives reports whether the call runs at least until the deadline of the context.
+func (c *singleflightCall) outlives(ctx context.Context) bool {
+	if !c.bounded {
+		return true
+	}
+
+	deadline, ok := ctx.Deadline()
+	return ok && !deadline.After(c.deadline)
+}
+
+func (s *SingleflightMiddleware) QueryMiddleware() cqrs.QueryMiddlewareFunc {
+	return func(handler cqrs.QueryHandlerFunc[any, any]) cqrs.QueryHandlerFunc[any, any] {
+		return func(ctx context.Context, query any) (interface{}, error) {
+			// queries inside a transaction must see its writes and keep it to themselves.
+			if db.InTransaction(ctx) {
+				return handler(ctx, query)
+			}
+
+			key, ok := s.key(ctx, query)
+			if !ok {
+				return handler(ctx, query)
+			}
+
+			s.mu.Lock()
+			call, ok := s.calls[key]
+			if !ok || !call.outlives(ctx) {
+				callCtx, cancel := callContext(ctx)
+				deadline, bounded := ctx.Deadline()
+				call = &singleflightCall{
+					done:     make(chan struct{}),
+					cancel:   cancel,
+					deadline: deadline,
+					bounded:  bounded,
+				}
+				s.calls[key] = call
+
+				go s.do(callCtx, key, call, handler, query)
+			}
+			call.waiters++
+			s.mu.Unlock()
+
+			select {
+			case <-call.done:
+				if call.panic != nil {
+					panic(call.panic)
+				}
+
+				if call.err != nil {
+					return nil, call.err
+				}
+
+				return call.result, nil
+			case <-ctx.Done():
+				s.leave(key, call)
+
+				return nil, ctx.Err()
+			}
+		}
+	}
+}
+
+// callContext keeps the values and the deadline of the query starting the call, queries with a later deadline
+// start a call of their own.
+func callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if deadline, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(context.WithoutCancel(ctx), deadline)
+	}
+
+	return context.WithCancel(context.WithoutCancel(ctx))
+}
+
+func (s *SingleflightMiddleware) do(ctx context.Context, key string, call *singleflightCall, handler cqrs.QueryHandlerFunc[any, any], query interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			call.panic = r
+		}
+
+		s.mu.Lock()
+		if s.calls[key] == call {
+			delete(s.calls, key)
+		}
+		s.mu.Unlock()
+
+		call.cancel()
+		close(call.done)
+	}()
+
+	call.result, call.err = handler(ctx, query)
+}
+
+func (s *SingleflightMiddleware) leave(key string, call *singleflightCall) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	call.waiters--
+	if call.waiters > 0 {
+		return
+	}
+
+	if s.calls[key] == call {
+		delete(s.calls, key)
+	}
+
+	call.cancel()
+}
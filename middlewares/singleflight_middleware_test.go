@@ -0,0 +1,248 @@
+package middlewares_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vulpes-ferrilata/cqrs/middlewares"
+	db_memory "github.com/vulpes-ferrilata/cqrs/pkg/db/memory"
+)
+
+type (
+	SingleflightQuery struct {
+		ID int
+	}
+	scopeKey struct{}
+)
+
+func TestSingleflightMiddleware_QueryMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		result = struct{}{}
+		Err    = errors.New("error")
+	)
+
+	shortCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	longCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	noScope := func(ctx context.Context) string {
+		return ""
+	}
+
+	transactionManager := db_memory.NewTransactionManager(db_memory.NewStore())
+	transactionCtx := func() context.Context {
+		_, ctx, _ := transactionManager.StartTransaction(context.Background())
+		return ctx
+	}
+
+	type execution struct {
+		delay time.Duration
+		ctx   func() context.Context
+		query interface{}
+	}
+	type wants struct {
+		calls   int32
+		results []interface{}
+		errs    []error
+	}
+	tests := []struct {
+		name       string
+		scope      func(ctx context.Context) string
+		opts       []middlewares.SingleflightOption
+		err        error
+		executions []execution
+		wants      wants
+	}{
+		{
+			name:  "identical queries share one handler call",
+			scope: noScope,
+			executions: []execution{
+				{ctx: context.Background, query: SingleflightQuery{ID: 1}},
+				{ctx: context.Background, query: SingleflightQuery{ID: 1}},
+				{ctx: context.Background, query: SingleflightQuery{ID: 1}},
+			},
+			wants: wants{
+				calls:   1,
+				results: []interface{}{result, result, result},
+				errs:    []error{nil, nil, nil},
+			},
+		},
+		{
+			name:  "different queries call handler separately",
+			scope: noScope,
+			executions: []execution{
+				{ctx: context.Background, query: SingleflightQuery{ID: 1}},
+				{ctx: context.Background, query: SingleflightQuery{ID: 2}},
+			},
+			wants: wants{
+				calls:   2,
+				results: []interface{}{result, result},
+				errs:    []error{nil, nil},
+			},
+		},
+		{
+			name:  "handler error shared with all waiters",
+			scope: noScope,
+			err:   Err,
+			executions: []execution{
+				{ctx: context.Background, query: SingleflightQuery{ID: 1}},
+				{ctx: context.Background, query: SingleflightQuery{ID: 1}},
+			},
+			wants: wants{
+				calls:   1,
+				results: []interface{}{nil, nil},
+				errs:    []error{Err, Err},
+			},
+		},
+		{
+			name: "identical queries in different scopes are not shared",
+			scope: func(ctx context.Context) string {
+				scope, _ := ctx.Value(scopeKey{}).(string)
+				return scope
+			},
+			executions: []execution{
+				{ctx: func() context.Context { return context.WithValue(context.Background(), scopeKey{}, "tenant-1") }, query: SingleflightQuery{ID: 1}},
+				{ctx: func() context.Context { return context.WithValue(context.Background(), scopeKey{}, "tenant-2") }, query: SingleflightQuery{ID: 1}},
+			},
+			wants: wants{
+				calls:   2,
+				results: []interface{}{result, result},
+				errs:    []error{nil, nil},
+			},
+		},
+		{
+			name:  "custom key function",
+			scope: noScope,
+			opts: []middlewares.SingleflightOption{
+				middlewares.WithSingleflightKey(func(query interface{}) (string, error) {
+					return "same", nil
+				}),
+			},
+			executions: []execution{
+				{ctx: context.Background, query: SingleflightQuery{ID: 1}},
+				{ctx: context.Background, query: SingleflightQuery{ID: 2}},
+			},
+			wants: wants{
+				calls:   1,
+				results: []interface{}{result, result},
+				errs:    []error{nil, nil},
+			},
+		},
+		{
+			name:  "query with an earlier deadline joins the call",
+			scope: noScope,
+			executions: []execution{
+				{ctx: func() context.Context { return longCtx }, query: SingleflightQuery{ID: 1}},
+				{ctx: func() context.Context { return shortCtx }, query: SingleflightQuery{ID: 1}, delay: 10 * time.Millisecond},
+				{ctx: context.Background, query: SingleflightQuery{ID: 1}, delay: 20 * time.Millisecond},
+			},
+			wants: wants{
+				calls:   2,
+				results: []interface{}{result, result, result},
+				errs:    []error{nil, nil, nil},
+			},
+		},
+		{
+			name:  "query with a later deadline starts its own call",
+			scope: noScope,
+			executions: []execution{
+				{ctx: func() context.Context { return shortCtx }, query: SingleflightQuery{ID: 1}},
+				{ctx: func() context.Context { return longCtx }, query: SingleflightQuery{ID: 1}, delay: 10 * time.Millisecond},
+			},
+			wants: wants{
+				calls:   2,
+				results: []interface{}{result, result},
+				errs:    []error{nil, nil},
+			},
+		},
+		{
+			name:  "queries inside a transaction are not shared",
+			scope: noScope,
+			executions: []execution{
+				{ctx: transactionCtx, query: SingleflightQuery{ID: 1}},
+				{ctx: context.Background, query: SingleflightQuery{ID: 1}},
+				{ctx: transactionCtx, query: SingleflightQuery{ID: 1}},
+			},
+			wants: wants{
+				calls:   3,
+				results: []interface{}{result, result, result},
+				errs:    []error{nil, nil, nil},
+			},
+		},
+		{
+			name:  "cancelled waiter does not affect others",
+			scope: noScope,
+			executions: []execution{
+				{ctx: context.Background, query: SingleflightQuery{ID: 1}},
+				{
+					delay: 10 * time.Millisecond,
+					ctx: func() context.Context {
+						ctx, cancel := context.WithCancel(context.Background())
+						cancel()
+						return ctx
+					},
+					query: SingleflightQuery{ID: 1},
+				},
+			},
+			wants: wants{
+				calls:   1,
+				results: []interface{}{result, nil},
+				errs:    []error{nil, context.Canceled},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			release := make(chan struct{})
+
+			singleflightMiddleware := middlewares.NewSingleflightMiddleware(tt.scope, tt.opts...)
+			queryMiddleware := singleflightMiddleware.QueryMiddleware()
+			handler := queryMiddleware(func(ctx context.Context, query interface{}) (interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				<-release
+
+				if tt.err != nil {
+					return nil, tt.err
+				}
+
+				return result, nil
+			})
+
+			results := make([]interface{}, len(tt.executions))
+			errs := make([]error, len(tt.executions))
+
+			wg := sync.WaitGroup{}
+			for i, execution := range tt.executions {
+				i, execution := i, execution
+
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					time.Sleep(execution.delay)
+					results[i], errs[i] = handler(execution.ctx(), execution.query)
+				}()
+			}
+
+			time.Sleep(50 * time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			assert.Equal(t, tt.wants.calls, atomic.LoadInt32(&calls))
+			assert.Equal(t, tt.wants.results, results)
+			for i, err := range errs {
+				assert.ErrorIs(t, err, tt.wants.errs[i])
+			}
+		})
+	}
+}
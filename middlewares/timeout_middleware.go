@@ -0,0 +1,121 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/vulpes-ferrilata/cqrs"
+)
+
+type TimeoutMessage interface {
+	Timeout() time.Duration
+}
+
+type TimeoutError struct {
+	MessageType string
+	Timeout     time.Duration
+}
+
+func (t TimeoutError) Error() string {
+	return fmt.Sprintf("%s timed out after %s", t.MessageType, t.Timeout)
+}
+
+func (t TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+type TimeoutOption func(t *TimeoutMiddleware)
+
+func WithMessageTimeout(message interface{}, timeout time.Duration) TimeoutOption {
+	return func(t *TimeoutMiddleware) {
+		t.timeouts[reflect.TypeOf(message)] = timeout
+	}
+}
+
+func NewTimeoutMiddleware(defaultTimeout time.Duration, opts ...TimeoutOption) *TimeoutMiddleware {
+	timeoutMiddleware := &TimeoutMiddleware{
+		defaultTimeout: defaultTimeout,
+		timeouts:       make(map[reflect.Type]time.Duration),
+	}
+
+	for _, opt := range opts {
+		opt(timeoutMiddleware)
+	}
+
+	return timeoutMiddleware
+}
+
+// TimeoutMiddleware must be used before TransactionMiddleware.
+type TimeoutMiddleware struct {
+	defaultTimeout time.Duration
+	timeouts       map[reflect.Type]time.Duration
+}
+
+func (t TimeoutMiddleware) timeout(message interface{}) time.Duration {
+	if timeoutMessage, ok := message.(TimeoutMessage); ok {
+		return timeoutMessage.Timeout()
+	}
+
+	timeout, ok := t.timeouts[reflect.TypeOf(message)]
+	if !ok {
+		return t.defaultTimeout
+	}
+
+	return timeout
+}
+
+func (t TimeoutMiddleware) withTimeout(ctx context.Context, message interface{}, fn func(ctx context.Context) error) error {
+	timeout := t.timeout(message)
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := fn(timeoutCtx); err != nil {
+		if errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+			return TimeoutError{
+				MessageType: messageType(message),
+				Timeout:     timeout,
+			}
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (t TimeoutMiddleware) CommandMiddleware() cqrs.CommandMiddlewareFunc {
+	return func(handler cqrs.CommandHandlerFunc[any]) cqrs.CommandHandlerFunc[any] {
+		return func(ctx context.Context, command any) error {
+			return t.withTimeout(ctx, command, func(ctx context.Context) error {
+				return handler(ctx, command)
+			})
+		}
+	}
+}
+
+func (t TimeoutMiddleware) QueryMiddleware() cqrs.QueryMiddlewareFunc {
+	return func(handler cqrs.QueryHandlerFunc[any, any]) cqrs.QueryHandlerFunc[any, any] {
+		return func(ctx context.Context, query any) (interface{}, error) {
+			var result interface{}
+
+			err := t.withTimeout(ctx, query, func(ctx context.Context) error {
+				var err error
+
+				result, err = handler(ctx, query)
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			return result, nil
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package middlewares_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+
+	"github.com/vulpes-ferrilata/cqrs"
+	"github.com/vulpes-ferrilata/cqrs/middlewares"
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+	mock_db "github.com/vulpes-ferrilata/cqrs/pkg/db/mocks"
+)
+
+type (
+	SlowCommand    struct{}
+	TimeoutCommand struct{}
+)
+
+func (t TimeoutCommand) Timeout() time.Duration {
+	return 10 * time.Millisecond
+}
+
+func TestTimeoutMiddleware_CommandMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		Err = errors.New("error")
+	)
+
+	type args struct {
+		handler cqrs.CommandHandlerFunc[any]
+		command interface{}
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+	}{
+		{
+			name: "handler return error",
+			args: args{
+				handler: func(ctx context.Context, command interface{}) error {
+					return Err
+				},
+				command: struct{}{},
+			},
+			wantErr: Err,
+		},
+		{
+			name: "default timeout exceeded",
+			args: args{
+				handler: func(ctx context.Context, command interface{}) error {
+					<-ctx.Done()
+					return ctx.Err()
+				},
+				command: struct{}{},
+			},
+			wantErr: middlewares.TimeoutError{
+				MessageType: "struct {}",
+				Timeout:     50 * time.Millisecond,
+			},
+		},
+		{
+			name: "registered timeout exceeded",
+			args: args{
+				handler: func(ctx context.Context, command interface{}) error {
+					<-ctx.Done()
+					return ctx.Err()
+				},
+				command: SlowCommand{},
+			},
+			wantErr: middlewares.TimeoutError{
+				MessageType: "github.com/vulpes-ferrilata/cqrs/middlewares_test.SlowCommand",
+				Timeout:     20 * time.Millisecond,
+			},
+		},
+		{
+			name: "message timeout exceeded",
+			args: args{
+				handler: func(ctx context.Context, command interface{}) error {
+					<-ctx.Done()
+					return ctx.Err()
+				},
+				command: TimeoutCommand{},
+			},
+			wantErr: middlewares.TimeoutError{
+				MessageType: "github.com/vulpes-ferrilata/cqrs/middlewares_test.TimeoutCommand",
+				Timeout:     10 * time.Millisecond,
+			},
+		},
+		{
+			name: "success",
+			args: args{
+				handler: func(ctx context.Context, command interface{}) error {
+					if _, ok := ctx.Deadline(); !ok {
+						return Err
+					}
+
+					return nil
+				},
+				command: struct{}{},
+			},
+			wantErr: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeoutMiddleware := middlewares.NewTimeoutMiddleware(50*time.Millisecond,
+				middlewares.WithMessageTimeout(SlowCommand{}, 20*time.Millisecond),
+			)
+			commandMiddleware := timeoutMiddleware.CommandMiddleware()
+			handler := commandMiddleware(tt.args.handler)
+			err := handler(context.Background(), tt.args.command)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestTimeoutMiddleware_QueryMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		result = struct{}{}
+		Err    = errors.New("error")
+	)
+
+	type args struct {
+		handler cqrs.QueryHandlerFunc[any, any]
+		query   interface{}
+	}
+	type wants struct {
+		result interface{}
+		err    error
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "handler return error",
+			args: args{
+				handler: func(ctx context.Context, query interface{}) (interface{}, error) {
+					return nil, Err
+				},
+				query: struct{}{},
+			},
+			wants: wants{
+				result: nil,
+				err:    Err,
+			},
+		},
+		{
+			name: "timeout exceeded",
+			args: args{
+				handler: func(ctx context.Context, query interface{}) (interface{}, error) {
+					<-ctx.Done()
+					return nil, ctx.Err()
+				},
+				query: struct{}{},
+			},
+			wants: wants{
+				result: nil,
+				err:    context.DeadlineExceeded,
+			},
+		},
+		{
+			name: "success",
+			args: args{
+				handler: func(ctx context.Context, query interface{}) (interface{}, error) {
+					return result, nil
+				},
+				query: struct{}{},
+			},
+			wants: wants{
+				result: result,
+				err:    nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeoutMiddleware := middlewares.NewTimeoutMiddleware(10 * time.Millisecond)
+			queryMiddleware := timeoutMiddleware.QueryMiddleware()
+			handler := queryMiddleware(tt.args.handler)
+			result, err := handler(context.Background(), tt.args.query)
+			assert.ErrorIs(t, err, tt.wants.err)
+			assert.Equal(t, tt.wants.result, result)
+		})
+	}
+}
+
+func TestTimeoutMiddleware_CommandMiddleware_TransactionMiddleware(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		handler cqrs.CommandHandlerFunc[any]
+	}{
+		{
+			name: "handler ignores the deadline",
+			handler: func(ctx context.Context, command interface{}) error {
+				time.Sleep(20 * time.Millisecond)
+
+				return nil
+			},
+		},
+		{
+			name: "handler returns the context error",
+			handler: func(ctx context.Context, command interface{}) error {
+				<-ctx.Done()
+
+				return ctx.Err()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			transactionManager := mock_db.NewMockTransactionManager[*gorm.DB](mockCtrl)
+			committer := mock_db.NewMockCommitter(mockCtrl)
+
+			transactionManager.EXPECT().IsTransactionStarted(gomock.Any()).Return(false)
+			transactionManager.EXPECT().StartTransaction(gomock.Any()).DoAndReturn(func(ctx context.Context) (db.Committer, context.Context, error) {
+				return committer, ctx, nil
+			})
+			var rollbackErr error
+			committer.EXPECT().RollbackTransaction(gomock.Any()).DoAndReturn(func(ctx context.Context) error {
+				rollbackErr = ctx.Err()
+				return nil
+			})
+
+			timeoutMiddleware := middlewares.NewTimeoutMiddleware(10 * time.Millisecond)
+			transactionMiddleware := middlewares.NewTransactionMiddleware[*gorm.DB](transactionManager)
+
+			handler := timeoutMiddleware.CommandMiddleware()(transactionMiddleware.CommandMiddleware()(tt.handler))
+			err := handler(context.Background(), struct{}{})
+			assert.ErrorAs(t, err, &middlewares.TimeoutError{})
+			// the rollback does not run with the expired context.
+			assert.NoError(t, rollbackErr)
+		})
+	}
+}
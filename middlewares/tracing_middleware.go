@@ -0,0 +1,88 @@
+package middlewares
+
+import (
+	"context"
+
+	"github.com/vulpes-ferrilata/cqrs"
+	"github.com/vulpes-ferrilata/cqrs/pkg/tracing"
+)
+
+func NewTracingMiddleware(tracer tracing.Tracer) *TracingMiddleware {
+	return &TracingMiddleware{
+		tracer: tracer,
+	}
+}
+
+type TracingMiddleware struct {
+	tracer tracing.Tracer
+}
+
+func (t TracingMiddleware) start(ctx context.Context, kind string, message interface{}, opts ...tracing.SpanOption) (context.Context, tracing.Span) {
+	messageType := messageType(message)
+
+	opts = append(opts, tracing.WithAttributes(
+		tracing.String("cqrs.message_kind", kind),
+		tracing.String("cqrs.message_type", messageType),
+	))
+
+	return t.tracer.Start(ctx, kind+" "+messageType, opts...)
+}
+
+func (t TracingMiddleware) CommandMiddleware() cqrs.CommandMiddlewareFunc {
+	return func(handler cqrs.CommandHandlerFunc[any]) cqrs.CommandHandlerFunc[any] {
+		return func(ctx context.Context, command any) error {
+			ctx, span := t.start(ctx, "command", command)
+			defer span.End()
+
+			ctx = withCommandSpan(ctx, span)
+
+			if err := handler(ctx, command); err != nil {
+				span.RecordError(err)
+
+				return err
+			}
+
+			return nil
+		}
+	}
+}
+
+func (t TracingMiddleware) QueryMiddleware() cqrs.QueryMiddlewareFunc {
+	return func(handler cqrs.QueryHandlerFunc[any, any]) cqrs.QueryHandlerFunc[any, any] {
+		return func(ctx context.Context, query any) (interface{}, error) {
+			ctx, span := t.start(ctx, "query", query)
+			defer span.End()
+
+			result, err := handler(ctx, query)
+			if err != nil {
+				span.RecordError(err)
+
+				return nil, err
+			}
+
+			return result, nil
+		}
+	}
+}
+
+func (t TracingMiddleware) EventMiddleware() cqrs.EventMiddlewareFunc {
+	return func(handler cqrs.EventHandlerFunc[any]) cqrs.EventHandlerFunc[any] {
+		return func(ctx context.Context, event any) error {
+			opts := make([]tracing.SpanOption, 0)
+			if commandSpan, ok := getCommandSpan(ctx); ok {
+				opts = append(opts, tracing.WithLinks(commandSpan))
+			}
+
+			ctx, span := t.start(ctx, "event", event, opts...)
+			defer span.End()
+
+			if err := handler(ctx, event); err != nil {
+				span.RecordError(err)
+
+				return err
+			}
+
+			return nil
+		}
+	}
+}
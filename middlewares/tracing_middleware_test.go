@@ -0,0 +1,230 @@
+package middlewares_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+
+	"github.com/vulpes-ferrilata/cqrs"
+	"github.com/vulpes-ferrilata/cqrs/middlewares"
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+	mock_db "github.com/vulpes-ferrilata/cqrs/pkg/db/mocks"
+	"github.com/vulpes-ferrilata/cqrs/pkg/tracing"
+	"github.com/vulpes-ferrilata/cqrs/pkg/tracing/memory"
+)
+
+type (
+	TracedCommand struct{}
+	TracedEvent   struct{}
+)
+
+func TestTracingMiddleware_CommandMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		Err = errors.New("error")
+	)
+
+	type args struct {
+		handler cqrs.CommandHandlerFunc[any]
+	}
+	type wants struct {
+		errors []error
+		err    error
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "handler return error",
+			args: args{
+				handler: func(ctx context.Context, command interface{}) error {
+					return Err
+				},
+			},
+			wants: wants{
+				errors: []error{Err},
+				err:    Err,
+			},
+		},
+		{
+			name: "success",
+			args: args{
+				handler: func(ctx context.Context, command interface{}) error {
+					return nil
+				},
+			},
+			wants: wants{
+				errors: nil,
+				err:    nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer := memory.NewTracer()
+
+			tracingMiddleware := middlewares.NewTracingMiddleware(tracer)
+			commandMiddleware := tracingMiddleware.CommandMiddleware()
+			handler := commandMiddleware(tt.args.handler)
+			err := handler(context.Background(), TracedCommand{})
+			assert.ErrorIs(t, err, tt.wants.err)
+
+			span, ok := tracer.Span("command github.com/vulpes-ferrilata/cqrs/middlewares_test.TracedCommand")
+			assert.True(t, ok)
+			assert.True(t, span.Ended)
+			assert.Equal(t, "command", span.Attributes["cqrs.message_kind"])
+			assert.Equal(t, tt.wants.errors, span.Errors)
+		})
+	}
+}
+
+func TestTracingMiddleware_QueryMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var (
+		result = struct{}{}
+		Err    = errors.New("error")
+	)
+
+	type args struct {
+		handler cqrs.QueryHandlerFunc[any, any]
+	}
+	type wants struct {
+		errors []error
+		result interface{}
+		err    error
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "handler return error",
+			args: args{
+				handler: func(ctx context.Context, query interface{}) (interface{}, error) {
+					return nil, Err
+				},
+			},
+			wants: wants{
+				errors: []error{Err},
+				result: nil,
+				err:    Err,
+			},
+		},
+		{
+			name: "success",
+			args: args{
+				handler: func(ctx context.Context, query interface{}) (interface{}, error) {
+					return result, nil
+				},
+			},
+			wants: wants{
+				errors: nil,
+				result: result,
+				err:    nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer := memory.NewTracer()
+
+			tracingMiddleware := middlewares.NewTracingMiddleware(tracer)
+			queryMiddleware := tracingMiddleware.QueryMiddleware()
+			handler := queryMiddleware(tt.args.handler)
+			result, err := handler(context.Background(), struct{}{})
+			assert.ErrorIs(t, err, tt.wants.err)
+			assert.Equal(t, tt.wants.result, result)
+
+			span, ok := tracer.Span("query struct {}")
+			assert.True(t, ok)
+			assert.True(t, span.Ended)
+			assert.Equal(t, tt.wants.errors, span.Errors)
+		})
+	}
+}
+
+func TestTracingMiddleware_EventFanOut(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	tracer := memory.NewTracer()
+
+	transactionManager := mock_db.NewMockTransactionManager[*gorm.DB](mockCtrl)
+	committer := mock_db.NewMockCommitter(mockCtrl)
+	transactionManager.EXPECT().IsTransactionStarted(gomock.Any()).Return(false)
+	transactionManager.EXPECT().StartTransaction(gomock.Any()).DoAndReturn(func(ctx context.Context) (db.Committer, context.Context, error) {
+		return committer, ctx, nil
+	})
+	committer.EXPECT().CommitTransaction(gomock.Any()).Return(nil)
+
+	tracingMiddleware := middlewares.NewTracingMiddleware(tracer)
+
+	eventBus := cqrs.NewEventBus()
+	eventBus.Use(tracingMiddleware.EventMiddleware())
+	err := cqrs.RegisterEventHandler(eventBus, func(ctx context.Context, event TracedEvent) error {
+		return nil
+	})
+	assert.NoError(t, err)
+	err = cqrs.RegisterEventHandler(eventBus, func(ctx context.Context, event TracedEvent) error {
+		return nil
+	})
+	assert.NoError(t, err)
+
+	commandBus := cqrs.NewCommandBus()
+	commandBus.Use(
+		tracingMiddleware.CommandMiddleware(),
+		middlewares.NewEventProviderMiddleware().CommandMiddleware(),
+		middlewares.NewEventDispatcherMiddleware(eventBus, middlewares.WithEventDispatcherTracer(tracer)).CommandMiddleware(),
+		middlewares.NewTransactionMiddleware(tracing.NewTransactionManager[*gorm.DB](transactionManager, tracer)).CommandMiddleware(),
+	)
+	err = cqrs.RegisterCommandHandler(commandBus, func(ctx context.Context, command TracedCommand) error {
+		eventProvider, _ := cqrs.GetEventProvider(ctx)
+		eventProvider.CollectEvents(TracedEvent{})
+
+		return nil
+	})
+	assert.NoError(t, err)
+
+	err = commandBus.Execute(context.Background(), TracedCommand{})
+	assert.NoError(t, err)
+
+	commandSpan, ok := tracer.Span("command github.com/vulpes-ferrilata/cqrs/middlewares_test.TracedCommand")
+	assert.True(t, ok)
+
+	dispatchSpan, ok := tracer.Span("dispatch events")
+	assert.True(t, ok)
+	assert.Equal(t, commandSpan, dispatchSpan.Parent)
+	assert.Equal(t, 1, dispatchSpan.Attributes["cqrs.event_count"])
+
+	startSpan, ok := tracer.Span("transaction start")
+	assert.True(t, ok)
+	assert.Equal(t, commandSpan, startSpan.Parent)
+
+	commitSpan, ok := tracer.Span("transaction commit")
+	assert.True(t, ok)
+	assert.Equal(t, commandSpan, commitSpan.Parent)
+
+	eventSpans := make([]*memory.Span, 0)
+	for _, span := range tracer.Spans() {
+		if span.Name == "event github.com/vulpes-ferrilata/cqrs/middlewares_test.TracedEvent" {
+			eventSpans = append(eventSpans, span)
+		}
+	}
+	assert.Len(t, eventSpans, 2)
+	for _, eventSpan := range eventSpans {
+		assert.Equal(t, dispatchSpan, eventSpan.Parent)
+		assert.Equal(t, []*memory.Span{commandSpan}, eventSpan.Links)
+		assert.True(t, eventSpan.Ended)
+	}
+}
@@ -78,3 +78,84 @@ func (mr *MockEventBusMockRecorder) Use(middlewares ...interface{}) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Use", reflect.TypeOf((*MockEventBus)(nil).Use), middlewares...)
 }
+
+// MockNamedEventBus is a mock of NamedEventBus interface.
+type MockNamedEventBus struct {
+	ctrl     *gomock.Controller
+	recorder *MockNamedEventBusMockRecorder
+}
+
+// MockNamedEventBusMockRecorder is the mock recorder for MockNamedEventBus.
+type MockNamedEventBusMockRecorder struct {
+	mock *MockNamedEventBus
+}
+
+// NewMockNamedEventBus creates a new mock instance.
+func NewMockNamedEventBus(ctrl *gomock.Controller) *MockNamedEventBus {
+	mock := &MockNamedEventBus{ctrl: ctrl}
+	mock.recorder = &MockNamedEventBusMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNamedEventBus) EXPECT() *MockNamedEventBusMockRecorder {
+	return m.recorder
+}
+
+// Dispatch mocks base method.
+func (m *MockNamedEventBus) Dispatch(ctx context.Context, events []interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Dispatch", ctx, events)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Dispatch indicates an expected call of Dispatch.
+func (mr *MockNamedEventBusMockRecorder) Dispatch(ctx, events interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Dispatch", reflect.TypeOf((*MockNamedEventBus)(nil).Dispatch), ctx, events)
+}
+
+// Register mocks base method.
+func (m *MockNamedEventBus) Register(handler interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Register", handler)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Register indicates an expected call of Register.
+func (mr *MockNamedEventBusMockRecorder) Register(handler interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockNamedEventBus)(nil).Register), handler)
+}
+
+// RegisterNamed mocks base method.
+func (m *MockNamedEventBus) RegisterNamed(name string, handler interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterNamed", name, handler)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RegisterNamed indicates an expected call of RegisterNamed.
+func (mr *MockNamedEventBusMockRecorder) RegisterNamed(name, handler interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterNamed", reflect.TypeOf((*MockNamedEventBus)(nil).RegisterNamed), name, handler)
+}
+
+// Use mocks base method.
+func (m *MockNamedEventBus) Use(middlewares ...cqrs.EventMiddlewareFunc) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range middlewares {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "Use", varargs...)
+}
+
+// Use indicates an expected call of Use.
+func (mr *MockNamedEventBusMockRecorder) Use(middlewares ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Use", reflect.TypeOf((*MockNamedEventBus)(nil).Use), middlewares...)
+}
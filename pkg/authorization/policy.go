@@ -0,0 +1,36 @@
+package authorization
+
+//go:generate mockgen -destination=./mocks/mock_$GOFILE -source=$GOFILE -package=mock_$GOPACKAGE
+import (
+	"context"
+
+	"github.com/vulpes-ferrilata/cqrs"
+)
+
+type Decision struct {
+	Allowed bool
+	Reason  string
+}
+
+func Allow() Decision {
+	return Decision{
+		Allowed: true,
+	}
+}
+
+func Deny(reason string) Decision {
+	return Decision{
+		Allowed: false,
+		Reason:  reason,
+	}
+}
+
+type Policy interface {
+	Authorize(ctx context.Context, principal cqrs.Principal, message interface{}) (Decision, error)
+}
+
+type PolicyFunc func(ctx context.Context, principal cqrs.Principal, message interface{}) (Decision, error)
+
+func (p PolicyFunc) Authorize(ctx context.Context, principal cqrs.Principal, message interface{}) (Decision, error) {
+	return p(ctx, principal, message)
+}
@@ -0,0 +1,136 @@
+package memory
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/cache"
+)
+
+// NewStore evicts the least recently used entry beyond capacity, if positive.
+func NewStore(capacity int) cache.Store {
+	return &store{
+		capacity:    capacity,
+		items:       list.New(),
+		groups:      make(map[string]map[string]*list.Element),
+		generations: make(map[string]uint64),
+	}
+}
+
+type entry struct {
+	group     string
+	key       string
+	value     interface{}
+	expiresAt time.Time
+}
+
+func (e entry) isExpired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+type store struct {
+	capacity    int
+	items       *list.List
+	groups      map[string]map[string]*list.Element
+	generations map[string]uint64
+	mu          sync.Mutex
+}
+
+func (s *store) Get(ctx context.Context, group string, key string) (interface{}, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.groups[group][key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	item := element.Value.(*entry)
+	if item.isExpired(time.Now()) {
+		s.remove(element)
+		return nil, false, nil
+	}
+
+	s.items.MoveToFront(element)
+
+	return item.value, true, nil
+}
+
+func (s *store) Generation(ctx context.Context, group string) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.generations[group], nil
+}
+
+func (s *store) Set(ctx context.Context, group string, key string, value interface{}, ttl time.Duration, generation uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.generations[group] != generation {
+		return nil
+	}
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	if element, ok := s.groups[group][key]; ok {
+		item := element.Value.(*entry)
+		item.value = value
+		item.expiresAt = expiresAt
+		s.items.MoveToFront(element)
+
+		return nil
+	}
+
+	keys, ok := s.groups[group]
+	if !ok {
+		keys = make(map[string]*list.Element)
+		s.groups[group] = keys
+	}
+
+	keys[key] = s.items.PushFront(&entry{
+		group:     group,
+		key:       key,
+		value:     value,
+		expiresAt: expiresAt,
+	})
+
+	for s.capacity > 0 && s.items.Len() > s.capacity {
+		s.remove(s.items.Back())
+	}
+
+	return nil
+}
+
+func (s *store) Invalidate(ctx context.Context, groups ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, group := range groups {
+		s.generations[group]++
+
+		for _, element := range s.groups[group] {
+			s.items.Remove(element)
+		}
+
+		delete(s.groups, group)
+	}
+
+	return nil
+}
+
+func (s *store) remove(element *list.Element) {
+	item := s.items.Remove(element).(*entry)
+
+	keys := s.groups[item.group]
+	delete(keys, item.key)
+
+	if len(keys) == 0 {
+		delete(s.groups, item.group)
+	}
+}
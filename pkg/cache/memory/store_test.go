@@ -0,0 +1,186 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/cache"
+	"github.com/vulpes-ferrilata/cqrs/pkg/cache/memory"
+)
+
+func Test_store_Get(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+	)
+
+	type args struct {
+		group string
+		key   string
+	}
+	type wants struct {
+		value interface{}
+		ok    bool
+	}
+	tests := []struct {
+		name    string
+		prepare func(store cache.Store)
+		args    args
+		wants   wants
+	}{
+		{
+			name:    "missing entry",
+			prepare: func(store cache.Store) {},
+			args: args{
+				group: "group",
+				key:   "key",
+			},
+			wants: wants{
+				value: nil,
+				ok:    false,
+			},
+		},
+		{
+			name: "existing entry",
+			prepare: func(store cache.Store) {
+				store.Set(ctx, "group", "key", "value", time.Minute, 0)
+			},
+			args: args{
+				group: "group",
+				key:   "key",
+			},
+			wants: wants{
+				value: "value",
+				ok:    true,
+			},
+		},
+		{
+			name: "expired entry",
+			prepare: func(store cache.Store) {
+				store.Set(ctx, "group", "key", "value", time.Nanosecond, 0)
+				time.Sleep(time.Millisecond)
+			},
+			args: args{
+				group: "group",
+				key:   "key",
+			},
+			wants: wants{
+				value: nil,
+				ok:    false,
+			},
+		},
+		{
+			name: "least recently used entry evicted",
+			prepare: func(store cache.Store) {
+				store.Set(ctx, "group", "key", "value", time.Minute, 0)
+				store.Set(ctx, "group", "other-key", "other-value", time.Minute, 0)
+				store.Get(ctx, "group", "other-key")
+				store.Set(ctx, "other-group", "key", "value", time.Minute, 0)
+			},
+			args: args{
+				group: "group",
+				key:   "key",
+			},
+			wants: wants{
+				value: nil,
+				ok:    false,
+			},
+		},
+		{
+			name: "recently used entry kept",
+			prepare: func(store cache.Store) {
+				store.Set(ctx, "group", "key", "value", time.Minute, 0)
+				store.Set(ctx, "group", "other-key", "other-value", time.Minute, 0)
+				store.Get(ctx, "group", "key")
+				store.Set(ctx, "other-group", "key", "value", time.Minute, 0)
+			},
+			args: args{
+				group: "group",
+				key:   "key",
+			},
+			wants: wants{
+				value: "value",
+				ok:    true,
+			},
+		},
+		{
+			name: "invalidated group",
+			prepare: func(store cache.Store) {
+				store.Set(ctx, "group", "key", "value", time.Minute, 0)
+				store.Invalidate(ctx, "group")
+			},
+			args: args{
+				group: "group",
+				key:   "key",
+			},
+			wants: wants{
+				value: nil,
+				ok:    false,
+			},
+		},
+		{
+			name: "set with a stale generation skipped",
+			prepare: func(store cache.Store) {
+				generation, _ := store.Generation(ctx, "group")
+				store.Invalidate(ctx, "group")
+				store.Set(ctx, "group", "key", "stale-value", time.Minute, generation)
+			},
+			args: args{
+				group: "group",
+				key:   "key",
+			},
+			wants: wants{
+				value: nil,
+				ok:    false,
+			},
+		},
+		{
+			name: "set with the current generation",
+			prepare: func(store cache.Store) {
+				store.Invalidate(ctx, "group")
+				generation, _ := store.Generation(ctx, "group")
+				store.Set(ctx, "group", "key", "value", time.Minute, generation)
+			},
+			args: args{
+				group: "group",
+				key:   "key",
+			},
+			wants: wants{
+				value: "value",
+				ok:    true,
+			},
+		},
+		{
+			name: "other group invalidated",
+			prepare: func(store cache.Store) {
+				store.Set(ctx, "group", "key", "value", time.Minute, 0)
+				store.Set(ctx, "other-group", "key", "other-value", time.Minute, 0)
+				store.Invalidate(ctx, "other-group")
+			},
+			args: args{
+				group: "group",
+				key:   "key",
+			},
+			wants: wants{
+				value: "value",
+				ok:    true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := memory.NewStore(2)
+
+			tt.prepare(store)
+
+			value, ok, err := store.Get(ctx, tt.args.group, tt.args.key)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wants.value, value)
+			assert.Equal(t, tt.wants.ok, ok)
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package cache
+
+//go:generate mockgen -destination=./mocks/mock_$GOFILE -source=$GOFILE -package=mock_$GOPACKAGE
+import (
+	"context"
+	"time"
+)
+
+type Store interface {
+	Get(ctx context.Context, group string, key string) (interface{}, bool, error)
+	// Generation changes whenever the group is invalidated.
+	Generation(ctx context.Context, group string) (uint64, error)
+	// Set skips the value when the group has been invalidated since generation.
+	Set(ctx context.Context, group string, key string, value interface{}, ttl time.Duration, generation uint64) error
+	Invalidate(ctx context.Context, groups ...string) error
+}
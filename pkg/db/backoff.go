@@ -0,0 +1,20 @@
+package db
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Backoff returns the jittered exponential backoff of an attempt, starting at 1.
+func Backoff(attempt int, initialBackoff time.Duration, maxBackoff time.Duration) time.Duration {
+	backoff := initialBackoff << (attempt - 1)
+	if backoff <= 0 || backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+
+	if backoff <= 0 {
+		return 0
+	}
+
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
@@ -0,0 +1,85 @@
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+)
+
+func TestBackoff(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		attempt        int
+		initialBackoff time.Duration
+		maxBackoff     time.Duration
+	}
+	type wants struct {
+		min time.Duration
+		max time.Duration
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "first attempt",
+			args: args{
+				attempt:        1,
+				initialBackoff: 100 * time.Millisecond,
+				maxBackoff:     time.Second,
+			},
+			wants: wants{
+				min: 50 * time.Millisecond,
+				max: 100 * time.Millisecond,
+			},
+		},
+		{
+			name: "third attempt",
+			args: args{
+				attempt:        3,
+				initialBackoff: 100 * time.Millisecond,
+				maxBackoff:     time.Second,
+			},
+			wants: wants{
+				min: 200 * time.Millisecond,
+				max: 400 * time.Millisecond,
+			},
+		},
+		{
+			name: "capped by max backoff",
+			args: args{
+				attempt:        100,
+				initialBackoff: 100 * time.Millisecond,
+				maxBackoff:     time.Second,
+			},
+			wants: wants{
+				min: 500 * time.Millisecond,
+				max: time.Second,
+			},
+		},
+		{
+			name: "no backoff",
+			args: args{
+				attempt:        1,
+				initialBackoff: 0,
+				maxBackoff:     0,
+			},
+			wants: wants{
+				min: 0,
+				max: 0,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backoff := db.Backoff(tt.args.attempt, tt.args.initialBackoff, tt.args.maxBackoff)
+			assert.GreaterOrEqual(t, backoff, tt.wants.min)
+			assert.LessOrEqual(t, backoff, tt.wants.max)
+		})
+	}
+}
@@ -0,0 +1,11 @@
+package db
+
+import "errors"
+
+var (
+	ErrNoActiveTransaction               = errors.New("no active transaction")
+	ErrTransactionAlreadyStarted         = errors.New("transaction already started")
+	ErrTransactionSuspensionNotSupported = errors.New("transaction suspension not supported")
+	ErrNestedTransactionNotSupported     = errors.New("nested transaction not supported")
+	ErrTransactionOptionsNotSupported    = errors.New("transaction options not supported")
+)
@@ -25,3 +25,23 @@ func (c committer) CommitTransaction(ctx context.Context) error {
 func (c committer) RollbackTransaction(ctx context.Context) error {
 	return c.db.Rollback().Error
 }
+
+func newSavepointCommitter(db *gorm.DB, name string) db.Committer {
+	return &savepointCommitter{
+		db:   db,
+		name: name,
+	}
+}
+
+type savepointCommitter struct {
+	db   *gorm.DB
+	name string
+}
+
+func (s savepointCommitter) CommitTransaction(ctx context.Context) error {
+	return s.db.Exec("RELEASE SAVEPOINT " + s.name).Error
+}
+
+func (s savepointCommitter) RollbackTransaction(ctx context.Context) error {
+	return s.db.RollbackTo(s.name).Error
+}
@@ -14,5 +14,16 @@ func withTransaction(ctx context.Context, transaction *gorm.DB) context.Context
 
 func getTransaction(ctx context.Context) (*gorm.DB, bool) {
 	transaction, ok := ctx.Value(transactionKey{}).(*gorm.DB)
-	return transaction, ok
+	return transaction, ok && transaction != nil
+}
+
+type savepointKey struct{}
+
+func withSavepoint(ctx context.Context, savepoint int) context.Context {
+	return context.WithValue(ctx, savepointKey{}, savepoint)
+}
+
+func getSavepoint(ctx context.Context) int {
+	savepoint, _ := ctx.Value(savepointKey{}).(int)
+	return savepoint
 }
@@ -0,0 +1,44 @@
+package gorm
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	retryableSQLStates = map[string]struct{}{
+		"40001": {},
+		"40P01": {},
+	}
+	retryableMessages = []string{
+		"deadlock",
+		"could not serialize access",
+		"database is locked",
+		"lock wait timeout exceeded",
+	}
+)
+
+type sqlStateError interface {
+	SQLState() string
+}
+
+func IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var stateErr sqlStateError
+	if errors.As(err, &stateErr) {
+		_, ok := retryableSQLStates[stateErr.SQLState()]
+		return ok
+	}
+
+	message := strings.ToLower(err.Error())
+	for _, retryableMessage := range retryableMessages {
+		if strings.Contains(message, retryableMessage) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -3,22 +3,79 @@ package gorm
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"time"
 
 	"gorm.io/gorm"
 
 	"github.com/vulpes-ferrilata/cqrs/pkg/db"
 )
 
-func NewTransactionManager(db *gorm.DB, opts *sql.TxOptions) db.TransactionManager[*gorm.DB] {
-	return &transactionManager{
-		db:   db,
-		opts: opts,
+type TransactionManagerOption func(t *transactionManager)
+
+// WithRetryMaxAttempts lets TransactionMiddleware re-execute the transactions it starts on a retryable error.
+func WithRetryMaxAttempts(maxAttempts int) TransactionManagerOption {
+	return func(t *transactionManager) {
+		t.retryMaxAttempts = maxAttempts
+	}
+}
+
+func WithRetryBackoff(initialBackoff time.Duration, maxBackoff time.Duration) TransactionManagerOption {
+	return func(t *transactionManager) {
+		t.retryInitialBackoff = initialBackoff
+		t.retryMaxBackoff = maxBackoff
+	}
+}
+
+func WithRetryClassifier(classifier func(err error) bool) TransactionManagerOption {
+	return func(t *transactionManager) {
+		t.retryClassifier = classifier
+	}
+}
+
+func WithReadOnlyIsolationLevel(isolationLevel sql.IsolationLevel) TransactionManagerOption {
+	return func(t *transactionManager) {
+		t.readOnlyOpts = &sql.TxOptions{
+			Isolation: isolationLevel,
+			ReadOnly:  true,
+		}
+	}
+}
+
+// WithReplica routes reads of queries to the replica, including their read-only transactions.
+func WithReplica(replica *gorm.DB) TransactionManagerOption {
+	return func(t *transactionManager) {
+		t.replica = replica
 	}
 }
 
+func NewTransactionManager(db *gorm.DB, opts *sql.TxOptions, managerOpts ...TransactionManagerOption) db.TransactionManager[*gorm.DB] {
+	transactionManager := &transactionManager{
+		db:                  db,
+		opts:                opts,
+		readOnlyOpts:        &sql.TxOptions{ReadOnly: true},
+		retryMaxAttempts:    1,
+		retryInitialBackoff: 50 * time.Millisecond,
+		retryMaxBackoff:     time.Second,
+		retryClassifier:     IsRetryableError,
+	}
+
+	for _, managerOpt := range managerOpts {
+		managerOpt(transactionManager)
+	}
+
+	return transactionManager
+}
+
 type transactionManager struct {
-	db   *gorm.DB
-	opts *sql.TxOptions
+	db                  *gorm.DB
+	replica             *gorm.DB
+	opts                *sql.TxOptions
+	readOnlyOpts        *sql.TxOptions
+	retryMaxAttempts    int
+	retryInitialBackoff time.Duration
+	retryMaxBackoff     time.Duration
+	retryClassifier     func(err error) bool
 }
 
 func (t transactionManager) IsTransactionStarted(ctx context.Context) bool {
@@ -27,18 +84,83 @@ func (t transactionManager) IsTransactionStarted(ctx context.Context) bool {
 }
 
 func (t transactionManager) StartTransaction(ctx context.Context) (db.Committer, context.Context, error) {
-	transaction := t.db.WithContext(ctx).Begin(t.opts)
+	return t.startTransaction(ctx, t.db, t.opts)
+}
+
+func (t transactionManager) StartReadOnlyTransaction(ctx context.Context) (db.Committer, context.Context, error) {
+	return t.startTransaction(ctx, t.route(ctx), t.readOnlyOpts)
+}
+
+// StartTransactionWithOptions starts a transaction with *sql.TxOptions replacing the options of the TransactionManager.
+func (t transactionManager) StartTransactionWithOptions(ctx context.Context, opts interface{}) (db.Committer, context.Context, error) {
+	txOptions, ok := opts.(*sql.TxOptions)
+	if !ok {
+		return nil, ctx, fmt.Errorf("%w: %T", db.ErrTransactionOptionsNotSupported, opts)
+	}
 
+	return t.startTransaction(ctx, t.db, txOptions)
+}
+
+func (t transactionManager) startTransaction(ctx context.Context, gormDB *gorm.DB, opts *sql.TxOptions) (db.Committer, context.Context, error) {
+	transaction := gormDB.WithContext(ctx).Begin(opts)
+	if err := transaction.Error; err != nil {
+		return nil, ctx, err
+	}
+
+	committer, ctx := db.WithHooks(ctx, newCommitter(transaction))
 	ctx = withTransaction(ctx, transaction)
 
-	return newCommitter(transaction), ctx, nil
+	return committer, ctx, nil
+}
+
+func (t transactionManager) StartNestedTransaction(ctx context.Context) (db.Committer, context.Context, error) {
+	transaction, ok := getTransaction(ctx)
+	if !ok {
+		return t.StartTransaction(ctx)
+	}
+
+	savepoint := getSavepoint(ctx) + 1
+	name := fmt.Sprintf("sp%d", savepoint)
+
+	if err := transaction.SavePoint(name).Error; err != nil {
+		return nil, ctx, err
+	}
+
+	committer, ctx := db.WithNestedHooks(ctx, newSavepointCommitter(transaction, name))
+	ctx = withSavepoint(ctx, savepoint)
+
+	return committer, ctx, nil
+}
+
+func (t transactionManager) SuspendTransaction(ctx context.Context) context.Context {
+	ctx = db.WithoutHooks(ctx)
+	ctx = withTransaction(ctx, nil)
+	ctx = withSavepoint(ctx, 0)
+
+	return ctx
 }
 
 func (t transactionManager) GetTransaction(ctx context.Context) *gorm.DB {
 	transaction, ok := getTransaction(ctx)
 	if !ok {
-		return t.db.WithContext(ctx)
+		return t.route(ctx).WithContext(ctx)
 	}
 
 	return transaction
 }
+
+func (t transactionManager) route(ctx context.Context) *gorm.DB {
+	if t.replica != nil && db.IsReplicaRead(ctx) {
+		return t.replica
+	}
+
+	return t.db
+}
+
+func (t transactionManager) RetryTransaction(err error, attempts int, startedAt time.Time) (time.Duration, bool) {
+	if attempts >= t.retryMaxAttempts || !t.retryClassifier(err) {
+		return 0, false
+	}
+
+	return db.Backoff(attempts, t.retryInitialBackoff, t.retryMaxBackoff), true
+}
@@ -0,0 +1,309 @@
+package gorm_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vulpes-ferrilata/cqrs/internal/testdb"
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+	db_gorm "github.com/vulpes-ferrilata/cqrs/pkg/db/gorm"
+)
+
+type Note struct {
+	ID   uint
+	Text string
+}
+
+func Test_transactionManager_StartNestedTransaction(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		rollbackNested bool
+		rollbackInner  bool
+		rollback       bool
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantNotes []string
+	}{
+		{
+			name: "commit all",
+			args: args{
+				rollbackNested: false,
+				rollbackInner:  false,
+				rollback:       false,
+			},
+			wantNotes: []string{"outer", "nested", "inner", "after nested"},
+		},
+		{
+			name: "rollback innermost savepoint",
+			args: args{
+				rollbackNested: false,
+				rollbackInner:  true,
+				rollback:       false,
+			},
+			wantNotes: []string{"outer", "nested", "after nested"},
+		},
+		{
+			name: "rollback savepoint",
+			args: args{
+				rollbackNested: true,
+				rollbackInner:  false,
+				rollback:       false,
+			},
+			wantNotes: []string{"outer", "after nested"},
+		},
+		{
+			name: "rollback transaction",
+			args: args{
+				rollbackNested: false,
+				rollbackInner:  false,
+				rollback:       true,
+			},
+			wantNotes: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gormDB := testdb.NewGorm(t, &Note{})
+
+			transactionManager := db_gorm.NewTransactionManager(gormDB, nil)
+			nestedTransactionManager, ok := transactionManager.(db.NestedTransactionManager)
+			assert.True(t, ok)
+
+			create := func(ctx context.Context, text string) {
+				err := transactionManager.GetTransaction(ctx).Create(&Note{Text: text}).Error
+				assert.NoError(t, err)
+			}
+
+			// without a transaction a nested transaction starts one.
+			committer, ctx, err := nestedTransactionManager.StartNestedTransaction(context.Background())
+			assert.NoError(t, err)
+			create(ctx, "outer")
+
+			nestedCommitter, nestedCtx, err := nestedTransactionManager.StartNestedTransaction(ctx)
+			assert.NoError(t, err)
+			create(nestedCtx, "nested")
+
+			innerCommitter, innerCtx, err := nestedTransactionManager.StartNestedTransaction(nestedCtx)
+			assert.NoError(t, err)
+			create(innerCtx, "inner")
+
+			if tt.args.rollbackInner {
+				err = innerCommitter.RollbackTransaction(innerCtx)
+			} else {
+				err = innerCommitter.CommitTransaction(innerCtx)
+			}
+			assert.NoError(t, err)
+
+			if tt.args.rollbackNested {
+				err = nestedCommitter.RollbackTransaction(nestedCtx)
+			} else {
+				err = nestedCommitter.CommitTransaction(nestedCtx)
+			}
+			assert.NoError(t, err)
+
+			// committed savepoints are released.
+			if !tt.args.rollbackNested {
+				err = transactionManager.GetTransaction(ctx).Exec("ROLLBACK TO SAVEPOINT sp1").Error
+				assert.Error(t, err)
+			}
+
+			// the transaction stays usable after a savepoint is rolled back.
+			create(ctx, "after nested")
+
+			if tt.args.rollback {
+				err = committer.RollbackTransaction(ctx)
+			} else {
+				err = committer.CommitTransaction(ctx)
+			}
+			assert.NoError(t, err)
+
+			texts := make([]string, 0)
+			err = gormDB.Model(&Note{}).Order("id").Pluck("text", &texts).Error
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantNotes, texts)
+		})
+	}
+}
+
+func Test_transactionManager_StartTransaction(t *testing.T) {
+	t.Parallel()
+
+	gormDB := testdb.NewGorm(t)
+	sqlDB, err := gormDB.DB()
+	assert.NoError(t, err)
+	err = sqlDB.Close()
+	assert.NoError(t, err)
+
+	transactionManager := db_gorm.NewTransactionManager(gormDB, nil)
+	_, ctx, err := transactionManager.StartTransaction(context.Background())
+	assert.Error(t, err)
+	assert.False(t, transactionManager.IsTransactionStarted(ctx))
+}
+
+func Test_transactionManager_RetryTransaction(t *testing.T) {
+	t.Parallel()
+
+	var (
+		Err = errors.New("error")
+	)
+
+	type args struct {
+		opts     []db_gorm.TransactionManagerOption
+		err      error
+		attempts int
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "no retry policy",
+			args: args{
+				opts:     nil,
+				err:      errors.New("deadlock detected"),
+				attempts: 1,
+			},
+			want: false,
+		},
+		{
+			name: "deadlock",
+			args: args{
+				opts: []db_gorm.TransactionManagerOption{
+					db_gorm.WithRetryMaxAttempts(3),
+				},
+				err:      errors.New("deadlock detected"),
+				attempts: 2,
+			},
+			want: true,
+		},
+		{
+			name: "max attempts reached",
+			args: args{
+				opts: []db_gorm.TransactionManagerOption{
+					db_gorm.WithRetryMaxAttempts(3),
+				},
+				err:      errors.New("deadlock detected"),
+				attempts: 3,
+			},
+			want: false,
+		},
+		{
+			name: "not retryable error",
+			args: args{
+				opts: []db_gorm.TransactionManagerOption{
+					db_gorm.WithRetryMaxAttempts(3),
+				},
+				err:      Err,
+				attempts: 1,
+			},
+			want: false,
+		},
+		{
+			name: "custom classifier",
+			args: args{
+				opts: []db_gorm.TransactionManagerOption{
+					db_gorm.WithRetryMaxAttempts(3),
+					db_gorm.WithRetryClassifier(func(err error) bool {
+						return errors.Is(err, Err)
+					}),
+				},
+				err:      Err,
+				attempts: 1,
+			},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transactionManager := db_gorm.NewTransactionManager(nil, nil, tt.args.opts...)
+			transactionRetrier, ok := transactionManager.(db.TransactionRetrier)
+			if assert.True(t, ok) {
+				backoff, got := transactionRetrier.RetryTransaction(tt.args.err, tt.args.attempts, time.Now())
+				assert.Equal(t, tt.want, got)
+				assert.LessOrEqual(t, backoff, time.Second)
+			}
+		})
+	}
+}
+
+func Test_transactionManager_StartReadOnlyTransaction(t *testing.T) {
+	t.Parallel()
+
+	gormDB := testdb.NewGorm(t, &Note{})
+
+	transactionManager := db_gorm.NewTransactionManager(gormDB, nil,
+		db_gorm.WithReadOnlyIsolationLevel(sql.LevelSerializable),
+	)
+	readOnlyTransactionManager, ok := transactionManager.(db.ReadOnlyTransactionManager)
+	if !assert.True(t, ok) {
+		return
+	}
+
+	committer, ctx, err := readOnlyTransactionManager.StartReadOnlyTransaction(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, transactionManager.IsTransactionStarted(ctx))
+
+	var notes int64
+	err = transactionManager.GetTransaction(ctx).Model(&Note{}).Count(&notes).Error
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), notes)
+
+	err = committer.CommitTransaction(ctx)
+	assert.NoError(t, err)
+	assert.False(t, db.InTransaction(ctx))
+}
+
+func Test_transactionManager_StartTransactionWithOptions(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		opts interface{}
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+	}{
+		{
+			name: "sql transaction options",
+			args: args{
+				opts: &sql.TxOptions{Isolation: sql.LevelSerializable},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "unsupported transaction options",
+			args: args{
+				opts: sql.LevelSerializable,
+			},
+			wantErr: db.ErrTransactionOptionsNotSupported,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transactionManager := db_gorm.NewTransactionManager(testdb.NewGorm(t, &Note{}), nil)
+			configurableTransactionManager, ok := transactionManager.(db.ConfigurableTransactionManager)
+			if !assert.True(t, ok) {
+				return
+			}
+
+			committer, ctx, err := configurableTransactionManager.StartTransactionWithOptions(context.Background(), tt.args.opts)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantErr == nil, transactionManager.IsTransactionStarted(ctx))
+
+			if committer != nil {
+				err = committer.CommitTransaction(ctx)
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
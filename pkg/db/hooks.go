@@ -0,0 +1,235 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+type BeforeCommitHook func(ctx context.Context) error
+
+type AfterHook func(ctx context.Context)
+
+type hooksKey struct{}
+
+type hooks struct {
+	mu            sync.Mutex
+	done          bool
+	beforeCommit  []BeforeCommitHook
+	afterCommit   []AfterHook
+	afterRollback []AfterHook
+}
+
+func getHooks(ctx context.Context) (*hooks, bool) {
+	hooks, ok := ctx.Value(hooksKey{}).(*hooks)
+	return hooks, ok && hooks != nil
+}
+
+func register(ctx context.Context, fn func(hooks *hooks)) error {
+	hooks, ok := getHooks(ctx)
+	if !ok {
+		return ErrNoActiveTransaction
+	}
+
+	hooks.mu.Lock()
+	defer hooks.mu.Unlock()
+
+	if hooks.done {
+		return ErrNoActiveTransaction
+	}
+
+	fn(hooks)
+
+	return nil
+}
+
+// OnBeforeCommit registers a hook running inside the transaction, an error rolls the transaction back.
+func OnBeforeCommit(ctx context.Context, hook BeforeCommitHook) error {
+	return register(ctx, func(hooks *hooks) {
+		hooks.beforeCommit = append(hooks.beforeCommit, hook)
+	})
+}
+
+func OnAfterCommit(ctx context.Context, hook AfterHook) error {
+	return register(ctx, func(hooks *hooks) {
+		hooks.afterCommit = append(hooks.afterCommit, hook)
+	})
+}
+
+// OnAfterRollback hooks also run when the transaction fails to commit.
+func OnAfterRollback(ctx context.Context, hook AfterHook) error {
+	return register(ctx, func(hooks *hooks) {
+		hooks.afterRollback = append(hooks.afterRollback, hook)
+	})
+}
+
+// WithHooks must be called before the transaction is stored in the context.
+func WithHooks(ctx context.Context, committer Committer) (Committer, context.Context) {
+	hooks := &hooks{}
+
+	return &hookCommitter{
+			committer: committer,
+			hooks:     hooks,
+			ctx:       context.WithoutCancel(ctx),
+		},
+		context.WithValue(ctx, hooksKey{}, hooks)
+}
+
+// WithNestedHooks hands the hooks of a nested transaction over to the enclosing transaction on commit.
+func WithNestedHooks(ctx context.Context, committer Committer) (Committer, context.Context) {
+	parent, ok := getHooks(ctx)
+	if !ok {
+		return WithHooks(ctx, committer)
+	}
+
+	hooks := &hooks{}
+
+	return &nestedHookCommitter{
+			committer: committer,
+			parent:    parent,
+			hooks:     hooks,
+			ctx:       context.WithoutCancel(ctx),
+		},
+		context.WithValue(ctx, hooksKey{}, hooks)
+}
+
+func WithoutHooks(ctx context.Context) context.Context {
+	return context.WithValue(ctx, hooksKey{}, (*hooks)(nil))
+}
+
+type hookCommitter struct {
+	committer Committer
+	hooks     *hooks
+	ctx       context.Context
+}
+
+func (h hookCommitter) CommitTransaction(ctx context.Context) error {
+	if err := h.runBeforeCommit(ctx); err != nil {
+		if rollbackErr := h.committer.RollbackTransaction(ctx); rollbackErr != nil {
+			err = errors.Join(err, rollbackErr)
+		}
+		h.runAfter(false)
+
+		return err
+	}
+
+	if err := h.committer.CommitTransaction(ctx); err != nil {
+		h.runAfter(false)
+
+		return err
+	}
+
+	h.runAfter(true)
+
+	return nil
+}
+
+func (h hookCommitter) RollbackTransaction(ctx context.Context) error {
+	err := h.committer.RollbackTransaction(ctx)
+
+	h.runAfter(false)
+
+	return err
+}
+
+func (h hookCommitter) runBeforeCommit(ctx context.Context) error {
+	for i := 0; ; i++ {
+		h.hooks.mu.Lock()
+		if h.hooks.done || i >= len(h.hooks.beforeCommit) {
+			h.hooks.mu.Unlock()
+			return nil
+		}
+		hook := h.hooks.beforeCommit[i]
+		h.hooks.mu.Unlock()
+
+		if err := hook(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+func (h hookCommitter) runAfter(committed bool) {
+	h.hooks.mu.Lock()
+	if h.hooks.done {
+		h.hooks.mu.Unlock()
+		return
+	}
+	h.hooks.done = true
+	hooks := h.hooks.afterRollback
+	if committed {
+		hooks = h.hooks.afterCommit
+	}
+	h.hooks.mu.Unlock()
+
+	for _, hook := range hooks {
+		hook(h.ctx)
+	}
+}
+
+type nestedHookCommitter struct {
+	committer Committer
+	parent    *hooks
+	hooks     *hooks
+	ctx       context.Context
+}
+
+func (n nestedHookCommitter) CommitTransaction(ctx context.Context) error {
+	if err := n.committer.CommitTransaction(ctx); err != nil {
+		n.runAfterRollback()
+
+		return err
+	}
+
+	n.hooks.mu.Lock()
+	if n.hooks.done {
+		n.hooks.mu.Unlock()
+		return nil
+	}
+	n.hooks.done = true
+	beforeCommit, afterCommit, afterRollback := n.hooks.beforeCommit, n.hooks.afterCommit, n.hooks.afterRollback
+	n.hooks.mu.Unlock()
+
+	n.parent.mu.Lock()
+	defer n.parent.mu.Unlock()
+
+	n.parent.beforeCommit = append(n.parent.beforeCommit, beforeCommit...)
+	n.parent.afterCommit = append(n.parent.afterCommit, afterCommit...)
+	n.parent.afterRollback = append(n.parent.afterRollback, afterRollback...)
+
+	return nil
+}
+
+func (n nestedHookCommitter) RollbackTransaction(ctx context.Context) error {
+	err := n.committer.RollbackTransaction(ctx)
+
+	n.runAfterRollback()
+
+	return err
+}
+
+func (n nestedHookCommitter) runAfterRollback() {
+	n.hooks.mu.Lock()
+	if n.hooks.done {
+		n.hooks.mu.Unlock()
+		return
+	}
+	n.hooks.done = true
+	hooks := n.hooks.afterRollback
+	n.hooks.mu.Unlock()
+
+	for _, hook := range hooks {
+		hook(n.ctx)
+	}
+}
+
+func InTransaction(ctx context.Context) bool {
+	hooks, ok := getHooks(ctx)
+	if !ok {
+		return false
+	}
+
+	hooks.mu.Lock()
+	defer hooks.mu.Unlock()
+
+	return !hooks.done
+}
@@ -0,0 +1,289 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+	mock_db "github.com/vulpes-ferrilata/cqrs/pkg/db/mocks"
+)
+
+func TestWithHooks(t *testing.T) {
+	t.Parallel()
+
+	var (
+		Err         = errors.New("error")
+		RollbackErr = errors.New("rollback error")
+	)
+
+	type args struct {
+		register func(ctx context.Context, calls *[]string) error
+		commit   bool
+	}
+	type wants struct {
+		calls []string
+		err   error
+	}
+	tests := []struct {
+		name    string
+		prepare func(committer *mock_db.MockCommitter)
+		args    args
+		wants   wants
+	}{
+		{
+			name: "commit runs before and after commit hooks",
+			prepare: func(committer *mock_db.MockCommitter) {
+				committer.EXPECT().CommitTransaction(gomock.Any()).Return(nil)
+			},
+			args: args{
+				register: func(ctx context.Context, calls *[]string) error {
+					return errors.Join(
+						db.OnAfterCommit(ctx, func(ctx context.Context) {
+							*calls = append(*calls, "after commit")
+						}),
+						db.OnAfterRollback(ctx, func(ctx context.Context) {
+							*calls = append(*calls, "after rollback")
+						}),
+						db.OnBeforeCommit(ctx, func(ctx context.Context) error {
+							*calls = append(*calls, "before commit")
+							return db.OnBeforeCommit(ctx, func(ctx context.Context) error {
+								*calls = append(*calls, "registered by before commit")
+								return nil
+							})
+						}),
+					)
+				},
+				commit: true,
+			},
+			wants: wants{
+				calls: []string{"before commit", "registered by before commit", "after commit"},
+				err:   nil,
+			},
+		},
+		{
+			name: "before commit hook fail rolls back",
+			prepare: func(committer *mock_db.MockCommitter) {
+				committer.EXPECT().RollbackTransaction(gomock.Any()).Return(nil)
+			},
+			args: args{
+				register: func(ctx context.Context, calls *[]string) error {
+					return errors.Join(
+						db.OnBeforeCommit(ctx, func(ctx context.Context) error {
+							return Err
+						}),
+						db.OnAfterCommit(ctx, func(ctx context.Context) {
+							*calls = append(*calls, "after commit")
+						}),
+						db.OnAfterRollback(ctx, func(ctx context.Context) {
+							*calls = append(*calls, "after rollback")
+						}),
+					)
+				},
+				commit: true,
+			},
+			wants: wants{
+				calls: []string{"after rollback"},
+				err:   Err,
+			},
+		},
+		{
+			name: "before commit hook fail with rollback fail",
+			prepare: func(committer *mock_db.MockCommitter) {
+				committer.EXPECT().RollbackTransaction(gomock.Any()).Return(RollbackErr)
+			},
+			args: args{
+				register: func(ctx context.Context, calls *[]string) error {
+					return db.OnBeforeCommit(ctx, func(ctx context.Context) error {
+						return Err
+					})
+				},
+				commit: true,
+			},
+			wants: wants{
+				calls: []string{},
+				err:   RollbackErr,
+			},
+		},
+		{
+			name: "commit fail runs after rollback hooks",
+			prepare: func(committer *mock_db.MockCommitter) {
+				committer.EXPECT().CommitTransaction(gomock.Any()).Return(Err)
+			},
+			args: args{
+				register: func(ctx context.Context, calls *[]string) error {
+					return errors.Join(
+						db.OnAfterCommit(ctx, func(ctx context.Context) {
+							*calls = append(*calls, "after commit")
+						}),
+						db.OnAfterRollback(ctx, func(ctx context.Context) {
+							*calls = append(*calls, "after rollback")
+						}),
+					)
+				},
+				commit: true,
+			},
+			wants: wants{
+				calls: []string{"after rollback"},
+				err:   Err,
+			},
+		},
+		{
+			name: "rollback runs after rollback hooks",
+			prepare: func(committer *mock_db.MockCommitter) {
+				committer.EXPECT().RollbackTransaction(gomock.Any()).Return(nil)
+			},
+			args: args{
+				register: func(ctx context.Context, calls *[]string) error {
+					return errors.Join(
+						db.OnBeforeCommit(ctx, func(ctx context.Context) error {
+							*calls = append(*calls, "before commit")
+							return nil
+						}),
+						db.OnAfterRollback(ctx, func(ctx context.Context) {
+							*calls = append(*calls, "after rollback")
+						}),
+					)
+				},
+				commit: false,
+			},
+			wants: wants{
+				calls: []string{"after rollback"},
+				err:   nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mockCommitter := mock_db.NewMockCommitter(mockCtrl)
+
+			tt.prepare(mockCommitter)
+
+			calls := make([]string, 0)
+
+			committer, ctx := db.WithHooks(context.Background(), mockCommitter)
+			assert.True(t, db.InTransaction(ctx))
+			err := tt.args.register(ctx, &calls)
+			assert.NoError(t, err)
+
+			if tt.args.commit {
+				err = committer.CommitTransaction(ctx)
+			} else {
+				err = committer.RollbackTransaction(ctx)
+			}
+			assert.ErrorIs(t, err, tt.wants.err)
+			assert.Equal(t, tt.wants.calls, calls)
+
+			// hooks run once, the transaction is finished.
+			assert.False(t, db.InTransaction(ctx))
+			err = db.OnAfterCommit(ctx, func(ctx context.Context) {})
+			assert.ErrorIs(t, err, db.ErrNoActiveTransaction)
+		})
+	}
+}
+
+func TestOnAfterCommit_NoTransaction(t *testing.T) {
+	t.Parallel()
+
+	err := db.OnAfterCommit(context.Background(), func(ctx context.Context) {})
+	assert.ErrorIs(t, err, db.ErrNoActiveTransaction)
+	assert.False(t, db.InTransaction(context.Background()))
+}
+
+func TestWithNestedHooks(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		commitNested bool
+	}
+	type wants struct {
+		calls []string
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "nested commit hands hooks over to the enclosing transaction",
+			args: args{
+				commitNested: true,
+			},
+			wants: wants{
+				calls: []string{"nested before commit", "nested after commit"},
+			},
+		},
+		{
+			name: "nested rollback discards hooks",
+			args: args{
+				commitNested: false,
+			},
+			wants: wants{
+				calls: []string{"nested after rollback"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mockCommitter := mock_db.NewMockCommitter(mockCtrl)
+			mockCommitter.EXPECT().CommitTransaction(gomock.Any()).Return(nil)
+			mockNestedCommitter := mock_db.NewMockCommitter(mockCtrl)
+			if tt.args.commitNested {
+				mockNestedCommitter.EXPECT().CommitTransaction(gomock.Any()).Return(nil)
+			} else {
+				mockNestedCommitter.EXPECT().RollbackTransaction(gomock.Any()).Return(nil)
+			}
+
+			calls := make([]string, 0)
+
+			committer, ctx := db.WithHooks(context.Background(), mockCommitter)
+			nestedCommitter, nestedCtx := db.WithNestedHooks(ctx, mockNestedCommitter)
+			err := errors.Join(
+				db.OnBeforeCommit(nestedCtx, func(ctx context.Context) error {
+					calls = append(calls, "nested before commit")
+					return nil
+				}),
+				db.OnAfterCommit(nestedCtx, func(ctx context.Context) {
+					calls = append(calls, "nested after commit")
+				}),
+				db.OnAfterRollback(nestedCtx, func(ctx context.Context) {
+					calls = append(calls, "nested after rollback")
+				}),
+			)
+			assert.NoError(t, err)
+
+			if tt.args.commitNested {
+				err = nestedCommitter.CommitTransaction(nestedCtx)
+			} else {
+				err = nestedCommitter.RollbackTransaction(nestedCtx)
+			}
+			assert.NoError(t, err)
+			assert.False(t, db.InTransaction(nestedCtx))
+			assert.True(t, db.InTransaction(ctx))
+
+			err = committer.CommitTransaction(ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wants.calls, calls)
+		})
+	}
+}
+
+func TestWithoutHooks(t *testing.T) {
+	t.Parallel()
+
+	_, ctx := db.WithHooks(context.Background(), nil)
+	ctx = db.WithoutHooks(ctx)
+
+	err := db.OnAfterCommit(ctx, func(ctx context.Context) {})
+	assert.ErrorIs(t, err, db.ErrNoActiveTransaction)
+	assert.False(t, db.InTransaction(ctx))
+}
@@ -0,0 +1,45 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+)
+
+func newCommitter(tx *Tx) db.Committer {
+	return &committer{
+		tx: tx,
+	}
+}
+
+type committer struct {
+	tx *Tx
+}
+
+func (c committer) CommitTransaction(ctx context.Context) error {
+	return c.tx.commit()
+}
+
+func (c committer) RollbackTransaction(ctx context.Context) error {
+	return c.tx.rollback()
+}
+
+func newSavepointCommitter(tx *Tx) db.Committer {
+	return &savepointCommitter{
+		tx:        tx,
+		savepoint: tx.savepoint(),
+	}
+}
+
+type savepointCommitter struct {
+	tx        *Tx
+	savepoint map[string]map[string]write
+}
+
+func (s savepointCommitter) CommitTransaction(ctx context.Context) error {
+	return nil
+}
+
+func (s savepointCommitter) RollbackTransaction(ctx context.Context) error {
+	return s.tx.rollbackTo(s.savepoint)
+}
@@ -0,0 +1,16 @@
+package memory
+
+import (
+	"context"
+)
+
+type transactionKey struct{}
+
+func withTransaction(ctx context.Context, transaction *Tx) context.Context {
+	return context.WithValue(ctx, transactionKey{}, transaction)
+}
+
+func getTransaction(ctx context.Context) (*Tx, bool) {
+	transaction, ok := ctx.Value(transactionKey{}).(*Tx)
+	return transaction, ok && transaction != nil
+}
@@ -0,0 +1,155 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+)
+
+func NewStore() *Store {
+	return &Store{
+		collections: make(map[string]map[string]interface{}),
+		versions:    make(map[string]map[string]uint64),
+	}
+}
+
+// Store is an in-memory document store for tests, documents must not be mutated once stored.
+type Store struct {
+	mu          sync.RWMutex
+	collections map[string]map[string]interface{}
+	versions    map[string]map[string]uint64
+}
+
+func (s *Store) snapshot() (map[string]map[string]interface{}, map[string]map[string]uint64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	collections := make(map[string]map[string]interface{}, len(s.collections))
+	for name, collection := range s.collections {
+		collections[name] = copyCollection(collection)
+	}
+
+	versions := make(map[string]map[string]uint64, len(s.versions))
+	for name, collectionVersions := range s.versions {
+		versions[name] = make(map[string]uint64, len(collectionVersions))
+		for key, version := range collectionVersions {
+			versions[name][key] = version
+		}
+	}
+
+	return collections, versions
+}
+
+func (s *Store) apply(writes map[string]map[string]write) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.applyWrites(writes)
+}
+
+func (s *Store) commit(writes map[string]map[string]write,
+	versions map[string]map[string]uint64,
+	accessed map[string]map[string]struct{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name, keys := range accessed {
+		for key := range keys {
+			if s.versions[name][key] != versions[name][key] {
+				return ErrWriteConflict
+			}
+		}
+	}
+
+	s.applyWrites(writes)
+
+	return nil
+}
+
+func (s *Store) applyWrites(writes map[string]map[string]write) {
+	applyWrites(s.collections, writes)
+
+	for name, collectionWrites := range writes {
+		if _, ok := s.versions[name]; !ok {
+			s.versions[name] = make(map[string]uint64)
+		}
+
+		for key := range collectionWrites {
+			s.versions[name][key]++
+		}
+	}
+}
+
+func (s *Store) get(collection string, key string) (interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	document, ok := s.collections[collection][key]
+	return document, ok
+}
+
+func (s *Store) find(collection string) []interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return sortedDocuments(s.collections[collection])
+}
+
+type write struct {
+	document interface{}
+	deleted  bool
+}
+
+func applyWrites(collections map[string]map[string]interface{}, writes map[string]map[string]write) {
+	for name, collectionWrites := range writes {
+		collection, ok := collections[name]
+		if !ok {
+			collection = make(map[string]interface{})
+			collections[name] = collection
+		}
+
+		for key, documentWrite := range collectionWrites {
+			if documentWrite.deleted {
+				delete(collection, key)
+				continue
+			}
+
+			collection[key] = documentWrite.document
+		}
+	}
+}
+
+func copyCollection(collection map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(collection))
+	for key, document := range collection {
+		copied[key] = document
+	}
+
+	return copied
+}
+
+func copyWrites(writes map[string]map[string]write) map[string]map[string]write {
+	copied := make(map[string]map[string]write, len(writes))
+	for name, collectionWrites := range writes {
+		copied[name] = make(map[string]write, len(collectionWrites))
+		for key, documentWrite := range collectionWrites {
+			copied[name][key] = documentWrite
+		}
+	}
+
+	return copied
+}
+
+func sortedDocuments(collection map[string]interface{}) []interface{} {
+	keys := make([]string, 0, len(collection))
+	for key := range collection {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	documents := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		documents = append(documents, collection[key])
+	}
+
+	return documents
+}
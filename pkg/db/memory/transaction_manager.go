@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+)
+
+func NewTransactionManager(store *Store) db.TransactionManager[*Tx] {
+	return &transactionManager{
+		store: store,
+	}
+}
+
+type transactionManager struct {
+	store *Store
+}
+
+func (t transactionManager) IsTransactionStarted(ctx context.Context) bool {
+	_, ok := getTransaction(ctx)
+	return ok
+}
+
+func (t transactionManager) StartTransaction(ctx context.Context) (db.Committer, context.Context, error) {
+	transaction := newTx(t.store)
+
+	committer, ctx := db.WithHooks(ctx, newCommitter(transaction))
+	ctx = withTransaction(ctx, transaction)
+
+	return committer, ctx, nil
+}
+
+func (t transactionManager) StartNestedTransaction(ctx context.Context) (db.Committer, context.Context, error) {
+	transaction, ok := getTransaction(ctx)
+	if !ok {
+		return t.StartTransaction(ctx)
+	}
+
+	committer, ctx := db.WithNestedHooks(ctx, newSavepointCommitter(transaction))
+
+	return committer, ctx, nil
+}
+
+func (t transactionManager) SuspendTransaction(ctx context.Context) context.Context {
+	ctx = db.WithoutHooks(ctx)
+	ctx = withTransaction(ctx, nil)
+
+	return ctx
+}
+
+func (t transactionManager) GetTransaction(ctx context.Context) *Tx {
+	transaction, ok := getTransaction(ctx)
+	if !ok {
+		return &Tx{
+			store: t.store,
+		}
+	}
+
+	return transaction
+}
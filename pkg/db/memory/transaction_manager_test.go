@@ -0,0 +1,315 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+	db_memory "github.com/vulpes-ferrilata/cqrs/pkg/db/memory"
+)
+
+func Test_transactionManager_Isolation(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	transactionManager := db_memory.NewTransactionManager(db_memory.NewStore())
+	err := transactionManager.GetTransaction(ctx).Put("notes", "1", "committed")
+	assert.NoError(t, err)
+
+	committer, txCtx, err := transactionManager.StartTransaction(ctx)
+	assert.NoError(t, err)
+	transaction := transactionManager.GetTransaction(txCtx)
+	err = transaction.Put("notes", "2", "pending")
+	assert.NoError(t, err)
+	err = transaction.Delete("notes", "1")
+	assert.NoError(t, err)
+
+	// writes made outside of the transaction after it began are not visible to it.
+	err = transactionManager.GetTransaction(ctx).Put("notes", "3", "concurrent")
+	assert.NoError(t, err)
+
+	documents, err := transaction.Find("notes")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"pending"}, documents)
+
+	// writes of the transaction are not visible before it commits.
+	documents, err = transactionManager.GetTransaction(ctx).Find("notes")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"committed", "concurrent"}, documents)
+
+	err = committer.CommitTransaction(txCtx)
+	assert.NoError(t, err)
+
+	documents, err = transactionManager.GetTransaction(ctx).Find("notes")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"pending", "concurrent"}, documents)
+
+	_, _, err = transaction.Get("notes", "2")
+	assert.ErrorIs(t, err, db_memory.ErrTransactionDone)
+	err = committer.RollbackTransaction(txCtx)
+	assert.ErrorIs(t, err, db_memory.ErrTransactionDone)
+}
+
+func Test_transactionManager_StartNestedTransaction(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		rollbackNested bool
+		rollbackInner  bool
+		rollback       bool
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantNotes []interface{}
+	}{
+		{
+			name: "commit all",
+			args: args{
+				rollbackNested: false,
+				rollbackInner:  false,
+				rollback:       false,
+			},
+			wantNotes: []interface{}{"outer", "nested", "inner", "after nested"},
+		},
+		{
+			name: "rollback innermost savepoint",
+			args: args{
+				rollbackNested: false,
+				rollbackInner:  true,
+				rollback:       false,
+			},
+			wantNotes: []interface{}{"outer", "nested", "after nested"},
+		},
+		{
+			name: "rollback savepoint",
+			args: args{
+				rollbackNested: true,
+				rollbackInner:  false,
+				rollback:       false,
+			},
+			wantNotes: []interface{}{"outer", "after nested"},
+		},
+		{
+			name: "rollback transaction",
+			args: args{
+				rollbackNested: false,
+				rollbackInner:  false,
+				rollback:       true,
+			},
+			wantNotes: []interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transactionManager := db_memory.NewTransactionManager(db_memory.NewStore())
+			nestedTransactionManager, ok := transactionManager.(db.NestedTransactionManager)
+			assert.True(t, ok)
+
+			create := func(ctx context.Context, key string, text string) {
+				err := transactionManager.GetTransaction(ctx).Put("notes", key, text)
+				assert.NoError(t, err)
+			}
+
+			// without a transaction a nested transaction starts one.
+			committer, ctx, err := nestedTransactionManager.StartNestedTransaction(context.Background())
+			assert.NoError(t, err)
+			create(ctx, "1", "outer")
+
+			nestedCommitter, nestedCtx, err := nestedTransactionManager.StartNestedTransaction(ctx)
+			assert.NoError(t, err)
+			create(nestedCtx, "2", "nested")
+
+			innerCommitter, innerCtx, err := nestedTransactionManager.StartNestedTransaction(nestedCtx)
+			assert.NoError(t, err)
+			create(innerCtx, "3", "inner")
+
+			if tt.args.rollbackInner {
+				err = innerCommitter.RollbackTransaction(innerCtx)
+			} else {
+				err = innerCommitter.CommitTransaction(innerCtx)
+			}
+			assert.NoError(t, err)
+
+			if tt.args.rollbackNested {
+				err = nestedCommitter.RollbackTransaction(nestedCtx)
+			} else {
+				err = nestedCommitter.CommitTransaction(nestedCtx)
+			}
+			assert.NoError(t, err)
+
+			// the transaction stays usable after a savepoint is rolled back.
+			create(ctx, "4", "after nested")
+
+			if tt.args.rollback {
+				err = committer.RollbackTransaction(ctx)
+			} else {
+				err = committer.CommitTransaction(ctx)
+			}
+			assert.NoError(t, err)
+
+			documents, err := transactionManager.GetTransaction(context.Background()).Find("notes")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantNotes, documents)
+		})
+	}
+}
+
+func Test_transactionManager_SuspendTransaction(t *testing.T) {
+	t.Parallel()
+
+	transactionManager := db_memory.NewTransactionManager(db_memory.NewStore())
+	committer, ctx, err := transactionManager.StartTransaction(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, transactionManager.IsTransactionStarted(ctx))
+
+	transactionSuspender, ok := transactionManager.(db.TransactionSuspender)
+	if assert.True(t, ok) {
+		suspendedCtx := transactionSuspender.SuspendTransaction(ctx)
+		assert.False(t, transactionManager.IsTransactionStarted(suspendedCtx))
+		assert.False(t, db.InTransaction(suspendedCtx))
+
+		// writes outside of the suspended transaction are applied immediately.
+		err = transactionManager.GetTransaction(suspendedCtx).Put("notes", "1", "independent")
+		assert.NoError(t, err)
+	}
+
+	err = committer.RollbackTransaction(ctx)
+	assert.NoError(t, err)
+
+	document, ok, err := transactionManager.GetTransaction(context.Background()).Get("notes", "1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "independent", document)
+}
+
+func Test_transactionManager_WriteConflict(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		access          func(transaction *db_memory.Tx) error
+		concurrentWrite func(transaction *db_memory.Tx) error
+	}
+	type wants struct {
+		notes []interface{}
+		err   error
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "document written concurrently",
+			args: args{
+				access: func(transaction *db_memory.Tx) error {
+					return transaction.Put("notes", "1", "updated")
+				},
+				concurrentWrite: func(transaction *db_memory.Tx) error {
+					return transaction.Put("notes", "1", "concurrent")
+				},
+			},
+			wants: wants{
+				notes: []interface{}{"concurrent"},
+				err:   db_memory.ErrWriteConflict,
+			},
+		},
+		{
+			name: "document read then deleted concurrently",
+			args: args{
+				access: func(transaction *db_memory.Tx) error {
+					if _, _, err := transaction.Get("notes", "1"); err != nil {
+						return err
+					}
+
+					return transaction.Put("notes", "2", "created")
+				},
+				concurrentWrite: func(transaction *db_memory.Tx) error {
+					return transaction.Delete("notes", "1")
+				},
+			},
+			wants: wants{
+				notes: []interface{}{},
+				err:   db_memory.ErrWriteConflict,
+			},
+		},
+		{
+			name: "missing document created concurrently",
+			args: args{
+				access: func(transaction *db_memory.Tx) error {
+					return transaction.Put("notes", "2", "created")
+				},
+				concurrentWrite: func(transaction *db_memory.Tx) error {
+					return transaction.Put("notes", "2", "concurrent")
+				},
+			},
+			wants: wants{
+				notes: []interface{}{"initial", "concurrent"},
+				err:   db_memory.ErrWriteConflict,
+			},
+		},
+		{
+			name: "another document written concurrently",
+			args: args{
+				access: func(transaction *db_memory.Tx) error {
+					return transaction.Put("notes", "1", "updated")
+				},
+				concurrentWrite: func(transaction *db_memory.Tx) error {
+					return transaction.Put("notes", "2", "concurrent")
+				},
+			},
+			wants: wants{
+				notes: []interface{}{"updated", "concurrent"},
+				err:   nil,
+			},
+		},
+		{
+			name: "transaction without writes",
+			args: args{
+				access: func(transaction *db_memory.Tx) error {
+					_, err := transaction.Find("notes")
+					return err
+				},
+				concurrentWrite: func(transaction *db_memory.Tx) error {
+					return transaction.Put("notes", "1", "concurrent")
+				},
+			},
+			wants: wants{
+				notes: []interface{}{"concurrent"},
+				err:   nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			transactionManager := db_memory.NewTransactionManager(db_memory.NewStore())
+			err := transactionManager.GetTransaction(ctx).Put("notes", "1", "initial")
+			assert.NoError(t, err)
+
+			committer, txCtx, err := transactionManager.StartTransaction(ctx)
+			assert.NoError(t, err)
+			err = tt.args.access(transactionManager.GetTransaction(txCtx))
+			assert.NoError(t, err)
+
+			// the first transaction to commit wins.
+			concurrentCommitter, concurrentCtx, err := transactionManager.StartTransaction(ctx)
+			assert.NoError(t, err)
+			err = tt.args.concurrentWrite(transactionManager.GetTransaction(concurrentCtx))
+			assert.NoError(t, err)
+			err = concurrentCommitter.CommitTransaction(concurrentCtx)
+			assert.NoError(t, err)
+
+			err = committer.CommitTransaction(txCtx)
+			assert.ErrorIs(t, err, tt.wants.err)
+
+			documents, err := transactionManager.GetTransaction(ctx).Find("notes")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wants.notes, documents)
+		})
+	}
+}
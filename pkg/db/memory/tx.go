@@ -0,0 +1,188 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/vulpes-ferrilata/cqrs"
+)
+
+var (
+	ErrTransactionDone = errors.New("transaction has already been committed or rolled back")
+	ErrWriteConflict   = fmt.Errorf("document written by a concurrent transaction: %w", cqrs.ErrConcurrencyConflict)
+)
+
+func newTx(store *Store) *Tx {
+	snapshot, versions := store.snapshot()
+
+	return &Tx{
+		store:    store,
+		snapshot: snapshot,
+		versions: versions,
+		accessed: make(map[string]map[string]struct{}),
+		writes:   make(map[string]map[string]write),
+	}
+}
+
+// Tx reads from a snapshot of the store, its commit fails with ErrWriteConflict when a document it accessed
+// has been written since. A Tx returned outside of a transaction accesses the store directly.
+type Tx struct {
+	mu       sync.Mutex
+	store    *Store
+	snapshot map[string]map[string]interface{}
+	versions map[string]map[string]uint64
+	accessed map[string]map[string]struct{}
+	writes   map[string]map[string]write
+	done     bool
+}
+
+func (t *Tx) isAutoCommit() bool {
+	return t.snapshot == nil
+}
+
+func (t *Tx) access(collection string, key string) {
+	if _, ok := t.accessed[collection]; !ok {
+		t.accessed[collection] = make(map[string]struct{})
+	}
+	t.accessed[collection][key] = struct{}{}
+}
+
+func (t *Tx) Get(collection string, key string) (interface{}, bool, error) {
+	if t.isAutoCommit() {
+		document, ok := t.store.get(collection, key)
+		return document, ok, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.done {
+		return nil, false, ErrTransactionDone
+	}
+
+	t.access(collection, key)
+
+	if documentWrite, ok := t.writes[collection][key]; ok {
+		return documentWrite.document, !documentWrite.deleted, nil
+	}
+
+	document, ok := t.snapshot[collection][key]
+	return document, ok, nil
+}
+
+// Find returns the documents of a collection ordered by key.
+func (t *Tx) Find(collection string) ([]interface{}, error) {
+	if t.isAutoCommit() {
+		return t.store.find(collection), nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.done {
+		return nil, ErrTransactionDone
+	}
+
+	documents := copyCollection(t.snapshot[collection])
+	for key := range documents {
+		t.access(collection, key)
+	}
+
+	for key, documentWrite := range t.writes[collection] {
+		if documentWrite.deleted {
+			delete(documents, key)
+			continue
+		}
+
+		documents[key] = documentWrite.document
+	}
+
+	return sortedDocuments(documents), nil
+}
+
+func (t *Tx) Put(collection string, key string, document interface{}) error {
+	return t.write(collection, key, write{document: document})
+}
+
+func (t *Tx) Delete(collection string, key string) error {
+	return t.write(collection, key, write{deleted: true})
+}
+
+func (t *Tx) write(collection string, key string, documentWrite write) error {
+	writes := map[string]map[string]write{
+		collection: {
+			key: documentWrite,
+		},
+	}
+
+	if t.isAutoCommit() {
+		t.store.apply(writes)
+
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.done {
+		return ErrTransactionDone
+	}
+
+	t.access(collection, key)
+
+	if _, ok := t.writes[collection]; !ok {
+		t.writes[collection] = make(map[string]write)
+	}
+	t.writes[collection][key] = documentWrite
+
+	return nil
+}
+
+func (t *Tx) commit() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.done {
+		return ErrTransactionDone
+	}
+	t.done = true
+
+	if len(t.writes) == 0 {
+		return nil
+	}
+
+	return t.store.commit(t.writes, t.versions, t.accessed)
+}
+
+func (t *Tx) rollback() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.done {
+		return ErrTransactionDone
+	}
+	t.done = true
+
+	return nil
+}
+
+func (t *Tx) savepoint() map[string]map[string]write {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return copyWrites(t.writes)
+}
+
+func (t *Tx) rollbackTo(savepoint map[string]map[string]write) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.done {
+		return ErrTransactionDone
+	}
+
+	t.writes = copyWrites(savepoint)
+
+	return nil
+}
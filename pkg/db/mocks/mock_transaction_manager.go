@@ -7,6 +7,7 @@ package mock_db
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	db "github.com/vulpes-ferrilata/cqrs/pkg/db"
 	gomock "go.uber.org/mock/gomock"
@@ -78,3 +79,195 @@ func (mr *MockTransactionManagerMockRecorder[DB]) StartTransaction(ctx interface
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartTransaction", reflect.TypeOf((*MockTransactionManager[DB])(nil).StartTransaction), ctx)
 }
+
+// MockTransactionSuspender is a mock of TransactionSuspender interface.
+type MockTransactionSuspender struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransactionSuspenderMockRecorder
+}
+
+// MockTransactionSuspenderMockRecorder is the mock recorder for MockTransactionSuspender.
+type MockTransactionSuspenderMockRecorder struct {
+	mock *MockTransactionSuspender
+}
+
+// NewMockTransactionSuspender creates a new mock instance.
+func NewMockTransactionSuspender(ctrl *gomock.Controller) *MockTransactionSuspender {
+	mock := &MockTransactionSuspender{ctrl: ctrl}
+	mock.recorder = &MockTransactionSuspenderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransactionSuspender) EXPECT() *MockTransactionSuspenderMockRecorder {
+	return m.recorder
+}
+
+// SuspendTransaction mocks base method.
+func (m *MockTransactionSuspender) SuspendTransaction(ctx context.Context) context.Context {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SuspendTransaction", ctx)
+	ret0, _ := ret[0].(context.Context)
+	return ret0
+}
+
+// SuspendTransaction indicates an expected call of SuspendTransaction.
+func (mr *MockTransactionSuspenderMockRecorder) SuspendTransaction(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SuspendTransaction", reflect.TypeOf((*MockTransactionSuspender)(nil).SuspendTransaction), ctx)
+}
+
+// MockNestedTransactionManager is a mock of NestedTransactionManager interface.
+type MockNestedTransactionManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockNestedTransactionManagerMockRecorder
+}
+
+// MockNestedTransactionManagerMockRecorder is the mock recorder for MockNestedTransactionManager.
+type MockNestedTransactionManagerMockRecorder struct {
+	mock *MockNestedTransactionManager
+}
+
+// NewMockNestedTransactionManager creates a new mock instance.
+func NewMockNestedTransactionManager(ctrl *gomock.Controller) *MockNestedTransactionManager {
+	mock := &MockNestedTransactionManager{ctrl: ctrl}
+	mock.recorder = &MockNestedTransactionManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNestedTransactionManager) EXPECT() *MockNestedTransactionManagerMockRecorder {
+	return m.recorder
+}
+
+// StartNestedTransaction mocks base method.
+func (m *MockNestedTransactionManager) StartNestedTransaction(ctx context.Context) (db.Committer, context.Context, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartNestedTransaction", ctx)
+	ret0, _ := ret[0].(db.Committer)
+	ret1, _ := ret[1].(context.Context)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// StartNestedTransaction indicates an expected call of StartNestedTransaction.
+func (mr *MockNestedTransactionManagerMockRecorder) StartNestedTransaction(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartNestedTransaction", reflect.TypeOf((*MockNestedTransactionManager)(nil).StartNestedTransaction), ctx)
+}
+
+// MockTransactionRetrier is a mock of TransactionRetrier interface.
+type MockTransactionRetrier struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransactionRetrierMockRecorder
+}
+
+// MockTransactionRetrierMockRecorder is the mock recorder for MockTransactionRetrier.
+type MockTransactionRetrierMockRecorder struct {
+	mock *MockTransactionRetrier
+}
+
+// NewMockTransactionRetrier creates a new mock instance.
+func NewMockTransactionRetrier(ctrl *gomock.Controller) *MockTransactionRetrier {
+	mock := &MockTransactionRetrier{ctrl: ctrl}
+	mock.recorder = &MockTransactionRetrierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransactionRetrier) EXPECT() *MockTransactionRetrierMockRecorder {
+	return m.recorder
+}
+
+// RetryTransaction mocks base method.
+func (m *MockTransactionRetrier) RetryTransaction(err error, attempts int, startedAt time.Time) (time.Duration, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryTransaction", err, attempts, startedAt)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// RetryTransaction indicates an expected call of RetryTransaction.
+func (mr *MockTransactionRetrierMockRecorder) RetryTransaction(err, attempts, startedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryTransaction", reflect.TypeOf((*MockTransactionRetrier)(nil).RetryTransaction), err, attempts, startedAt)
+}
+
+// MockReadOnlyTransactionManager is a mock of ReadOnlyTransactionManager interface.
+type MockReadOnlyTransactionManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockReadOnlyTransactionManagerMockRecorder
+}
+
+// MockReadOnlyTransactionManagerMockRecorder is the mock recorder for MockReadOnlyTransactionManager.
+type MockReadOnlyTransactionManagerMockRecorder struct {
+	mock *MockReadOnlyTransactionManager
+}
+
+// NewMockReadOnlyTransactionManager creates a new mock instance.
+func NewMockReadOnlyTransactionManager(ctrl *gomock.Controller) *MockReadOnlyTransactionManager {
+	mock := &MockReadOnlyTransactionManager{ctrl: ctrl}
+	mock.recorder = &MockReadOnlyTransactionManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReadOnlyTransactionManager) EXPECT() *MockReadOnlyTransactionManagerMockRecorder {
+	return m.recorder
+}
+
+// StartReadOnlyTransaction mocks base method.
+func (m *MockReadOnlyTransactionManager) StartReadOnlyTransaction(ctx context.Context) (db.Committer, context.Context, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartReadOnlyTransaction", ctx)
+	ret0, _ := ret[0].(db.Committer)
+	ret1, _ := ret[1].(context.Context)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// StartReadOnlyTransaction indicates an expected call of StartReadOnlyTransaction.
+func (mr *MockReadOnlyTransactionManagerMockRecorder) StartReadOnlyTransaction(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartReadOnlyTransaction", reflect.TypeOf((*MockReadOnlyTransactionManager)(nil).StartReadOnlyTransaction), ctx)
+}
+
+// MockConfigurableTransactionManager is a mock of ConfigurableTransactionManager interface.
+type MockConfigurableTransactionManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockConfigurableTransactionManagerMockRecorder
+}
+
+// MockConfigurableTransactionManagerMockRecorder is the mock recorder for MockConfigurableTransactionManager.
+type MockConfigurableTransactionManagerMockRecorder struct {
+	mock *MockConfigurableTransactionManager
+}
+
+// NewMockConfigurableTransactionManager creates a new mock instance.
+func NewMockConfigurableTransactionManager(ctrl *gomock.Controller) *MockConfigurableTransactionManager {
+	mock := &MockConfigurableTransactionManager{ctrl: ctrl}
+	mock.recorder = &MockConfigurableTransactionManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockConfigurableTransactionManager) EXPECT() *MockConfigurableTransactionManagerMockRecorder {
+	return m.recorder
+}
+
+// StartTransactionWithOptions mocks base method.
+func (m *MockConfigurableTransactionManager) StartTransactionWithOptions(ctx context.Context, opts interface{}) (db.Committer, context.Context, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartTransactionWithOptions", ctx, opts)
+	ret0, _ := ret[0].(db.Committer)
+	ret1, _ := ret[1].(context.Context)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// StartTransactionWithOptions indicates an expected call of StartTransactionWithOptions.
+func (mr *MockConfigurableTransactionManagerMockRecorder) StartTransactionWithOptions(ctx, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartTransactionWithOptions", reflect.TypeOf((*MockConfigurableTransactionManager)(nil).StartTransactionWithOptions), ctx, opts)
+}
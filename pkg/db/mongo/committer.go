@@ -2,26 +2,77 @@ package mongo
 
 import (
 	"context"
+	"time"
 
 	"go.mongodb.org/mongo-driver/mongo"
 
 	"github.com/vulpes-ferrilata/cqrs/pkg/db"
 )
 
-func newCommitter(session mongo.Session) db.Committer {
+func newCommitter(session mongo.Session, startedAt time.Time, initialBackoff time.Duration, maxBackoff time.Duration) db.Committer {
 	return &committer{
-		session: session,
+		session:        session,
+		startedAt:      startedAt,
+		initialBackoff: initialBackoff,
+		maxBackoff:     maxBackoff,
 	}
 }
 
 type committer struct {
-	session mongo.Session
+	session        mongo.Session
+	startedAt      time.Time
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
 }
 
 func (c committer) CommitTransaction(ctx context.Context) error {
-	return c.session.CommitTransaction(ctx)
+	defer c.session.EndSession(context.WithoutCancel(ctx))
+
+	for attempts := 1; ; attempts++ {
+		err := c.session.CommitTransaction(ctx)
+		if err == nil {
+			return nil
+		}
+
+		if !isUnknownTransactionCommitResult(err) || time.Since(c.startedAt) >= transactionRetryTimeout {
+			return err
+		}
+
+		timer := time.NewTimer(db.Backoff(attempts, c.initialBackoff, c.maxBackoff))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return err
+		case <-timer.C:
+		}
+	}
 }
 
 func (c committer) RollbackTransaction(ctx context.Context) error {
+	defer c.session.EndSession(context.WithoutCancel(ctx))
+
 	return c.session.AbortTransaction(ctx)
 }
+
+func newSnapshotCommitter(session mongo.Session) db.Committer {
+	return &snapshotCommitter{
+		session: session,
+	}
+}
+
+type snapshotCommitter struct {
+	session mongo.Session
+}
+
+func (s snapshotCommitter) CommitTransaction(ctx context.Context) error {
+	s.session.EndSession(context.WithoutCancel(ctx))
+
+	return nil
+}
+
+func (s snapshotCommitter) RollbackTransaction(ctx context.Context) error {
+	s.session.EndSession(context.WithoutCancel(ctx))
+
+	return nil
+}
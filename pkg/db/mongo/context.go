@@ -14,5 +14,5 @@ func withTransaction(ctx context.Context, transaction *mongo.Database) context.C
 
 func getTransaction(ctx context.Context) (*mongo.Database, bool) {
 	transaction, ok := ctx.Value(transactionKey{}).(*mongo.Database)
-	return transaction, ok
+	return transaction, ok && transaction != nil
 }
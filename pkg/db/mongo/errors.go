@@ -0,0 +1,38 @@
+package mongo
+
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	transientTransactionErrorLabel      = "TransientTransactionError"
+	unknownTransactionCommitResultLabel = "UnknownTransactionCommitResult"
+
+	transactionRetryTimeout = 120 * time.Second
+)
+
+// IsTransientTransactionError reports whether the whole transaction can be re-executed.
+func IsTransientTransactionError(err error) bool {
+	return hasErrorLabel(err, transientTransactionErrorLabel)
+}
+
+func hasErrorLabel(err error, label string) bool {
+	var labeledError mongo.LabeledError
+	if !errors.As(err, &labeledError) {
+		return false
+	}
+
+	return labeledError.HasErrorLabel(label)
+}
+
+func isUnknownTransactionCommitResult(err error) bool {
+	var commandError mongo.CommandError
+	if errors.As(err, &commandError) && commandError.IsMaxTimeMSExpiredError() {
+		return false
+	}
+
+	return hasErrorLabel(err, unknownTransactionCommitResultLabel)
+}
@@ -0,0 +1,50 @@
+package mongo_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	db_mongo "github.com/vulpes-ferrilata/cqrs/pkg/db/mongo"
+)
+
+func TestIsTransientTransactionError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "transient transaction error",
+			err: mongo.CommandError{
+				Code:   112,
+				Name:   "WriteConflict",
+				Labels: []string{"TransientTransactionError"},
+			},
+			want: true,
+		},
+		{
+			name: "unknown transaction commit result",
+			err: mongo.CommandError{
+				Code:   91,
+				Name:   "ShutdownInProgress",
+				Labels: []string{"UnknownTransactionCommitResult"},
+			},
+			want: false,
+		},
+		{
+			name: "unlabeled error",
+			err:  errors.New("error"),
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, db_mongo.IsTransientTransactionError(tt.err))
+		})
+	}
+}
@@ -2,27 +2,70 @@ package mongo
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
 
 	"github.com/vulpes-ferrilata/cqrs/pkg/db"
 )
 
+type TransactionManagerOption func(t *transactionManager)
+
+// WithRetryMaxAttempts lets TransactionMiddleware re-execute the transactions it starts when they fail with
+// a TransientTransactionError label, within 120 seconds as mongo.Session.WithTransaction does.
+func WithRetryMaxAttempts(maxAttempts int) TransactionManagerOption {
+	return func(t *transactionManager) {
+		t.retryMaxAttempts = maxAttempts
+	}
+}
+
+// WithRetryBackoff spaces the re-executed transactions and the commits retried on an UnknownTransactionCommitResult label.
+func WithRetryBackoff(initialBackoff time.Duration, maxBackoff time.Duration) TransactionManagerOption {
+	return func(t *transactionManager) {
+		t.retryInitialBackoff = initialBackoff
+		t.retryMaxBackoff = maxBackoff
+	}
+}
+
+// WithReplica routes reads of queries to the replica, their read-only transactions run in a snapshot session
+// of the replica, which requires MongoDB 5.0.
+func WithReplica(replica *mongo.Database) TransactionManagerOption {
+	return func(t *transactionManager) {
+		t.replica = replica
+	}
+}
+
 func NewTransactionManager(db *mongo.Database,
 	sessionOptions *options.SessionOptions,
-	transactionOptions *options.TransactionOptions) db.TransactionManager[*mongo.Database] {
-	return &transactionManager{
-		db:                 db,
-		sessionOptions:     sessionOptions,
-		transactionOptions: transactionOptions,
+	transactionOptions *options.TransactionOptions,
+	managerOpts ...TransactionManagerOption) db.TransactionManager[*mongo.Database] {
+	transactionManager := &transactionManager{
+		db:                  db,
+		sessionOptions:      sessionOptions,
+		transactionOptions:  transactionOptions,
+		retryMaxAttempts:    1,
+		retryInitialBackoff: 50 * time.Millisecond,
+		retryMaxBackoff:     time.Second,
 	}
+
+	for _, managerOpt := range managerOpts {
+		managerOpt(transactionManager)
+	}
+
+	return transactionManager
 }
 
 type transactionManager struct {
-	db                 *mongo.Database
-	sessionOptions     *options.SessionOptions
-	transactionOptions *options.TransactionOptions
+	db                  *mongo.Database
+	replica             *mongo.Database
+	sessionOptions      *options.SessionOptions
+	transactionOptions  *options.TransactionOptions
+	retryMaxAttempts    int
+	retryInitialBackoff time.Duration
+	retryMaxBackoff     time.Duration
 }
 
 func (t transactionManager) IsTransactionStarted(ctx context.Context) bool {
@@ -31,23 +74,93 @@ func (t transactionManager) IsTransactionStarted(ctx context.Context) bool {
 }
 
 func (t transactionManager) StartTransaction(ctx context.Context) (db.Committer, context.Context, error) {
+	return t.startTransaction(ctx, t.transactionOptions)
+}
+
+func (t transactionManager) StartReadOnlyTransaction(ctx context.Context) (db.Committer, context.Context, error) {
+	if t.replica != nil && db.IsReplicaRead(ctx) {
+		return t.startSnapshotSession(ctx)
+	}
+
+	transactionOptions := options.MergeTransactionOptions(
+		t.transactionOptions,
+		options.Transaction().SetReadConcern(readconcern.Snapshot()),
+	)
+
+	return t.startTransaction(ctx, transactionOptions)
+}
+
+// StartTransactionWithOptions starts a transaction with *options.TransactionOptions, such as a read concern,
+// a write concern or a max commit time, merged over the options of the TransactionManager.
+func (t transactionManager) StartTransactionWithOptions(ctx context.Context, opts interface{}) (db.Committer, context.Context, error) {
+	transactionOptions, ok := opts.(*options.TransactionOptions)
+	if !ok {
+		return nil, ctx, fmt.Errorf("%w: %T", db.ErrTransactionOptionsNotSupported, opts)
+	}
+
+	return t.startTransaction(ctx, options.MergeTransactionOptions(t.transactionOptions, transactionOptions))
+}
+
+func (t transactionManager) startSnapshotSession(ctx context.Context) (db.Committer, context.Context, error) {
+	sessionOptions := options.MergeSessionOptions(
+		t.sessionOptions,
+		options.Session().SetSnapshot(true).SetCausalConsistency(false),
+	)
+
+	session, err := t.replica.Client().StartSession(sessionOptions)
+	if err != nil {
+		return nil, ctx, err
+	}
+
+	committer, ctx := db.WithHooks(ctx, newSnapshotCommitter(session))
+	ctx = mongo.NewSessionContext(ctx, session)
+	ctx = withTransaction(ctx, t.replica)
+
+	return committer, ctx, nil
+}
+
+func (t transactionManager) startTransaction(ctx context.Context, transactionOptions *options.TransactionOptions) (db.Committer, context.Context, error) {
 	session, err := t.db.Client().StartSession(t.sessionOptions)
 	if err != nil {
 		return nil, ctx, err
 	}
 
-	if err = session.StartTransaction(t.transactionOptions); err != nil {
+	if err = session.StartTransaction(transactionOptions); err != nil {
+		session.EndSession(ctx)
+
 		return nil, ctx, err
 	}
 
+	committer, ctx := db.WithHooks(ctx, newCommitter(session, time.Now(), t.retryInitialBackoff, t.retryMaxBackoff))
+	ctx = mongo.NewSessionContext(ctx, session)
 	ctx = withTransaction(ctx, t.db)
 
-	return newCommitter(session), ctx, nil
+	return committer, ctx, nil
+}
+
+func (t transactionManager) SuspendTransaction(ctx context.Context) context.Context {
+	ctx = db.WithoutHooks(ctx)
+	ctx = mongo.NewSessionContext(ctx, nil)
+	ctx = withTransaction(ctx, nil)
+
+	return ctx
+}
+
+func (t transactionManager) RetryTransaction(err error, attempts int, startedAt time.Time) (time.Duration, bool) {
+	if attempts >= t.retryMaxAttempts || !hasErrorLabel(err, transientTransactionErrorLabel) || time.Since(startedAt) >= transactionRetryTimeout {
+		return 0, false
+	}
+
+	return db.Backoff(attempts, t.retryInitialBackoff, t.retryMaxBackoff), true
 }
 
 func (t transactionManager) GetTransaction(ctx context.Context) *mongo.Database {
 	transaction, ok := getTransaction(ctx)
 	if !ok {
+		if t.replica != nil && db.IsReplicaRead(ctx) {
+			return t.replica
+		}
+
 		return t.db
 	}
 
@@ -0,0 +1,446 @@
+package mongo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/session"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+	db_mongo "github.com/vulpes-ferrilata/cqrs/pkg/db/mongo"
+)
+
+func Test_transactionManager_StartTransaction(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		responses []bson.D
+		rollback  bool
+	}
+	type wants struct {
+		commands   []string
+		err        bool
+		minElapsed time.Duration
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "commit",
+			args: args{
+				responses: []bson.D{
+					mtest.CreateSuccessResponse(),
+					mtest.CreateSuccessResponse(),
+				},
+				rollback: false,
+			},
+			wants: wants{
+				commands: []string{"insert", "commitTransaction"},
+				err:      false,
+			},
+		},
+		{
+			name: "unknown commit result is retried",
+			args: args{
+				responses: []bson.D{
+					mtest.CreateSuccessResponse(),
+					mtest.CreateCommandErrorResponse(mtest.CommandError{
+						Code:    91,
+						Name:    "ShutdownInProgress",
+						Message: "shutdown in progress",
+						Labels:  []string{"UnknownTransactionCommitResult"},
+					}),
+					mtest.CreateSuccessResponse(),
+				},
+				rollback: false,
+			},
+			wants: wants{
+				commands:   []string{"insert", "commitTransaction", "commitTransaction"},
+				err:        false,
+				minElapsed: 10 * time.Millisecond,
+			},
+		},
+		{
+			name: "transient commit error is left to the caller",
+			args: args{
+				responses: []bson.D{
+					mtest.CreateSuccessResponse(),
+					mtest.CreateCommandErrorResponse(mtest.CommandError{
+						Code:    112,
+						Name:    "WriteConflict",
+						Message: "write conflict",
+						Labels:  []string{"TransientTransactionError"},
+					}),
+				},
+				rollback: false,
+			},
+			wants: wants{
+				commands: []string{"insert", "commitTransaction"},
+				err:      true,
+			},
+		},
+		{
+			name: "rollback",
+			args: args{
+				responses: []bson.D{
+					mtest.CreateSuccessResponse(),
+					mtest.CreateSuccessResponse(),
+				},
+				rollback: true,
+			},
+			wants: wants{
+				commands: []string{"insert", "abortTransaction"},
+				err:      false,
+			},
+		},
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			mt.AddMockResponses(tt.args.responses...)
+
+			transactionManager := db_mongo.NewTransactionManager(mt.DB, nil, nil,
+				db_mongo.WithRetryBackoff(20*time.Millisecond, 20*time.Millisecond),
+			)
+			committer, ctx, err := transactionManager.StartTransaction(context.Background())
+			assert.NoError(mt, err)
+			assert.True(mt, transactionManager.IsTransactionStarted(ctx))
+
+			mongoSession := mongo.SessionFromContext(ctx)
+			if assert.NotNil(mt, mongoSession) {
+				_, err = transactionManager.GetTransaction(ctx).Collection("notes").InsertOne(ctx, bson.D{{Key: "text", Value: "note"}})
+				assert.NoError(mt, err)
+
+				startedAt := time.Now()
+				if tt.args.rollback {
+					err = committer.RollbackTransaction(ctx)
+				} else {
+					err = committer.CommitTransaction(ctx)
+				}
+				assert.Equal(mt, tt.wants.err, err != nil)
+				// retried commits wait for the backoff.
+				assert.GreaterOrEqual(mt, time.Since(startedAt), tt.wants.minElapsed)
+
+				// the session is ended once the transaction has finished.
+				err = mongoSession.AdvanceClusterTime(nil)
+				assert.ErrorIs(mt, err, session.ErrSessionEnded)
+			}
+
+			commands := make([]string, 0)
+			for _, event := range mt.GetAllStartedEvents() {
+				commands = append(commands, event.CommandName)
+			}
+			assert.Equal(mt, tt.wants.commands, commands)
+
+			// writes run inside the transaction.
+			insert := mt.GetAllStartedEvents()[0].Command
+			assert.True(mt, insert.Lookup("startTransaction").Boolean())
+			assert.NotNil(mt, insert.Lookup("lsid").Value)
+		})
+	}
+}
+
+func Test_transactionManager_SuspendTransaction(t *testing.T) {
+	t.Parallel()
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("suspend", func(mt *mtest.T) {
+		transactionManager := db_mongo.NewTransactionManager(mt.DB, nil, nil)
+		committer, ctx, err := transactionManager.StartTransaction(context.Background())
+		assert.NoError(mt, err)
+
+		transactionSuspender, ok := transactionManager.(db.TransactionSuspender)
+		if assert.True(mt, ok) {
+			suspendedCtx := transactionSuspender.SuspendTransaction(ctx)
+			assert.False(mt, transactionManager.IsTransactionStarted(suspendedCtx))
+			assert.False(mt, db.InTransaction(suspendedCtx))
+			assert.Nil(mt, mongo.SessionFromContext(suspendedCtx))
+		}
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		err = committer.RollbackTransaction(ctx)
+		assert.NoError(mt, err)
+	})
+}
+
+func Test_transactionManager_StartReadOnlyTransaction(t *testing.T) {
+	t.Parallel()
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("snapshot read concern", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db.notes", mtest.FirstBatch),
+			mtest.CreateSuccessResponse(),
+		)
+
+		transactionManager := db_mongo.NewTransactionManager(mt.DB, nil, nil)
+		readOnlyTransactionManager, ok := transactionManager.(db.ReadOnlyTransactionManager)
+		if !assert.True(mt, ok) {
+			return
+		}
+
+		committer, ctx, err := readOnlyTransactionManager.StartReadOnlyTransaction(context.Background())
+		assert.NoError(mt, err)
+		assert.True(mt, transactionManager.IsTransactionStarted(ctx))
+
+		cursor, err := transactionManager.GetTransaction(ctx).Collection("notes").Find(ctx, bson.D{})
+		assert.NoError(mt, err)
+		err = cursor.Close(ctx)
+		assert.NoError(mt, err)
+
+		err = committer.CommitTransaction(ctx)
+		assert.NoError(mt, err)
+
+		find := mt.GetStartedEvent().Command
+		assert.True(mt, find.Lookup("startTransaction").Boolean())
+		assert.Equal(mt, "snapshot", find.Lookup("readConcern", "level").StringValue())
+	})
+}
+
+func Test_transactionManager_GetTransaction(t *testing.T) {
+	t.Parallel()
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("replica", func(mt *mtest.T) {
+		replica := mt.Client.Database("replica")
+
+		transactionManager := db_mongo.NewTransactionManager(mt.DB, nil, nil, db_mongo.WithReplica(replica))
+		assert.Equal(mt, mt.DB, transactionManager.GetTransaction(context.Background()))
+		assert.Equal(mt, replica, transactionManager.GetTransaction(db.ReadFromReplica(context.Background())))
+		assert.Equal(mt, mt.DB, transactionManager.GetTransaction(db.ReadFromReplica(db.ReadFromPrimary(context.Background()))))
+
+		// transactions run on the primary.
+		committer, ctx, err := transactionManager.StartTransaction(db.ReadFromReplica(context.Background()))
+		assert.NoError(mt, err)
+		assert.Equal(mt, mt.DB, transactionManager.GetTransaction(ctx))
+
+		err = committer.RollbackTransaction(ctx)
+		assert.NoError(mt, err)
+
+		// read-only transactions routed to the replica read from it in a snapshot session.
+		readOnlyTransactionManager, ok := transactionManager.(db.ReadOnlyTransactionManager)
+		if assert.True(mt, ok) {
+			mt.ClearEvents()
+
+			committer, ctx, err = readOnlyTransactionManager.StartReadOnlyTransaction(db.ReadFromReplica(context.Background()))
+			assert.NoError(mt, err)
+			assert.True(mt, transactionManager.IsTransactionStarted(ctx))
+			assert.Equal(mt, replica, transactionManager.GetTransaction(ctx))
+
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "replica.notes", mtest.FirstBatch))
+			cursor, err := transactionManager.GetTransaction(ctx).Collection("notes").Find(ctx, bson.D{})
+			assert.NoError(mt, err)
+			err = cursor.Close(ctx)
+			assert.NoError(mt, err)
+
+			err = committer.CommitTransaction(ctx)
+			assert.NoError(mt, err)
+
+			find := mt.GetStartedEvent().Command
+			_, err = find.LookupErr("startTransaction")
+			assert.Error(mt, err)
+			assert.Equal(mt, "snapshot", find.Lookup("readConcern", "level").StringValue())
+		}
+	})
+}
+
+func Test_transactionManager_StartTransactionWithOptions(t *testing.T) {
+	t.Parallel()
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("unsupported transaction options", func(mt *mtest.T) {
+		transactionManager := db_mongo.NewTransactionManager(mt.DB, nil, nil)
+		configurableTransactionManager, ok := transactionManager.(db.ConfigurableTransactionManager)
+		if !assert.True(mt, ok) {
+			return
+		}
+
+		_, ctx, err := configurableTransactionManager.StartTransactionWithOptions(context.Background(), writeconcern.Majority())
+		assert.ErrorIs(mt, err, db.ErrTransactionOptionsNotSupported)
+		assert.False(mt, transactionManager.IsTransactionStarted(ctx))
+	})
+
+	mt.Run("merged over the transaction manager options", func(mt *mtest.T) {
+		maxCommitTime := 5 * time.Second
+
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(),
+			mtest.CreateSuccessResponse(),
+		)
+
+		transactionManager := db_mongo.NewTransactionManager(mt.DB, nil, options.Transaction().SetReadConcern(readconcern.Majority()))
+		configurableTransactionManager, ok := transactionManager.(db.ConfigurableTransactionManager)
+		if !assert.True(mt, ok) {
+			return
+		}
+
+		committer, ctx, err := configurableTransactionManager.StartTransactionWithOptions(context.Background(), options.Transaction().
+			SetWriteConcern(writeconcern.Majority()).
+			SetMaxCommitTime(&maxCommitTime),
+		)
+		assert.NoError(mt, err)
+
+		_, err = transactionManager.GetTransaction(ctx).Collection("notes").InsertOne(ctx, bson.D{{Key: "text", Value: "note"}})
+		assert.NoError(mt, err)
+		err = committer.CommitTransaction(ctx)
+		assert.NoError(mt, err)
+
+		insert := mt.GetStartedEvent().Command
+		assert.Equal(mt, "majority", insert.Lookup("readConcern", "level").StringValue())
+
+		commitTransaction := mt.GetStartedEvent().Command
+		assert.Equal(mt, "majority", commitTransaction.Lookup("writeConcern", "w").StringValue())
+		assert.Equal(mt, int64(maxCommitTime/time.Millisecond), commitTransaction.Lookup("maxTimeMS").AsInt64())
+	})
+}
+
+func Test_transactionManager_RetryTransaction(t *testing.T) {
+	t.Parallel()
+
+	var (
+		transientError = mongo.CommandError{
+			Code:   112,
+			Name:   "WriteConflict",
+			Labels: []string{"TransientTransactionError"},
+		}
+	)
+
+	type args struct {
+		opts      []db_mongo.TransactionManagerOption
+		err       error
+		attempts  int
+		startedAt time.Time
+	}
+	type wants struct {
+		retry      bool
+		minBackoff time.Duration
+		maxBackoff time.Duration
+	}
+	tests := []struct {
+		name  string
+		args  args
+		wants wants
+	}{
+		{
+			name: "no retry policy",
+			args: args{
+				opts:      nil,
+				err:       transientError,
+				attempts:  1,
+				startedAt: time.Now(),
+			},
+			wants: wants{
+				retry: false,
+			},
+		},
+		{
+			name: "transient error",
+			args: args{
+				opts: []db_mongo.TransactionManagerOption{
+					db_mongo.WithRetryMaxAttempts(20),
+				},
+				err:       transientError,
+				attempts:  10,
+				startedAt: time.Now(),
+			},
+			wants: wants{
+				retry:      true,
+				minBackoff: 500 * time.Millisecond,
+				maxBackoff: time.Second,
+			},
+		},
+		{
+			name: "custom backoff",
+			args: args{
+				opts: []db_mongo.TransactionManagerOption{
+					db_mongo.WithRetryMaxAttempts(3),
+					db_mongo.WithRetryBackoff(10*time.Millisecond, 20*time.Millisecond),
+				},
+				err:       transientError,
+				attempts:  1,
+				startedAt: time.Now(),
+			},
+			wants: wants{
+				retry:      true,
+				minBackoff: 5 * time.Millisecond,
+				maxBackoff: 10 * time.Millisecond,
+			},
+		},
+		{
+			name: "max attempts reached",
+			args: args{
+				opts: []db_mongo.TransactionManagerOption{
+					db_mongo.WithRetryMaxAttempts(3),
+				},
+				err:       transientError,
+				attempts:  3,
+				startedAt: time.Now(),
+			},
+			wants: wants{
+				retry: false,
+			},
+		},
+		{
+			name: "retry timeout expired",
+			args: args{
+				opts: []db_mongo.TransactionManagerOption{
+					db_mongo.WithRetryMaxAttempts(3),
+				},
+				err:       transientError,
+				attempts:  1,
+				startedAt: time.Now().Add(-2 * time.Minute),
+			},
+			wants: wants{
+				retry: false,
+			},
+		},
+		{
+			name: "not transient error",
+			args: args{
+				opts: []db_mongo.TransactionManagerOption{
+					db_mongo.WithRetryMaxAttempts(3),
+				},
+				err:       errors.New("error"),
+				attempts:  1,
+				startedAt: time.Now(),
+			},
+			wants: wants{
+				retry: false,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transactionManager := db_mongo.NewTransactionManager(nil, nil, nil, tt.args.opts...)
+			transactionRetrier, ok := transactionManager.(db.TransactionRetrier)
+			if assert.True(t, ok) {
+				backoff, retry := transactionRetrier.RetryTransaction(tt.args.err, tt.args.attempts, tt.args.startedAt)
+				assert.Equal(t, tt.wants.retry, retry)
+				assert.GreaterOrEqual(t, backoff, tt.wants.minBackoff)
+				assert.LessOrEqual(t, backoff, tt.wants.maxBackoff)
+			}
+		})
+	}
+}
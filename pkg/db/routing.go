@@ -0,0 +1,39 @@
+package db
+
+import "context"
+
+type routingKey struct{}
+
+type routing int
+
+const (
+	routeReplica routing = iota + 1
+	routePrimary
+)
+
+func getRouting(ctx context.Context) routing {
+	routing, _ := ctx.Value(routingKey{}).(routing)
+	return routing
+}
+
+// ReadFromReplica has no effect on a context passed to ReadFromPrimary.
+func ReadFromReplica(ctx context.Context) context.Context {
+	if getRouting(ctx) != 0 {
+		return ctx
+	}
+
+	return context.WithValue(ctx, routingKey{}, routeReplica)
+}
+
+// ReadFromPrimary routes every read of the context to the primary.
+func ReadFromPrimary(ctx context.Context) context.Context {
+	if getRouting(ctx) == routePrimary {
+		return ctx
+	}
+
+	return context.WithValue(ctx, routingKey{}, routePrimary)
+}
+
+func IsReplicaRead(ctx context.Context) bool {
+	return getRouting(ctx) == routeReplica
+}
@@ -0,0 +1,51 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+)
+
+func TestIsReplicaRead(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want bool
+	}{
+		{
+			name: "not routed",
+			ctx:  context.Background(),
+			want: false,
+		},
+		{
+			name: "read from replica",
+			ctx:  db.ReadFromReplica(context.Background()),
+			want: true,
+		},
+		{
+			name: "read from primary",
+			ctx:  db.ReadFromPrimary(context.Background()),
+			want: false,
+		},
+		{
+			name: "read from primary takes precedence",
+			ctx:  db.ReadFromReplica(db.ReadFromPrimary(context.Background())),
+			want: false,
+		},
+		{
+			name: "read from primary overrides replica",
+			ctx:  db.ReadFromPrimary(db.ReadFromReplica(context.Background())),
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, db.IsReplicaRead(tt.ctx))
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+)
+
+func newCommitter(tx *sql.Tx) db.Committer {
+	return &committer{
+		tx: tx,
+	}
+}
+
+type committer struct {
+	tx *sql.Tx
+}
+
+func (c committer) CommitTransaction(ctx context.Context) error {
+	return c.tx.Commit()
+}
+
+func (c committer) RollbackTransaction(ctx context.Context) error {
+	return c.tx.Rollback()
+}
+
+func newSavepointCommitter(tx *sql.Tx, name string) db.Committer {
+	return &savepointCommitter{
+		tx:   tx,
+		name: name,
+	}
+}
+
+type savepointCommitter struct {
+	tx   *sql.Tx
+	name string
+}
+
+func (s savepointCommitter) CommitTransaction(ctx context.Context) error {
+	_, err := s.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+s.name)
+	return err
+}
+
+func (s savepointCommitter) RollbackTransaction(ctx context.Context) error {
+	_, err := s.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+s.name)
+	return err
+}
@@ -0,0 +1,28 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+)
+
+type transactionKey struct{}
+
+func withTransaction(ctx context.Context, transaction *sql.Tx) context.Context {
+	return context.WithValue(ctx, transactionKey{}, transaction)
+}
+
+func getTransaction(ctx context.Context) (*sql.Tx, bool) {
+	transaction, ok := ctx.Value(transactionKey{}).(*sql.Tx)
+	return transaction, ok && transaction != nil
+}
+
+type savepointKey struct{}
+
+func withSavepoint(ctx context.Context, savepoint int) context.Context {
+	return context.WithValue(ctx, savepointKey{}, savepoint)
+}
+
+func getSavepoint(ctx context.Context) int {
+	savepoint, _ := ctx.Value(savepointKey{}).(int)
+	return savepoint
+}
@@ -0,0 +1,14 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Querier is implemented by *sql.DB and *sql.Tx, as well as by their sqlx counterparts.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
@@ -0,0 +1,111 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+)
+
+type TransactionManagerOption func(t *transactionManager)
+
+func WithReadOnlyIsolationLevel(isolationLevel sql.IsolationLevel) TransactionManagerOption {
+	return func(t *transactionManager) {
+		t.readOnlyOpts = &sql.TxOptions{
+			Isolation: isolationLevel,
+			ReadOnly:  true,
+		}
+	}
+}
+
+func NewTransactionManager(db *sql.DB, opts *sql.TxOptions, managerOpts ...TransactionManagerOption) db.TransactionManager[Querier] {
+	transactionManager := &transactionManager{
+		db:           db,
+		opts:         opts,
+		readOnlyOpts: &sql.TxOptions{ReadOnly: true},
+	}
+
+	for _, managerOpt := range managerOpts {
+		managerOpt(transactionManager)
+	}
+
+	return transactionManager
+}
+
+type transactionManager struct {
+	db           *sql.DB
+	opts         *sql.TxOptions
+	readOnlyOpts *sql.TxOptions
+}
+
+func (t transactionManager) IsTransactionStarted(ctx context.Context) bool {
+	_, ok := getTransaction(ctx)
+	return ok
+}
+
+func (t transactionManager) StartTransaction(ctx context.Context) (db.Committer, context.Context, error) {
+	return t.startTransaction(ctx, t.opts)
+}
+
+func (t transactionManager) StartReadOnlyTransaction(ctx context.Context) (db.Committer, context.Context, error) {
+	return t.startTransaction(ctx, t.readOnlyOpts)
+}
+
+// StartTransactionWithOptions starts a transaction with *sql.TxOptions replacing the options of the TransactionManager.
+func (t transactionManager) StartTransactionWithOptions(ctx context.Context, opts interface{}) (db.Committer, context.Context, error) {
+	txOptions, ok := opts.(*sql.TxOptions)
+	if !ok {
+		return nil, ctx, fmt.Errorf("%w: %T", db.ErrTransactionOptionsNotSupported, opts)
+	}
+
+	return t.startTransaction(ctx, txOptions)
+}
+
+func (t transactionManager) startTransaction(ctx context.Context, opts *sql.TxOptions) (db.Committer, context.Context, error) {
+	transaction, err := t.db.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, ctx, err
+	}
+
+	committer, ctx := db.WithHooks(ctx, newCommitter(transaction))
+	ctx = withTransaction(ctx, transaction)
+
+	return committer, ctx, nil
+}
+
+func (t transactionManager) StartNestedTransaction(ctx context.Context) (db.Committer, context.Context, error) {
+	transaction, ok := getTransaction(ctx)
+	if !ok {
+		return t.StartTransaction(ctx)
+	}
+
+	savepoint := getSavepoint(ctx) + 1
+	name := fmt.Sprintf("sp%d", savepoint)
+
+	if _, err := transaction.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return nil, ctx, err
+	}
+
+	committer, ctx := db.WithNestedHooks(ctx, newSavepointCommitter(transaction, name))
+	ctx = withSavepoint(ctx, savepoint)
+
+	return committer, ctx, nil
+}
+
+func (t transactionManager) SuspendTransaction(ctx context.Context) context.Context {
+	ctx = db.WithoutHooks(ctx)
+	ctx = withTransaction(ctx, nil)
+	ctx = withSavepoint(ctx, 0)
+
+	return ctx
+}
+
+func (t transactionManager) GetTransaction(ctx context.Context) Querier {
+	transaction, ok := getTransaction(ctx)
+	if !ok {
+		return t.db
+	}
+
+	return transaction
+}
@@ -0,0 +1,218 @@
+package sql_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vulpes-ferrilata/cqrs/internal/testdb"
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+	db_sql "github.com/vulpes-ferrilata/cqrs/pkg/db/sql"
+)
+
+const notesTable = "CREATE TABLE notes (id INTEGER PRIMARY KEY, text TEXT)"
+
+func Test_transactionManager_StartNestedTransaction(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		rollbackNested bool
+		rollbackInner  bool
+		rollback       bool
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantNotes []string
+	}{
+		{
+			name: "commit all",
+			args: args{
+				rollbackNested: false,
+				rollbackInner:  false,
+				rollback:       false,
+			},
+			wantNotes: []string{"outer", "nested", "inner", "after nested"},
+		},
+		{
+			name: "rollback innermost savepoint",
+			args: args{
+				rollbackNested: false,
+				rollbackInner:  true,
+				rollback:       false,
+			},
+			wantNotes: []string{"outer", "nested", "after nested"},
+		},
+		{
+			name: "rollback savepoint",
+			args: args{
+				rollbackNested: true,
+				rollbackInner:  false,
+				rollback:       false,
+			},
+			wantNotes: []string{"outer", "after nested"},
+		},
+		{
+			name: "rollback transaction",
+			args: args{
+				rollbackNested: false,
+				rollbackInner:  false,
+				rollback:       true,
+			},
+			wantNotes: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlDB := testdb.NewSQL(t, notesTable)
+
+			transactionManager := db_sql.NewTransactionManager(sqlDB, &sql.TxOptions{Isolation: sql.LevelSerializable})
+			nestedTransactionManager, ok := transactionManager.(db.NestedTransactionManager)
+			assert.True(t, ok)
+
+			create := func(ctx context.Context, text string) {
+				_, err := transactionManager.GetTransaction(ctx).ExecContext(ctx, "INSERT INTO notes (text) VALUES (?)", text)
+				assert.NoError(t, err)
+			}
+
+			// without a transaction a nested transaction starts one.
+			committer, ctx, err := nestedTransactionManager.StartNestedTransaction(context.Background())
+			assert.NoError(t, err)
+			create(ctx, "outer")
+
+			nestedCommitter, nestedCtx, err := nestedTransactionManager.StartNestedTransaction(ctx)
+			assert.NoError(t, err)
+			create(nestedCtx, "nested")
+
+			innerCommitter, innerCtx, err := nestedTransactionManager.StartNestedTransaction(nestedCtx)
+			assert.NoError(t, err)
+			create(innerCtx, "inner")
+
+			if tt.args.rollbackInner {
+				err = innerCommitter.RollbackTransaction(innerCtx)
+			} else {
+				err = innerCommitter.CommitTransaction(innerCtx)
+			}
+			assert.NoError(t, err)
+
+			if tt.args.rollbackNested {
+				err = nestedCommitter.RollbackTransaction(nestedCtx)
+			} else {
+				err = nestedCommitter.CommitTransaction(nestedCtx)
+			}
+			assert.NoError(t, err)
+
+			// the transaction stays usable after a savepoint is rolled back.
+			create(ctx, "after nested")
+
+			if tt.args.rollback {
+				err = committer.RollbackTransaction(ctx)
+			} else {
+				err = committer.CommitTransaction(ctx)
+			}
+			assert.NoError(t, err)
+
+			rows, err := sqlDB.Query("SELECT text FROM notes ORDER BY id")
+			assert.NoError(t, err)
+			defer rows.Close()
+
+			texts := make([]string, 0)
+			for rows.Next() {
+				var text string
+				err = rows.Scan(&text)
+				assert.NoError(t, err)
+				texts = append(texts, text)
+			}
+			assert.NoError(t, rows.Err())
+			assert.Equal(t, tt.wantNotes, texts)
+		})
+	}
+}
+
+func Test_transactionManager_StartTransaction(t *testing.T) {
+	t.Parallel()
+
+	sqlDB := testdb.NewSQL(t, notesTable)
+	err := sqlDB.Close()
+	assert.NoError(t, err)
+
+	transactionManager := db_sql.NewTransactionManager(sqlDB, nil)
+	_, ctx, err := transactionManager.StartTransaction(context.Background())
+	assert.Error(t, err)
+	assert.False(t, transactionManager.IsTransactionStarted(ctx))
+}
+
+func Test_transactionManager_SuspendTransaction(t *testing.T) {
+	t.Parallel()
+
+	sqlDB := testdb.NewSQL(t, notesTable)
+
+	transactionManager := db_sql.NewTransactionManager(sqlDB, nil)
+	committer, ctx, err := transactionManager.StartTransaction(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, transactionManager.IsTransactionStarted(ctx))
+	assert.IsType(t, &sql.Tx{}, transactionManager.GetTransaction(ctx))
+
+	transactionSuspender, ok := transactionManager.(db.TransactionSuspender)
+	if assert.True(t, ok) {
+		suspendedCtx := transactionSuspender.SuspendTransaction(ctx)
+		assert.False(t, transactionManager.IsTransactionStarted(suspendedCtx))
+		assert.False(t, db.InTransaction(suspendedCtx))
+		assert.Equal(t, sqlDB, transactionManager.GetTransaction(suspendedCtx))
+	}
+
+	err = committer.RollbackTransaction(ctx)
+	assert.NoError(t, err)
+}
+
+func Test_transactionManager_StartReadOnlyTransaction(t *testing.T) {
+	t.Parallel()
+
+	sqlDB := testdb.NewSQL(t, notesTable)
+
+	transactionManager := db_sql.NewTransactionManager(sqlDB, nil,
+		db_sql.WithReadOnlyIsolationLevel(sql.LevelSerializable),
+	)
+	readOnlyTransactionManager, ok := transactionManager.(db.ReadOnlyTransactionManager)
+	if !assert.True(t, ok) {
+		return
+	}
+
+	committer, ctx, err := readOnlyTransactionManager.StartReadOnlyTransaction(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, transactionManager.IsTransactionStarted(ctx))
+	assert.True(t, db.InTransaction(ctx))
+
+	var notes int
+	err = transactionManager.GetTransaction(ctx).QueryRowContext(ctx, "SELECT COUNT(*) FROM notes").Scan(&notes)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, notes)
+
+	err = committer.CommitTransaction(ctx)
+	assert.NoError(t, err)
+	assert.False(t, db.InTransaction(ctx))
+}
+
+func Test_transactionManager_StartTransactionWithOptions(t *testing.T) {
+	t.Parallel()
+
+	sqlDB := testdb.NewSQL(t, notesTable)
+
+	transactionManager := db_sql.NewTransactionManager(sqlDB, nil)
+	configurableTransactionManager, ok := transactionManager.(db.ConfigurableTransactionManager)
+	if !assert.True(t, ok) {
+		return
+	}
+
+	_, _, err := configurableTransactionManager.StartTransactionWithOptions(context.Background(), sql.LevelSerializable)
+	assert.ErrorIs(t, err, db.ErrTransactionOptionsNotSupported)
+
+	committer, ctx, err := configurableTransactionManager.StartTransactionWithOptions(context.Background(), &sql.TxOptions{Isolation: sql.LevelSerializable})
+	assert.NoError(t, err)
+	assert.True(t, transactionManager.IsTransactionStarted(ctx))
+
+	err = committer.CommitTransaction(ctx)
+	assert.NoError(t, err)
+}
@@ -1,10 +1,40 @@
 package db
 
 //go:generate mockgen -destination=./mocks/mock_$GOFILE -source=$GOFILE -package=mock_$GOPACKAGE
-import "context"
+import (
+	"context"
+	"time"
+)
 
+// TransactionManager implementations must install transaction hooks with WithHooks in StartTransaction.
 type TransactionManager[DB any] interface {
 	IsTransactionStarted(ctx context.Context) bool
 	StartTransaction(ctx context.Context) (Committer, context.Context, error)
 	GetTransaction(ctx context.Context) DB
 }
+
+// TransactionSuspender returns a context without the transaction nor its hooks.
+type TransactionSuspender interface {
+	SuspendTransaction(ctx context.Context) context.Context
+}
+
+// NestedTransactionManager implementations must install transaction hooks with WithNestedHooks.
+type NestedTransactionManager interface {
+	StartNestedTransaction(ctx context.Context) (Committer, context.Context, error)
+}
+
+// TransactionRetrier returns the backoff before re-executing a transaction after the given attempts.
+type TransactionRetrier interface {
+	RetryTransaction(err error, attempts int, startedAt time.Time) (time.Duration, bool)
+}
+
+// ReadOnlyTransactionManager implementations must install transaction hooks with WithHooks.
+type ReadOnlyTransactionManager interface {
+	StartReadOnlyTransaction(ctx context.Context) (Committer, context.Context, error)
+}
+
+// ConfigurableTransactionManager implementations must document the options type they accept, return
+// ErrTransactionOptionsNotSupported for any other and install transaction hooks with WithHooks.
+type ConfigurableTransactionManager interface {
+	StartTransactionWithOptions(ctx context.Context, opts interface{}) (Committer, context.Context, error)
+}
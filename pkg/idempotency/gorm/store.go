@@ -0,0 +1,65 @@
+package gorm
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/vulpes-ferrilata/cqrs/internal/gormerrors"
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+	"github.com/vulpes-ferrilata/cqrs/pkg/idempotency"
+)
+
+type IdempotencyRecord struct {
+	MessageType string `gorm:"primaryKey;size:255"`
+	Key         string `gorm:"primaryKey;size:255"`
+	Fingerprint string `gorm:"size:64"`
+	CreatedAt   time.Time
+}
+
+func NewStore(transactionManager db.TransactionManager[*gorm.DB]) idempotency.Store {
+	return &store{
+		transactionManager: transactionManager,
+	}
+}
+
+type store struct {
+	transactionManager db.TransactionManager[*gorm.DB]
+}
+
+func (s store) Find(ctx context.Context, messageType string, key string) (idempotency.Record, bool, error) {
+	record := IdempotencyRecord{}
+
+	err := s.transactionManager.GetTransaction(ctx).
+		Where(&IdempotencyRecord{MessageType: messageType, Key: key}).
+		Take(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return idempotency.Record{}, false, nil
+	}
+	if err != nil {
+		return idempotency.Record{}, false, err
+	}
+
+	return idempotency.Record{
+		MessageType: record.MessageType,
+		Key:         record.Key,
+		Fingerprint: record.Fingerprint,
+		CreatedAt:   record.CreatedAt,
+	}, true, nil
+}
+
+func (s store) Save(ctx context.Context, record idempotency.Record) error {
+	err := s.transactionManager.GetTransaction(ctx).Create(&IdempotencyRecord{
+		MessageType: record.MessageType,
+		Key:         record.Key,
+		Fingerprint: record.Fingerprint,
+		CreatedAt:   record.CreatedAt,
+	}).Error
+	if gormerrors.IsDuplicatedKey(err) {
+		return idempotency.ErrRecordAlreadyExists
+	}
+
+	return err
+}
@@ -0,0 +1,61 @@
+package gorm_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vulpes-ferrilata/cqrs/internal/testdb"
+	db_gorm "github.com/vulpes-ferrilata/cqrs/pkg/db/gorm"
+	"github.com/vulpes-ferrilata/cqrs/pkg/idempotency"
+	idempotency_gorm "github.com/vulpes-ferrilata/cqrs/pkg/idempotency/gorm"
+)
+
+func Test_store(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx    = context.Background()
+		record = idempotency.Record{
+			MessageType: "command",
+			Key:         "key",
+			Fingerprint: "fingerprint",
+			CreatedAt:   time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+	)
+
+	db := testdb.NewGorm(t, &idempotency_gorm.IdempotencyRecord{})
+
+	transactionManager := db_gorm.NewTransactionManager(db, nil)
+	store := idempotency_gorm.NewStore(transactionManager)
+
+	// a record saved in a rolled back transaction is discarded together with the transaction.
+	committer, txCtx, err := transactionManager.StartTransaction(ctx)
+	assert.NoError(t, err)
+	err = store.Save(txCtx, record)
+	assert.NoError(t, err)
+	err = committer.RollbackTransaction(txCtx)
+	assert.NoError(t, err)
+
+	_, ok, err := store.Find(ctx, record.MessageType, record.Key)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	committer, txCtx, err = transactionManager.StartTransaction(ctx)
+	assert.NoError(t, err)
+	err = store.Save(txCtx, record)
+	assert.NoError(t, err)
+	err = committer.CommitTransaction(txCtx)
+	assert.NoError(t, err)
+
+	got, ok, err := store.Find(ctx, record.MessageType, record.Key)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, record.Fingerprint, got.Fingerprint)
+	assert.True(t, record.CreatedAt.Equal(got.CreatedAt))
+
+	err = store.Save(ctx, record)
+	assert.ErrorIs(t, err, idempotency.ErrRecordAlreadyExists)
+}
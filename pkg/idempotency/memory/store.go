@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+	db_memory "github.com/vulpes-ferrilata/cqrs/pkg/db/memory"
+	"github.com/vulpes-ferrilata/cqrs/pkg/idempotency"
+)
+
+const collection = "idempotency_records"
+
+func NewStore(transactionManager db.TransactionManager[*db_memory.Tx]) idempotency.Store {
+	return &store{
+		transactionManager: transactionManager,
+	}
+}
+
+type store struct {
+	transactionManager db.TransactionManager[*db_memory.Tx]
+}
+
+func recordKey(messageType string, key string) string {
+	return messageType + "\x00" + key
+}
+
+func (s store) Find(ctx context.Context, messageType string, key string) (idempotency.Record, bool, error) {
+	document, ok, err := s.transactionManager.GetTransaction(ctx).Get(collection, recordKey(messageType, key))
+	if err != nil || !ok {
+		return idempotency.Record{}, false, err
+	}
+
+	return document.(idempotency.Record), true, nil
+}
+
+func (s store) Save(ctx context.Context, record idempotency.Record) error {
+	transaction := s.transactionManager.GetTransaction(ctx)
+	recordKey := recordKey(record.MessageType, record.Key)
+
+	_, ok, err := transaction.Get(collection, recordKey)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return idempotency.ErrRecordAlreadyExists
+	}
+
+	return transaction.Put(collection, recordKey, record)
+}
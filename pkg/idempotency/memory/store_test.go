@@ -0,0 +1,96 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	db_memory "github.com/vulpes-ferrilata/cqrs/pkg/db/memory"
+	"github.com/vulpes-ferrilata/cqrs/pkg/idempotency"
+	"github.com/vulpes-ferrilata/cqrs/pkg/idempotency/memory"
+)
+
+func Test_store(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx    = context.Background()
+		record = idempotency.Record{
+			MessageType: "command",
+			Key:         "key",
+			Fingerprint: "fingerprint",
+			CreatedAt:   time.Now(),
+		}
+	)
+
+	transactionManager := db_memory.NewTransactionManager(db_memory.NewStore())
+	store := memory.NewStore(transactionManager)
+
+	committer, txCtx, err := transactionManager.StartTransaction(ctx)
+	assert.NoError(t, err)
+	err = store.Save(txCtx, record)
+	assert.NoError(t, err)
+	err = store.Save(txCtx, record)
+	assert.ErrorIs(t, err, idempotency.ErrRecordAlreadyExists)
+	err = committer.RollbackTransaction(txCtx)
+	assert.NoError(t, err)
+
+	// the record is discarded with the transaction.
+	_, ok, err := store.Find(ctx, record.MessageType, record.Key)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	committer, txCtx, err = transactionManager.StartTransaction(ctx)
+	assert.NoError(t, err)
+	err = store.Save(txCtx, record)
+	assert.NoError(t, err)
+	err = committer.CommitTransaction(txCtx)
+	assert.NoError(t, err)
+
+	got, ok, err := store.Find(ctx, record.MessageType, record.Key)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, record, got)
+
+	_, ok, err = store.Find(ctx, "other command", record.Key)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	err = store.Save(ctx, record)
+	assert.ErrorIs(t, err, idempotency.ErrRecordAlreadyExists)
+}
+
+func Test_store_ConcurrentSave(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx    = context.Background()
+		record = idempotency.Record{
+			MessageType: "command",
+			Key:         "key",
+			Fingerprint: "fingerprint",
+			CreatedAt:   time.Now(),
+		}
+	)
+
+	transactionManager := db_memory.NewTransactionManager(db_memory.NewStore())
+	store := memory.NewStore(transactionManager)
+
+	committer, txCtx, err := transactionManager.StartTransaction(ctx)
+	assert.NoError(t, err)
+	concurrentCommitter, concurrentCtx, err := transactionManager.StartTransaction(ctx)
+	assert.NoError(t, err)
+
+	err = store.Save(txCtx, record)
+	assert.NoError(t, err)
+	err = store.Save(concurrentCtx, record)
+	assert.NoError(t, err)
+
+	// the command is executed once, by the first transaction to commit.
+	err = committer.CommitTransaction(txCtx)
+	assert.NoError(t, err)
+	err = concurrentCommitter.CommitTransaction(concurrentCtx)
+	assert.ErrorIs(t, err, db_memory.ErrWriteConflict)
+}
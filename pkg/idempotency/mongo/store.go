@@ -0,0 +1,76 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+	"github.com/vulpes-ferrilata/cqrs/pkg/idempotency"
+)
+
+const collectionName = "idempotency_records"
+
+type recordID struct {
+	MessageType string `bson:"message_type"`
+	Key         string `bson:"key"`
+}
+
+type record struct {
+	ID          recordID  `bson:"_id"`
+	Fingerprint string    `bson:"fingerprint"`
+	CreatedAt   time.Time `bson:"created_at"`
+}
+
+func NewStore(transactionManager db.TransactionManager[*mongo.Database]) idempotency.Store {
+	return &store{
+		transactionManager: transactionManager,
+	}
+}
+
+type store struct {
+	transactionManager db.TransactionManager[*mongo.Database]
+}
+
+func (s store) collection(ctx context.Context) *mongo.Collection {
+	return s.transactionManager.GetTransaction(ctx).Collection(collectionName)
+}
+
+func (s store) Find(ctx context.Context, messageType string, key string) (idempotency.Record, bool, error) {
+	document := record{}
+
+	filter := bson.M{"_id": recordID{MessageType: messageType, Key: key}}
+	err := s.collection(ctx).FindOne(ctx, filter).Decode(&document)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return idempotency.Record{}, false, nil
+	}
+	if err != nil {
+		return idempotency.Record{}, false, err
+	}
+
+	return idempotency.Record{
+		MessageType: document.ID.MessageType,
+		Key:         document.ID.Key,
+		Fingerprint: document.Fingerprint,
+		CreatedAt:   document.CreatedAt,
+	}, true, nil
+}
+
+func (s store) Save(ctx context.Context, idempotencyRecord idempotency.Record) error {
+	_, err := s.collection(ctx).InsertOne(ctx, record{
+		ID: recordID{
+			MessageType: idempotencyRecord.MessageType,
+			Key:         idempotencyRecord.Key,
+		},
+		Fingerprint: idempotencyRecord.Fingerprint,
+		CreatedAt:   idempotencyRecord.CreatedAt,
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return idempotency.ErrRecordAlreadyExists
+	}
+
+	return err
+}
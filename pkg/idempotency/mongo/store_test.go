@@ -0,0 +1,144 @@
+package mongo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+
+	db_mongo "github.com/vulpes-ferrilata/cqrs/pkg/db/mongo"
+	"github.com/vulpes-ferrilata/cqrs/pkg/idempotency"
+	idempotency_mongo "github.com/vulpes-ferrilata/cqrs/pkg/idempotency/mongo"
+)
+
+func Test_store_Find(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx       = context.Background()
+		createdAt = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	)
+
+	type wants struct {
+		record idempotency.Record
+		ok     bool
+		err    bool
+	}
+	tests := []struct {
+		name      string
+		responses []bson.D
+		wants     wants
+	}{
+		{
+			name: "record not found",
+			responses: []bson.D{
+				mtest.CreateCursorResponse(0, "db.idempotency_records", mtest.FirstBatch),
+			},
+			wants: wants{
+				record: idempotency.Record{},
+				ok:     false,
+				err:    false,
+			},
+		},
+		{
+			name: "find fail",
+			responses: []bson.D{
+				mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 1, Message: "error"}),
+			},
+			wants: wants{
+				record: idempotency.Record{},
+				ok:     false,
+				err:    true,
+			},
+		},
+		{
+			name: "record found",
+			responses: []bson.D{
+				mtest.CreateCursorResponse(0, "db.idempotency_records", mtest.FirstBatch, bson.D{
+					{Key: "_id", Value: bson.D{
+						{Key: "message_type", Value: "command"},
+						{Key: "key", Value: "key"},
+					}},
+					{Key: "fingerprint", Value: "fingerprint"},
+					{Key: "created_at", Value: createdAt},
+				}),
+			},
+			wants: wants{
+				record: idempotency.Record{
+					MessageType: "command",
+					Key:         "key",
+					Fingerprint: "fingerprint",
+					CreatedAt:   createdAt,
+				},
+				ok:  true,
+				err: false,
+			},
+		},
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			mt.AddMockResponses(tt.responses...)
+
+			store := idempotency_mongo.NewStore(db_mongo.NewTransactionManager(mt.DB, nil, nil))
+			record, ok, err := store.Find(ctx, "command", "key")
+			assert.Equal(mt, tt.wants.err, err != nil)
+			assert.Equal(mt, tt.wants.ok, ok)
+			assert.Equal(mt, tt.wants.record, record)
+		})
+	}
+}
+
+func Test_store_Save(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx    = context.Background()
+		record = idempotency.Record{
+			MessageType: "command",
+			Key:         "key",
+			Fingerprint: "fingerprint",
+			CreatedAt:   time.Now(),
+		}
+	)
+
+	tests := []struct {
+		name      string
+		responses []bson.D
+		wantErr   error
+	}{
+		{
+			name: "duplicated record",
+			responses: []bson.D{
+				mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "duplicate key error"}),
+			},
+			wantErr: idempotency.ErrRecordAlreadyExists,
+		},
+		{
+			name: "success",
+			responses: []bson.D{
+				mtest.CreateSuccessResponse(),
+			},
+			wantErr: nil,
+		},
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			mt.AddMockResponses(tt.responses...)
+
+			store := idempotency_mongo.NewStore(db_mongo.NewTransactionManager(mt.DB, nil, nil))
+			err := store.Save(ctx, record)
+			assert.ErrorIs(mt, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package idempotency
+
+//go:generate mockgen -destination=./mocks/mock_$GOFILE -source=$GOFILE -package=mock_$GOPACKAGE
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrRecordAlreadyExists = errors.New("idempotency record already exists")
+
+type Record struct {
+	MessageType string
+	Key         string
+	Fingerprint string
+	CreatedAt   time.Time
+}
+
+// Store implementations must save records through the transaction of the context.
+type Store interface {
+	Find(ctx context.Context, messageType string, key string) (Record, bool, error)
+	Save(ctx context.Context, record Record) error
+}
@@ -0,0 +1,64 @@
+package inbox
+
+import (
+	"context"
+	"time"
+)
+
+type CleanerOption func(c *Cleaner)
+
+func WithCleanerInterval(interval time.Duration) CleanerOption {
+	return func(c *Cleaner) {
+		c.interval = interval
+	}
+}
+
+func WithCleanerErrorHandler(errorHandler func(err error)) CleanerOption {
+	return func(c *Cleaner) {
+		c.errorHandler = errorHandler
+	}
+}
+
+func NewCleaner(store Store, retention time.Duration, opts ...CleanerOption) *Cleaner {
+	cleaner := &Cleaner{
+		store:        store,
+		retention:    retention,
+		interval:     time.Hour,
+		errorHandler: func(err error) {},
+	}
+
+	for _, opt := range opts {
+		opt(cleaner)
+	}
+
+	return cleaner
+}
+
+// Cleaner retention must exceed the longest period an event can be redelivered.
+type Cleaner struct {
+	store        Store
+	retention    time.Duration
+	interval     time.Duration
+	errorHandler func(err error)
+}
+
+func (c Cleaner) Run(ctx context.Context) error {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := c.Clean(ctx); err != nil {
+			c.errorHandler(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c Cleaner) Clean(ctx context.Context) (int64, error) {
+	return c.store.DeleteBefore(ctx, time.Now().Add(-c.retention))
+}
@@ -0,0 +1,119 @@
+package inbox_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/inbox"
+	mock_inbox "github.com/vulpes-ferrilata/cqrs/pkg/inbox/mocks"
+)
+
+type beforeMatcher struct {
+	from time.Time
+	to   time.Time
+}
+
+func (b beforeMatcher) Matches(x interface{}) bool {
+	before, ok := x.(time.Time)
+	return ok && !before.Before(b.from) && !before.After(b.to)
+}
+
+func (b beforeMatcher) String() string {
+	return "between " + b.from.String() + " and " + b.to.String()
+}
+
+func TestCleaner_Clean(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx       = context.Background()
+		retention = time.Hour
+		Err       = errors.New("error")
+	)
+
+	type wants struct {
+		count int64
+		err   error
+	}
+	tests := []struct {
+		name   string
+		result int64
+		err    error
+		wants  wants
+	}{
+		{
+			name:   "delete fail",
+			result: 0,
+			err:    Err,
+			wants: wants{
+				count: 0,
+				err:   Err,
+			},
+		},
+		{
+			name:   "success",
+			result: 2,
+			err:    nil,
+			wants: wants{
+				count: 2,
+				err:   nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			store := mock_inbox.NewMockStore(mockCtrl)
+
+			now := time.Now()
+			store.EXPECT().DeleteBefore(ctx, beforeMatcher{
+				from: now.Add(-retention),
+				to:   now.Add(-retention).Add(time.Second),
+			}).Return(tt.result, tt.err)
+
+			cleaner := inbox.NewCleaner(store, retention)
+			count, err := cleaner.Clean(ctx)
+			assert.ErrorIs(t, err, tt.wants.err)
+			assert.Equal(t, tt.wants.count, count)
+		})
+	}
+}
+
+func TestCleaner_Run(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	Err := errors.New("error")
+
+	store := mock_inbox.NewMockStore(mockCtrl)
+	gomock.InOrder(
+		store.EXPECT().DeleteBefore(gomock.Any(), gomock.Any()).Return(int64(0), Err),
+		store.EXPECT().DeleteBefore(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, before time.Time) (int64, error) {
+			cancel()
+			return 1, nil
+		}),
+	)
+
+	errs := make([]error, 0)
+	cleaner := inbox.NewCleaner(store, time.Hour,
+		inbox.WithCleanerInterval(time.Millisecond),
+		inbox.WithCleanerErrorHandler(func(err error) {
+			errs = append(errs, err)
+		}),
+	)
+	err := cleaner.Run(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []error{Err}, errs)
+}
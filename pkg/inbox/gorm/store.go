@@ -0,0 +1,66 @@
+package gorm
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/vulpes-ferrilata/cqrs/internal/gormerrors"
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+	"github.com/vulpes-ferrilata/cqrs/pkg/inbox"
+)
+
+type InboxRecord struct {
+	Handler     string    `gorm:"primaryKey;size:255"`
+	EventID     string    `gorm:"primaryKey;size:255"`
+	ProcessedAt time.Time `gorm:"index"`
+}
+
+func NewStore(transactionManager db.TransactionManager[*gorm.DB]) inbox.Store {
+	return &store{
+		transactionManager: transactionManager,
+	}
+}
+
+type store struct {
+	transactionManager db.TransactionManager[*gorm.DB]
+}
+
+func (s store) Exists(ctx context.Context, handler string, eventID string) (bool, error) {
+	var count int64
+
+	err := s.transactionManager.GetTransaction(ctx).
+		Model(&InboxRecord{}).
+		Where(&InboxRecord{Handler: handler, EventID: eventID}).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (s store) Save(ctx context.Context, handler string, eventID string, processedAt time.Time) error {
+	err := s.transactionManager.GetTransaction(ctx).Create(&InboxRecord{
+		Handler:     handler,
+		EventID:     eventID,
+		ProcessedAt: processedAt,
+	}).Error
+	if gormerrors.IsDuplicatedKey(err) {
+		return inbox.ErrRecordAlreadyExists
+	}
+
+	return err
+}
+
+func (s store) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := s.transactionManager.GetTransaction(ctx).
+		Where("processed_at < ?", before).
+		Delete(&InboxRecord{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,65 @@
+package gorm_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vulpes-ferrilata/cqrs/internal/testdb"
+	db_gorm "github.com/vulpes-ferrilata/cqrs/pkg/db/gorm"
+	"github.com/vulpes-ferrilata/cqrs/pkg/inbox"
+	inbox_gorm "github.com/vulpes-ferrilata/cqrs/pkg/inbox/gorm"
+)
+
+func Test_store(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+		now = time.Now()
+	)
+
+	db := testdb.NewGorm(t, &inbox_gorm.InboxRecord{})
+
+	transactionManager := db_gorm.NewTransactionManager(db, nil)
+	store := inbox_gorm.NewStore(transactionManager)
+
+	committer, txCtx, err := transactionManager.StartTransaction(ctx)
+	assert.NoError(t, err)
+	err = store.Save(txCtx, "handler", "1", now)
+	assert.NoError(t, err)
+	err = committer.RollbackTransaction(txCtx)
+	assert.NoError(t, err)
+
+	ok, err := store.Exists(ctx, "handler", "1")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	err = store.Save(ctx, "handler", "1", now.Add(-2*time.Hour))
+	assert.NoError(t, err)
+	err = store.Save(ctx, "handler", "2", now)
+	assert.NoError(t, err)
+	err = store.Save(ctx, "other handler", "1", now)
+	assert.NoError(t, err)
+
+	ok, err = store.Exists(ctx, "handler", "1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	err = store.Save(ctx, "handler", "1", now)
+	assert.ErrorIs(t, err, inbox.ErrRecordAlreadyExists)
+
+	count, err := store.DeleteBefore(ctx, now.Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	ok, err = store.Exists(ctx, "handler", "1")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = store.Exists(ctx, "other handler", "1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
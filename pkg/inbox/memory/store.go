@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+	db_memory "github.com/vulpes-ferrilata/cqrs/pkg/db/memory"
+	"github.com/vulpes-ferrilata/cqrs/pkg/inbox"
+)
+
+const collection = "inbox_records"
+
+type inboxRecord struct {
+	handler     string
+	eventID     string
+	processedAt time.Time
+}
+
+func NewStore(transactionManager db.TransactionManager[*db_memory.Tx]) inbox.Store {
+	return &store{
+		transactionManager: transactionManager,
+	}
+}
+
+type store struct {
+	transactionManager db.TransactionManager[*db_memory.Tx]
+}
+
+func recordKey(handler string, eventID string) string {
+	return handler + "\x00" + eventID
+}
+
+func (s store) Exists(ctx context.Context, handler string, eventID string) (bool, error) {
+	_, ok, err := s.transactionManager.GetTransaction(ctx).Get(collection, recordKey(handler, eventID))
+	return ok, err
+}
+
+func (s store) Save(ctx context.Context, handler string, eventID string, processedAt time.Time) error {
+	transaction := s.transactionManager.GetTransaction(ctx)
+	recordKey := recordKey(handler, eventID)
+
+	_, ok, err := transaction.Get(collection, recordKey)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return inbox.ErrRecordAlreadyExists
+	}
+
+	return transaction.Put(collection, recordKey, inboxRecord{
+		handler:     handler,
+		eventID:     eventID,
+		processedAt: processedAt,
+	})
+}
+
+func (s store) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	transaction := s.transactionManager.GetTransaction(ctx)
+
+	documents, err := transaction.Find(collection)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	for _, document := range documents {
+		record := document.(inboxRecord)
+		if !record.processedAt.Before(before) {
+			continue
+		}
+
+		if err := transaction.Delete(collection, recordKey(record.handler, record.eventID)); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
@@ -0,0 +1,87 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	db_memory "github.com/vulpes-ferrilata/cqrs/pkg/db/memory"
+	"github.com/vulpes-ferrilata/cqrs/pkg/inbox"
+	"github.com/vulpes-ferrilata/cqrs/pkg/inbox/memory"
+)
+
+func Test_store(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+		now = time.Now()
+	)
+
+	transactionManager := db_memory.NewTransactionManager(db_memory.NewStore())
+	store := memory.NewStore(transactionManager)
+
+	committer, txCtx, err := transactionManager.StartTransaction(ctx)
+	assert.NoError(t, err)
+	err = store.Save(txCtx, "handler", "1", now)
+	assert.NoError(t, err)
+	err = committer.RollbackTransaction(txCtx)
+	assert.NoError(t, err)
+
+	ok, err := store.Exists(ctx, "handler", "1")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	err = store.Save(ctx, "handler", "1", now.Add(-2*time.Hour))
+	assert.NoError(t, err)
+	err = store.Save(ctx, "handler", "2", now)
+	assert.NoError(t, err)
+	err = store.Save(ctx, "other handler", "1", now)
+	assert.NoError(t, err)
+
+	ok, err = store.Exists(ctx, "handler", "1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	err = store.Save(ctx, "handler", "1", now)
+	assert.ErrorIs(t, err, inbox.ErrRecordAlreadyExists)
+
+	count, err := store.DeleteBefore(ctx, now.Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	ok, err = store.Exists(ctx, "handler", "1")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = store.Exists(ctx, "other handler", "1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func Test_store_ConcurrentSave(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	transactionManager := db_memory.NewTransactionManager(db_memory.NewStore())
+	store := memory.NewStore(transactionManager)
+
+	committer, txCtx, err := transactionManager.StartTransaction(ctx)
+	assert.NoError(t, err)
+	concurrentCommitter, concurrentCtx, err := transactionManager.StartTransaction(ctx)
+	assert.NoError(t, err)
+
+	err = store.Save(txCtx, "handler", "1", time.Now())
+	assert.NoError(t, err)
+	err = store.Save(concurrentCtx, "handler", "1", time.Now())
+	assert.NoError(t, err)
+
+	// the event is processed once, by the first transaction to commit.
+	err = committer.CommitTransaction(txCtx)
+	assert.NoError(t, err)
+	err = concurrentCommitter.CommitTransaction(concurrentCtx)
+	assert.ErrorIs(t, err, db_memory.ErrWriteConflict)
+}
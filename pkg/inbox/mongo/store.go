@@ -0,0 +1,73 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+	"github.com/vulpes-ferrilata/cqrs/pkg/inbox"
+)
+
+const collectionName = "inbox_records"
+
+type recordID struct {
+	Handler string `bson:"handler"`
+	EventID string `bson:"event_id"`
+}
+
+type record struct {
+	ID          recordID  `bson:"_id"`
+	ProcessedAt time.Time `bson:"processed_at"`
+}
+
+func NewStore(transactionManager db.TransactionManager[*mongo.Database]) inbox.Store {
+	return &store{
+		transactionManager: transactionManager,
+	}
+}
+
+type store struct {
+	transactionManager db.TransactionManager[*mongo.Database]
+}
+
+func (s store) collection(ctx context.Context) *mongo.Collection {
+	return s.transactionManager.GetTransaction(ctx).Collection(collectionName)
+}
+
+func (s store) Exists(ctx context.Context, handler string, eventID string) (bool, error) {
+	filter := bson.M{"_id": recordID{Handler: handler, EventID: eventID}}
+
+	count, err := s.collection(ctx).CountDocuments(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (s store) Save(ctx context.Context, handler string, eventID string, processedAt time.Time) error {
+	_, err := s.collection(ctx).InsertOne(ctx, record{
+		ID: recordID{
+			Handler: handler,
+			EventID: eventID,
+		},
+		ProcessedAt: processedAt,
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return inbox.ErrRecordAlreadyExists
+	}
+
+	return err
+}
+
+func (s store) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.collection(ctx).DeleteMany(ctx, bson.M{"processed_at": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,119 @@
+package mongo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+
+	db_mongo "github.com/vulpes-ferrilata/cqrs/pkg/db/mongo"
+	"github.com/vulpes-ferrilata/cqrs/pkg/inbox"
+	inbox_mongo "github.com/vulpes-ferrilata/cqrs/pkg/inbox/mongo"
+)
+
+func Test_store_Exists(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+	)
+
+	tests := []struct {
+		name      string
+		responses []bson.D
+		wants     bool
+	}{
+		{
+			name: "not processed",
+			responses: []bson.D{
+				mtest.CreateCursorResponse(0, "db.inbox_records", mtest.FirstBatch),
+			},
+			wants: false,
+		},
+		{
+			name: "processed",
+			responses: []bson.D{
+				mtest.CreateCursorResponse(0, "db.inbox_records", mtest.FirstBatch, bson.D{{Key: "n", Value: int32(1)}}),
+			},
+			wants: true,
+		},
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			mt.AddMockResponses(tt.responses...)
+
+			store := inbox_mongo.NewStore(db_mongo.NewTransactionManager(mt.DB, nil, nil))
+			ok, err := store.Exists(ctx, "handler", "event-id")
+			assert.NoError(mt, err)
+			assert.Equal(mt, tt.wants, ok)
+		})
+	}
+}
+
+func Test_store_Save(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx = context.Background()
+	)
+
+	tests := []struct {
+		name      string
+		responses []bson.D
+		wantErr   error
+	}{
+		{
+			name: "duplicated record",
+			responses: []bson.D{
+				mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "duplicate key error"}),
+			},
+			wantErr: inbox.ErrRecordAlreadyExists,
+		},
+		{
+			name: "success",
+			responses: []bson.D{
+				mtest.CreateSuccessResponse(),
+			},
+			wantErr: nil,
+		},
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			mt.AddMockResponses(tt.responses...)
+
+			store := inbox_mongo.NewStore(db_mongo.NewTransactionManager(mt.DB, nil, nil))
+			err := store.Save(ctx, "handler", "event-id", time.Now())
+			assert.ErrorIs(mt, err, tt.wantErr)
+		})
+	}
+}
+
+func Test_store_DeleteBefore(t *testing.T) {
+	t.Parallel()
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: int32(3)}))
+
+		store := inbox_mongo.NewStore(db_mongo.NewTransactionManager(mt.DB, nil, nil))
+		count, err := store.DeleteBefore(context.Background(), time.Now())
+		assert.NoError(mt, err)
+		assert.Equal(mt, int64(3), count)
+
+		started := mt.GetStartedEvent()
+		assert.Equal(mt, "delete", started.CommandName)
+	})
+}
@@ -0,0 +1,17 @@
+package inbox
+
+//go:generate mockgen -destination=./mocks/mock_$GOFILE -source=$GOFILE -package=mock_$GOPACKAGE
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrRecordAlreadyExists = errors.New("inbox record already exists")
+
+// Store implementations must save records through the transaction of the context.
+type Store interface {
+	Exists(ctx context.Context, handler string, eventID string) (bool, error)
+	Save(ctx context.Context, handler string, eventID string, processedAt time.Time) error
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
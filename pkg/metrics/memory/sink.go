@@ -0,0 +1,229 @@
+package memory
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/metrics"
+)
+
+type Option func(s *Sink)
+
+func WithBuckets(name string, buckets []float64) Option {
+	return func(s *Sink) {
+		s.buckets[name] = buckets
+	}
+}
+
+func NewSink(opts ...Option) *Sink {
+	sink := &Sink{
+		families: make(map[string]*family),
+		buckets:  make(map[string][]float64),
+	}
+
+	for _, opt := range opts {
+		opt(sink)
+	}
+
+	return sink
+}
+
+type Sink struct {
+	mu       sync.Mutex
+	families map[string]*family
+	buckets  map[string][]float64
+}
+
+type family struct {
+	name    string
+	kind    metrics.Type
+	buckets []float64
+	series  map[string]*series
+}
+
+type series struct {
+	labels  metrics.Labels
+	value   float64
+	count   uint64
+	sum     float64
+	buckets []uint64
+}
+
+func (s *Sink) IncCounter(name string, labels metrics.Labels, delta float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.series(name, metrics.CounterType, labels).value += delta
+}
+
+func (s *Sink) AddGauge(name string, labels metrics.Labels, delta float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.series(name, metrics.GaugeType, labels).value += delta
+}
+
+func (s *Sink) ObserveHistogram(name string, labels metrics.Labels, value float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	family := s.family(name, metrics.HistogramType)
+	series := s.series(name, metrics.HistogramType, labels)
+
+	series.count++
+	series.sum += value
+
+	for i, upperBound := range family.buckets {
+		if value <= upperBound {
+			series.buckets[i]++
+		}
+	}
+}
+
+func (s *Sink) Counter(name string, labels metrics.Labels) float64 {
+	return s.get(name, labels).Value
+}
+
+func (s *Sink) Gauge(name string, labels metrics.Labels) float64 {
+	return s.get(name, labels).Value
+}
+
+func (s *Sink) Histogram(name string, labels metrics.Labels) metrics.Series {
+	return s.get(name, labels)
+}
+
+func (s *Sink) Gather() []metrics.Family {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.families))
+	for name := range s.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	families := make([]metrics.Family, 0, len(names))
+	for _, name := range names {
+		family := s.families[name]
+
+		keys := make([]string, 0, len(family.series))
+		for key := range family.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		result := metrics.Family{
+			Name:   family.name,
+			Type:   family.kind,
+			Series: make([]metrics.Series, 0, len(keys)),
+		}
+		for _, key := range keys {
+			result.Series = append(result.Series, family.series[key].snapshot(family.buckets))
+		}
+
+		families = append(families, result)
+	}
+
+	return families
+}
+
+func (s *Sink) get(name string, labels metrics.Labels) metrics.Series {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	family, ok := s.families[name]
+	if !ok {
+		return metrics.Series{}
+	}
+
+	series, ok := family.series[labelsKey(labels)]
+	if !ok {
+		return metrics.Series{}
+	}
+
+	return series.snapshot(family.buckets)
+}
+
+func (s *Sink) family(name string, kind metrics.Type) *family {
+	f, ok := s.families[name]
+	if !ok {
+		f = &family{
+			name:   name,
+			kind:   kind,
+			series: make(map[string]*series),
+		}
+
+		if kind == metrics.HistogramType {
+			f.buckets = metrics.DefaultBuckets
+			if buckets, ok := s.buckets[name]; ok {
+				f.buckets = buckets
+			}
+		}
+
+		s.families[name] = f
+	}
+
+	return f
+}
+
+func (s *Sink) series(name string, kind metrics.Type, labels metrics.Labels) *series {
+	family := s.family(name, kind)
+
+	key := labelsKey(labels)
+
+	item, ok := family.series[key]
+	if !ok {
+		copied := make(metrics.Labels, len(labels))
+		for name, value := range labels {
+			copied[name] = value
+		}
+
+		item = &series{
+			labels:  copied,
+			buckets: make([]uint64, len(family.buckets)),
+		}
+		family.series[key] = item
+	}
+
+	return item
+}
+
+func (s *series) snapshot(upperBounds []float64) metrics.Series {
+	result := metrics.Series{
+		Labels: s.labels,
+		Value:  s.value,
+		Count:  s.count,
+		Sum:    s.sum,
+	}
+
+	if len(s.buckets) > 0 {
+		result.Buckets = make([]metrics.Bucket, len(s.buckets))
+		for i, count := range s.buckets {
+			result.Buckets[i] = metrics.Bucket{
+				UpperBound: upperBounds[i],
+				Count:      count,
+			}
+		}
+	}
+
+	return result
+}
+
+func labelsKey(labels metrics.Labels) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	builder := strings.Builder{}
+	for _, name := range names {
+		builder.WriteString(name)
+		builder.WriteByte(0)
+		builder.WriteString(labels[name])
+		builder.WriteByte(0)
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,121 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/metrics"
+	"github.com/vulpes-ferrilata/cqrs/pkg/metrics/memory"
+)
+
+func TestSink_IncCounter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		prepare func(sink *memory.Sink)
+		labels  metrics.Labels
+		want    float64
+	}{
+		{
+			name:    "missing series",
+			prepare: func(sink *memory.Sink) {},
+			labels:  metrics.Labels{"outcome": "success"},
+			want:    0,
+		},
+		{
+			name: "increments accumulated",
+			prepare: func(sink *memory.Sink) {
+				sink.IncCounter("counter", metrics.Labels{"kind": "command", "outcome": "success"}, 1)
+				sink.IncCounter("counter", metrics.Labels{"outcome": "success", "kind": "command"}, 2)
+			},
+			labels: metrics.Labels{"kind": "command", "outcome": "success"},
+			want:   3,
+		},
+		{
+			name: "series separated by labels",
+			prepare: func(sink *memory.Sink) {
+				sink.IncCounter("counter", metrics.Labels{"outcome": "success"}, 1)
+				sink.IncCounter("counter", metrics.Labels{"outcome": "failure"}, 2)
+			},
+			labels: metrics.Labels{"outcome": "failure"},
+			want:   2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := memory.NewSink()
+
+			tt.prepare(sink)
+
+			assert.Equal(t, tt.want, sink.Counter("counter", tt.labels))
+		})
+	}
+}
+
+func TestSink_AddGauge(t *testing.T) {
+	t.Parallel()
+
+	sink := memory.NewSink()
+
+	sink.AddGauge("gauge", metrics.Labels{"kind": "command"}, 1)
+	sink.AddGauge("gauge", metrics.Labels{"kind": "command"}, 1)
+	sink.AddGauge("gauge", metrics.Labels{"kind": "command"}, -1)
+
+	assert.Equal(t, float64(1), sink.Gauge("gauge", metrics.Labels{"kind": "command"}))
+}
+
+func TestSink_ObserveHistogram(t *testing.T) {
+	t.Parallel()
+
+	sink := memory.NewSink(
+		memory.WithBuckets("histogram", []float64{1, 5}),
+	)
+
+	sink.ObserveHistogram("histogram", nil, 0.5)
+	sink.ObserveHistogram("histogram", nil, 3)
+	sink.ObserveHistogram("histogram", nil, 10)
+
+	assert.Equal(t, metrics.Series{
+		Labels: metrics.Labels{},
+		Count:  3,
+		Sum:    13.5,
+		Buckets: []metrics.Bucket{
+			{UpperBound: 1, Count: 1},
+			{UpperBound: 5, Count: 2},
+		},
+	}, sink.Histogram("histogram", nil))
+}
+
+func TestSink_Gather(t *testing.T) {
+	t.Parallel()
+
+	sink := memory.NewSink()
+
+	labels := metrics.Labels{"outcome": "success"}
+	sink.IncCounter("b_counter", labels, 1)
+	sink.AddGauge("a_gauge", metrics.Labels{"kind": "query"}, 2)
+	sink.AddGauge("a_gauge", metrics.Labels{"kind": "command"}, 1)
+
+	// labels are copied, later changes are not observed.
+	labels["outcome"] = "failure"
+
+	assert.Equal(t, []metrics.Family{
+		{
+			Name: "a_gauge",
+			Type: metrics.GaugeType,
+			Series: []metrics.Series{
+				{Labels: metrics.Labels{"kind": "command"}, Value: 1},
+				{Labels: metrics.Labels{"kind": "query"}, Value: 2},
+			},
+		},
+		{
+			Name: "b_counter",
+			Type: metrics.CounterType,
+			Series: []metrics.Series{
+				{Labels: metrics.Labels{"outcome": "success"}, Value: 1},
+			},
+		},
+	}, sink.Gather())
+}
@@ -0,0 +1,98 @@
+package prometheus
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/metrics"
+)
+
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+func NewHandler(gatherer metrics.Gatherer) http.Handler {
+	return &handler{
+		gatherer: gatherer,
+	}
+}
+
+type handler struct {
+	gatherer metrics.Gatherer
+}
+
+func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", contentType)
+
+	if err := Write(w, h.gatherer.Gather()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func Write(w io.Writer, families []metrics.Family) error {
+	writer := bufio.NewWriter(w)
+
+	for _, family := range families {
+		writer.WriteString("# TYPE " + family.Name + " " + string(family.Type) + "\n")
+
+		for _, series := range family.Series {
+			if family.Type != metrics.HistogramType {
+				writeSample(writer, family.Name, series.Labels, "", "", series.Value)
+				continue
+			}
+
+			for _, bucket := range series.Buckets {
+				writeSample(writer, family.Name+"_bucket", series.Labels, "le", formatFloat(bucket.UpperBound), float64(bucket.Count))
+			}
+			writeSample(writer, family.Name+"_bucket", series.Labels, "le", "+Inf", float64(series.Count))
+			writeSample(writer, family.Name+"_sum", series.Labels, "", "", series.Sum)
+			writeSample(writer, family.Name+"_count", series.Labels, "", "", float64(series.Count))
+		}
+	}
+
+	return writer.Flush()
+}
+
+func writeSample(writer *bufio.Writer, name string, labels metrics.Labels, extraName string, extraValue string, value float64) {
+	writer.WriteString(name)
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names)+1)
+	for _, name := range names {
+		pairs = append(pairs, name+`="`+escape(labels[name])+`"`)
+	}
+	if extraName != "" {
+		pairs = append(pairs, extraName+`="`+extraValue+`"`)
+	}
+
+	if len(pairs) > 0 {
+		writer.WriteString("{" + strings.Join(pairs, ",") + "}")
+	}
+
+	writer.WriteString(" " + formatFloat(value) + "\n")
+}
+
+func escape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
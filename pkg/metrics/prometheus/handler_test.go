@@ -0,0 +1,47 @@
+package prometheus_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/metrics"
+	"github.com/vulpes-ferrilata/cqrs/pkg/metrics/memory"
+	"github.com/vulpes-ferrilata/cqrs/pkg/metrics/prometheus"
+)
+
+func Test_handler_ServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	sink := memory.NewSink(memory.WithBuckets("duration_seconds", []float64{0.1, 1}))
+	sink.IncCounter("messages_total", metrics.Labels{"kind": "command", "message_type": `"quoted"`}, 2)
+	sink.AddGauge("in_flight", nil, 3)
+	sink.AddGauge("in_flight", nil, -1)
+	sink.ObserveHistogram("duration_seconds", metrics.Labels{"kind": "query"}, 0.05)
+	sink.ObserveHistogram("duration_seconds", metrics.Labels{"kind": "query"}, 0.5)
+
+	server := httptest.NewServer(prometheus.NewHandler(sink))
+	defer server.Close()
+
+	response, err := http.Get(server.URL)
+	assert.NoError(t, err)
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", response.Header.Get("Content-Type"))
+	assert.Equal(t, `# TYPE duration_seconds histogram
+duration_seconds_bucket{kind="query",le="0.1"} 1
+duration_seconds_bucket{kind="query",le="1"} 2
+duration_seconds_bucket{kind="query",le="+Inf"} 2
+duration_seconds_sum{kind="query"} 0.55
+duration_seconds_count{kind="query"} 2
+# TYPE in_flight gauge
+in_flight 2
+# TYPE messages_total counter
+messages_total{kind="command",message_type="\"quoted\""} 2
+`, string(body))
+}
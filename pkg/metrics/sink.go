@@ -0,0 +1,44 @@
+package metrics
+
+//go:generate mockgen -destination=./mocks/mock_$GOFILE -source=$GOFILE -package=mock_$GOPACKAGE
+
+type Labels map[string]string
+
+type Sink interface {
+	IncCounter(name string, labels Labels, delta float64)
+	AddGauge(name string, labels Labels, delta float64)
+	ObserveHistogram(name string, labels Labels, value float64)
+}
+
+type Type string
+
+const (
+	CounterType   Type = "counter"
+	GaugeType     Type = "gauge"
+	HistogramType Type = "histogram"
+)
+
+type Family struct {
+	Name   string
+	Type   Type
+	Series []Series
+}
+
+type Series struct {
+	Labels  Labels
+	Value   float64
+	Count   uint64
+	Sum     float64
+	Buckets []Bucket
+}
+
+type Bucket struct {
+	UpperBound float64
+	Count      uint64
+}
+
+type Gatherer interface {
+	Gather() []Family
+}
+
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
@@ -0,0 +1,136 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+)
+
+const TransactionsTotal = "cqrs_transactions_total"
+
+func NewTransactionManager[DB any](transactionManager db.TransactionManager[DB], sink Sink) db.TransactionManager[DB] {
+	metricsTransactionManager := &metricsTransactionManager[DB]{
+		transactionManager: transactionManager,
+		sink:               sink,
+	}
+
+	if _, ok := transactionManager.(db.TransactionSuspender); ok {
+		return &metricsTransactionSuspender[DB]{
+			metricsTransactionManager: metricsTransactionManager,
+		}
+	}
+
+	return metricsTransactionManager
+}
+
+type metricsTransactionManager[DB any] struct {
+	transactionManager db.TransactionManager[DB]
+	sink               Sink
+}
+
+func (m metricsTransactionManager[DB]) IsTransactionStarted(ctx context.Context) bool {
+	return m.transactionManager.IsTransactionStarted(ctx)
+}
+
+func (m metricsTransactionManager[DB]) start(ctx context.Context,
+	startTransaction func(ctx context.Context) (db.Committer, context.Context, error)) (db.Committer, context.Context, error) {
+	committer, ctx, err := startTransaction(ctx)
+	if err != nil {
+		m.sink.IncCounter(TransactionsTotal, Labels{"operation": "start", "outcome": "failure"}, 1)
+
+		return nil, ctx, err
+	}
+
+	return newCommitter(committer, m.sink), ctx, nil
+}
+
+func (m metricsTransactionManager[DB]) StartTransaction(ctx context.Context) (db.Committer, context.Context, error) {
+	return m.start(ctx, m.transactionManager.StartTransaction)
+}
+
+func (m metricsTransactionManager[DB]) StartNestedTransaction(ctx context.Context) (db.Committer, context.Context, error) {
+	nestedTransactionManager, ok := m.transactionManager.(db.NestedTransactionManager)
+	if !ok {
+		return nil, ctx, db.ErrNestedTransactionNotSupported
+	}
+
+	return m.start(ctx, nestedTransactionManager.StartNestedTransaction)
+}
+
+func (m metricsTransactionManager[DB]) StartReadOnlyTransaction(ctx context.Context) (db.Committer, context.Context, error) {
+	readOnlyTransactionManager, ok := m.transactionManager.(db.ReadOnlyTransactionManager)
+	if !ok {
+		return m.StartTransaction(ctx)
+	}
+
+	return m.start(ctx, readOnlyTransactionManager.StartReadOnlyTransaction)
+}
+
+func (m metricsTransactionManager[DB]) StartTransactionWithOptions(ctx context.Context, opts interface{}) (db.Committer, context.Context, error) {
+	configurableTransactionManager, ok := m.transactionManager.(db.ConfigurableTransactionManager)
+	if !ok {
+		return nil, ctx, db.ErrTransactionOptionsNotSupported
+	}
+
+	return m.start(ctx, func(ctx context.Context) (db.Committer, context.Context, error) {
+		return configurableTransactionManager.StartTransactionWithOptions(ctx, opts)
+	})
+}
+
+func (m metricsTransactionManager[DB]) RetryTransaction(err error, attempts int, startedAt time.Time) (time.Duration, bool) {
+	transactionRetrier, ok := m.transactionManager.(db.TransactionRetrier)
+	if !ok {
+		return 0, false
+	}
+
+	return transactionRetrier.RetryTransaction(err, attempts, startedAt)
+}
+
+func (m metricsTransactionManager[DB]) GetTransaction(ctx context.Context) DB {
+	return m.transactionManager.GetTransaction(ctx)
+}
+
+type metricsTransactionSuspender[DB any] struct {
+	*metricsTransactionManager[DB]
+}
+
+func (m metricsTransactionSuspender[DB]) SuspendTransaction(ctx context.Context) context.Context {
+	return m.transactionManager.(db.TransactionSuspender).SuspendTransaction(ctx)
+}
+
+func newCommitter(committer db.Committer, sink Sink) db.Committer {
+	return &metricsCommitter{
+		committer: committer,
+		sink:      sink,
+	}
+}
+
+type metricsCommitter struct {
+	committer db.Committer
+	sink      Sink
+}
+
+func (m metricsCommitter) CommitTransaction(ctx context.Context) error {
+	err := m.committer.CommitTransaction(ctx)
+
+	m.sink.IncCounter(TransactionsTotal, Labels{"operation": "commit", "outcome": outcome(err)}, 1)
+
+	return err
+}
+
+func (m metricsCommitter) RollbackTransaction(ctx context.Context) error {
+	err := m.committer.RollbackTransaction(ctx)
+
+	m.sink.IncCounter(TransactionsTotal, Labels{"operation": "rollback", "outcome": outcome(err)}, 1)
+
+	return err
+}
+
+func outcome(err error) string {
+	if err != nil {
+		return "failure"
+	}
+
+	return "success"
+}
@@ -0,0 +1,503 @@
+package metrics_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+
+	"github.com/vulpes-ferrilata/cqrs/internal/dbmocks"
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+	mock_db "github.com/vulpes-ferrilata/cqrs/pkg/db/mocks"
+	"github.com/vulpes-ferrilata/cqrs/pkg/metrics"
+	"github.com/vulpes-ferrilata/cqrs/pkg/metrics/memory"
+)
+
+func Test_metricsTransactionManager_StartTransaction(t *testing.T) {
+	t.Parallel()
+
+	var (
+		Err = errors.New("error")
+	)
+
+	type mocks struct {
+		transactionManager *mock_db.MockTransactionManager[*gorm.DB]
+		committer          *mock_db.MockCommitter
+	}
+	type args struct {
+		commit bool
+	}
+	type wants struct {
+		labels metrics.Labels
+		err    error
+	}
+	tests := []struct {
+		name    string
+		prepare func(mocks mocks)
+		args    args
+		wants   wants
+	}{
+		{
+			name: "start transaction fail",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().StartTransaction(gomock.Any()).Return(nil, nil, Err)
+			},
+			args: args{
+				commit: true,
+			},
+			wants: wants{
+				labels: metrics.Labels{"operation": "start", "outcome": "failure"},
+				err:    Err,
+			},
+		},
+		{
+			name: "commit transaction fail",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().StartTransaction(gomock.Any()).Return(mocks.committer, context.Background(), nil)
+				mocks.committer.EXPECT().CommitTransaction(gomock.Any()).Return(Err)
+			},
+			args: args{
+				commit: true,
+			},
+			wants: wants{
+				labels: metrics.Labels{"operation": "commit", "outcome": "failure"},
+				err:    Err,
+			},
+		},
+		{
+			name: "commit transaction success",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().StartTransaction(gomock.Any()).Return(mocks.committer, context.Background(), nil)
+				mocks.committer.EXPECT().CommitTransaction(gomock.Any()).Return(nil)
+			},
+			args: args{
+				commit: true,
+			},
+			wants: wants{
+				labels: metrics.Labels{"operation": "commit", "outcome": "success"},
+				err:    nil,
+			},
+		},
+		{
+			name: "rollback transaction success",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().StartTransaction(gomock.Any()).Return(mocks.committer, context.Background(), nil)
+				mocks.committer.EXPECT().RollbackTransaction(gomock.Any()).Return(nil)
+			},
+			args: args{
+				commit: false,
+			},
+			wants: wants{
+				labels: metrics.Labels{"operation": "rollback", "outcome": "success"},
+				err:    nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := mocks{
+				transactionManager: mock_db.NewMockTransactionManager[*gorm.DB](mockCtrl),
+				committer:          mock_db.NewMockCommitter(mockCtrl),
+			}
+
+			tt.prepare(mocks)
+
+			sink := memory.NewSink()
+			transactionManager := metrics.NewTransactionManager[*gorm.DB](mocks.transactionManager, sink)
+
+			err := func() error {
+				committer, ctx, err := transactionManager.StartTransaction(context.Background())
+				if err != nil {
+					return err
+				}
+
+				if !tt.args.commit {
+					return committer.RollbackTransaction(ctx)
+				}
+
+				return committer.CommitTransaction(ctx)
+			}()
+			assert.ErrorIs(t, err, tt.wants.err)
+			assert.Equal(t, float64(1), sink.Counter(metrics.TransactionsTotal, tt.wants.labels))
+		})
+	}
+}
+
+func Test_metricsTransactionManager_SuspendTransaction(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		supported bool
+	}
+	type wants struct {
+		supported bool
+	}
+	tests := []struct {
+		name    string
+		prepare func(mocks dbmocks.Mocks)
+		args    args
+		wants   wants
+	}{
+		{
+			name:    "suspension not supported",
+			prepare: func(mocks dbmocks.Mocks) {},
+			args: args{
+				supported: false,
+			},
+			wants: wants{
+				supported: false,
+			},
+		},
+		{
+			name: "suspension supported",
+			prepare: func(mocks dbmocks.Mocks) {
+				mocks.TransactionSuspender.EXPECT().SuspendTransaction(gomock.Any()).Return(context.TODO())
+			},
+			args: args{
+				supported: true,
+			},
+			wants: wants{
+				supported: true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := dbmocks.NewMocks(mockCtrl)
+
+			tt.prepare(mocks)
+
+			transactionManager := metrics.NewTransactionManager[*gorm.DB](mocks.Build(tt.args.supported), memory.NewSink())
+
+			transactionSuspender, ok := transactionManager.(db.TransactionSuspender)
+			assert.Equal(t, tt.wants.supported, ok)
+			if ok {
+				assert.Equal(t, context.TODO(), transactionSuspender.SuspendTransaction(context.Background()))
+			}
+		})
+	}
+}
+
+func Test_metricsTransactionManager_StartNestedTransaction(t *testing.T) {
+	t.Parallel()
+
+	var (
+		Err = errors.New("error")
+	)
+
+	type args struct {
+		supported bool
+	}
+	type wants struct {
+		labels metrics.Labels
+		err    error
+	}
+	tests := []struct {
+		name    string
+		prepare func(mocks dbmocks.Mocks)
+		args    args
+		wants   wants
+	}{
+		{
+			name:    "nested transaction not supported",
+			prepare: func(mocks dbmocks.Mocks) {},
+			args: args{
+				supported: false,
+			},
+			wants: wants{
+				labels: nil,
+				err:    db.ErrNestedTransactionNotSupported,
+			},
+		},
+		{
+			name: "start nested transaction fail",
+			prepare: func(mocks dbmocks.Mocks) {
+				mocks.NestedTransactionManager.EXPECT().StartNestedTransaction(gomock.Any()).Return(nil, nil, Err)
+			},
+			args: args{
+				supported: true,
+			},
+			wants: wants{
+				labels: metrics.Labels{"operation": "start", "outcome": "failure"},
+				err:    Err,
+			},
+		},
+		{
+			name: "commit nested transaction success",
+			prepare: func(mocks dbmocks.Mocks) {
+				mocks.NestedTransactionManager.EXPECT().StartNestedTransaction(gomock.Any()).Return(mocks.Committer, context.Background(), nil)
+				mocks.Committer.EXPECT().CommitTransaction(gomock.Any()).Return(nil)
+			},
+			args: args{
+				supported: true,
+			},
+			wants: wants{
+				labels: metrics.Labels{"operation": "commit", "outcome": "success"},
+				err:    nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := dbmocks.NewMocks(mockCtrl)
+
+			tt.prepare(mocks)
+
+			sink := memory.NewSink()
+			transactionManager := metrics.NewTransactionManager[*gorm.DB](mocks.Build(tt.args.supported), sink)
+
+			err := func() error {
+				committer, ctx, err := transactionManager.(db.NestedTransactionManager).StartNestedTransaction(context.Background())
+				if err != nil {
+					return err
+				}
+
+				return committer.CommitTransaction(ctx)
+			}()
+			assert.ErrorIs(t, err, tt.wants.err)
+			if tt.wants.labels != nil {
+				assert.Equal(t, float64(1), sink.Counter(metrics.TransactionsTotal, tt.wants.labels))
+			}
+		})
+	}
+}
+
+func Test_metricsTransactionManager_RetryTransaction(t *testing.T) {
+	t.Parallel()
+
+	var (
+		Err       = errors.New("error")
+		startedAt = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	)
+
+	type args struct {
+		supported bool
+	}
+	type wants struct {
+		backoff time.Duration
+		retry   bool
+	}
+	tests := []struct {
+		name    string
+		prepare func(mocks dbmocks.Mocks)
+		args    args
+		wants   wants
+	}{
+		{
+			name:    "retry not supported",
+			prepare: func(mocks dbmocks.Mocks) {},
+			args: args{
+				supported: false,
+			},
+			wants: wants{
+				backoff: 0,
+				retry:   false,
+			},
+		},
+		{
+			name: "retry supported",
+			prepare: func(mocks dbmocks.Mocks) {
+				mocks.TransactionRetrier.EXPECT().RetryTransaction(Err, 1, startedAt).Return(time.Second, true)
+			},
+			args: args{
+				supported: true,
+			},
+			wants: wants{
+				backoff: time.Second,
+				retry:   true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := dbmocks.NewMocks(mockCtrl)
+
+			tt.prepare(mocks)
+
+			transactionManager := metrics.NewTransactionManager[*gorm.DB](mocks.Build(tt.args.supported), memory.NewSink())
+
+			backoff, retry := transactionManager.(db.TransactionRetrier).RetryTransaction(Err, 1, startedAt)
+			assert.Equal(t, tt.wants.backoff, backoff)
+			assert.Equal(t, tt.wants.retry, retry)
+		})
+	}
+}
+
+func Test_metricsTransactionManager_StartReadOnlyTransaction(t *testing.T) {
+	t.Parallel()
+
+	var (
+		Err = errors.New("error")
+	)
+
+	type args struct {
+		supported bool
+	}
+	type wants struct {
+		labels metrics.Labels
+		err    error
+	}
+	tests := []struct {
+		name    string
+		prepare func(mocks dbmocks.Mocks)
+		args    args
+		wants   wants
+	}{
+		{
+			name: "read-only transaction not supported",
+			prepare: func(mocks dbmocks.Mocks) {
+				mocks.TransactionManager.EXPECT().StartTransaction(gomock.Any()).Return(mocks.Committer, context.Background(), nil)
+				mocks.Committer.EXPECT().CommitTransaction(gomock.Any()).Return(nil)
+			},
+			args: args{
+				supported: false,
+			},
+			wants: wants{
+				labels: metrics.Labels{"operation": "commit", "outcome": "success"},
+				err:    nil,
+			},
+		},
+		{
+			name: "start read-only transaction fail",
+			prepare: func(mocks dbmocks.Mocks) {
+				mocks.ReadOnlyTransactionManager.EXPECT().StartReadOnlyTransaction(gomock.Any()).Return(nil, nil, Err)
+			},
+			args: args{
+				supported: true,
+			},
+			wants: wants{
+				labels: metrics.Labels{"operation": "start", "outcome": "failure"},
+				err:    Err,
+			},
+		},
+		{
+			name: "commit read-only transaction success",
+			prepare: func(mocks dbmocks.Mocks) {
+				mocks.ReadOnlyTransactionManager.EXPECT().StartReadOnlyTransaction(gomock.Any()).Return(mocks.Committer, context.Background(), nil)
+				mocks.Committer.EXPECT().CommitTransaction(gomock.Any()).Return(nil)
+			},
+			args: args{
+				supported: true,
+			},
+			wants: wants{
+				labels: metrics.Labels{"operation": "commit", "outcome": "success"},
+				err:    nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := dbmocks.NewMocks(mockCtrl)
+
+			tt.prepare(mocks)
+
+			sink := memory.NewSink()
+			transactionManager := metrics.NewTransactionManager[*gorm.DB](mocks.Build(tt.args.supported), sink)
+
+			err := func() error {
+				committer, ctx, err := transactionManager.(db.ReadOnlyTransactionManager).StartReadOnlyTransaction(context.Background())
+				if err != nil {
+					return err
+				}
+
+				return committer.CommitTransaction(ctx)
+			}()
+			assert.ErrorIs(t, err, tt.wants.err)
+			assert.Equal(t, float64(1), sink.Counter(metrics.TransactionsTotal, tt.wants.labels))
+		})
+	}
+}
+
+func Test_metricsTransactionManager_StartTransactionWithOptions(t *testing.T) {
+	t.Parallel()
+
+	var (
+		opts = &sql.TxOptions{ReadOnly: true}
+	)
+
+	type args struct {
+		supported bool
+	}
+	type wants struct {
+		labels metrics.Labels
+		err    error
+	}
+	tests := []struct {
+		name    string
+		prepare func(mocks dbmocks.Mocks)
+		args    args
+		wants   wants
+	}{
+		{
+			name:    "transaction options not supported",
+			prepare: func(mocks dbmocks.Mocks) {},
+			args: args{
+				supported: false,
+			},
+			wants: wants{
+				labels: nil,
+				err:    db.ErrTransactionOptionsNotSupported,
+			},
+		},
+		{
+			name: "transaction options supported",
+			prepare: func(mocks dbmocks.Mocks) {
+				mocks.ConfigurableTransactionManager.EXPECT().StartTransactionWithOptions(gomock.Any(), opts).Return(mocks.Committer, context.Background(), nil)
+				mocks.Committer.EXPECT().CommitTransaction(gomock.Any()).Return(nil)
+			},
+			args: args{
+				supported: true,
+			},
+			wants: wants{
+				labels: metrics.Labels{"operation": "commit", "outcome": "success"},
+				err:    nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := dbmocks.NewMocks(mockCtrl)
+
+			tt.prepare(mocks)
+
+			sink := memory.NewSink()
+			transactionManager := metrics.NewTransactionManager[*gorm.DB](mocks.Build(tt.args.supported), sink)
+
+			err := func() error {
+				committer, ctx, err := transactionManager.(db.ConfigurableTransactionManager).StartTransactionWithOptions(context.Background(), opts)
+				if err != nil {
+					return err
+				}
+
+				return committer.CommitTransaction(ctx)
+			}()
+			assert.ErrorIs(t, err, tt.wants.err)
+			if tt.wants.labels != nil {
+				assert.Equal(t, float64(1), sink.Counter(metrics.TransactionsTotal, tt.wants.labels))
+			}
+		})
+	}
+}
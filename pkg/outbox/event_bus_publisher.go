@@ -0,0 +1,38 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/vulpes-ferrilata/cqrs"
+)
+
+func NewEventBusPublisher(eventBus cqrs.EventBus, codec Codec) Publisher {
+	return &eventBusPublisher{
+		eventBus: eventBus,
+		codec:    codec,
+	}
+}
+
+type eventBusPublisher struct {
+	eventBus cqrs.EventBus
+	codec    Codec
+}
+
+func (e eventBusPublisher) Publish(ctx context.Context, message Message) error {
+	event, err := e.codec.Unmarshal(message.EventType, message.Payload)
+	if err != nil {
+		return err
+	}
+
+	ctx = cqrs.WithEventID(ctx, message.ID)
+
+	if message.CorrelationID != "" {
+		ctx = cqrs.WithCorrelationID(ctx, message.CorrelationID)
+	}
+
+	if err := e.eventBus.Dispatch(ctx, []interface{}{event}); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/vulpes-ferrilata/cqrs"
+	mock_cqrs "github.com/vulpes-ferrilata/cqrs/mocks"
+	"github.com/vulpes-ferrilata/cqrs/pkg/outbox"
+)
+
+func Test_eventBusPublisher_Publish(t *testing.T) {
+	t.Parallel()
+
+	var (
+		message = outbox.Message{
+			ID:            "1",
+			EventType:     "github.com/vulpes-ferrilata/cqrs/pkg/outbox_test.OrderPlaced",
+			Payload:       []byte(`{"OrderID":"order-id"}`),
+			CorrelationID: "correlation-id",
+		}
+		Err = errors.New("error")
+	)
+
+	type mocks struct {
+		eventBus *mock_cqrs.MockEventBus
+	}
+	tests := []struct {
+		name    string
+		prepare func(mocks mocks)
+		message outbox.Message
+		wantErr error
+	}{
+		{
+			name:    "unknown event type",
+			prepare: func(mocks mocks) {},
+			message: outbox.Message{
+				EventType: "unknown",
+			},
+			wantErr: outbox.ErrEventTypeNotRegistered,
+		},
+		{
+			name: "dispatch fail",
+			prepare: func(mocks mocks) {
+				mocks.eventBus.EXPECT().Dispatch(gomock.Any(), []interface{}{OrderPlaced{OrderID: "order-id"}}).Return(Err)
+			},
+			message: message,
+			wantErr: Err,
+		},
+		{
+			name: "success",
+			prepare: func(mocks mocks) {
+				mocks.eventBus.EXPECT().Dispatch(gomock.Any(), []interface{}{OrderPlaced{OrderID: "order-id"}}).DoAndReturn(func(ctx context.Context, events []interface{}) error {
+					eventID, _ := cqrs.GetEventID(ctx)
+					assert.Equal(t, "1", eventID)
+					correlationID, _ := cqrs.GetCorrelationID(ctx)
+					assert.Equal(t, "correlation-id", correlationID)
+					return nil
+				})
+			},
+			message: message,
+			wantErr: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := mocks{
+				eventBus: mock_cqrs.NewMockEventBus(mockCtrl),
+			}
+
+			tt.prepare(mocks)
+
+			publisher := outbox.NewEventBusPublisher(mocks.eventBus, outbox.NewJSONCodec(OrderPlaced{}))
+			err := publisher.Publish(context.Background(), tt.message)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
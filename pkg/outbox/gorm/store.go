@@ -0,0 +1,86 @@
+package gorm
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+	"github.com/vulpes-ferrilata/cqrs/pkg/outbox"
+)
+
+type OutboxMessage struct {
+	ID            string `gorm:"primaryKey;size:36"`
+	EventType     string `gorm:"size:255"`
+	Payload       []byte
+	CorrelationID string `gorm:"size:255"`
+	OccurredAt    time.Time
+	SentAt        *time.Time `gorm:"index"`
+}
+
+func NewStore(transactionManager db.TransactionManager[*gorm.DB]) outbox.Store {
+	return &store{
+		transactionManager: transactionManager,
+	}
+}
+
+type store struct {
+	transactionManager db.TransactionManager[*gorm.DB]
+}
+
+func (s store) Save(ctx context.Context, messages ...outbox.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	outboxMessages := make([]OutboxMessage, 0, len(messages))
+	for _, message := range messages {
+		outboxMessages = append(outboxMessages, OutboxMessage{
+			ID:            message.ID,
+			EventType:     message.EventType,
+			Payload:       message.Payload,
+			CorrelationID: message.CorrelationID,
+			OccurredAt:    message.OccurredAt,
+		})
+	}
+
+	return s.transactionManager.GetTransaction(ctx).Create(&outboxMessages).Error
+}
+
+func (s store) FetchPending(ctx context.Context, limit int) ([]outbox.Message, error) {
+	outboxMessages := make([]OutboxMessage, 0)
+
+	err := s.transactionManager.GetTransaction(ctx).
+		Where("sent_at IS NULL").
+		Order("id").
+		Limit(limit).
+		Find(&outboxMessages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]outbox.Message, 0, len(outboxMessages))
+	for _, outboxMessage := range outboxMessages {
+		messages = append(messages, outbox.Message{
+			ID:            outboxMessage.ID,
+			EventType:     outboxMessage.EventType,
+			Payload:       outboxMessage.Payload,
+			CorrelationID: outboxMessage.CorrelationID,
+			OccurredAt:    outboxMessage.OccurredAt,
+		})
+	}
+
+	return messages, nil
+}
+
+func (s store) MarkSent(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return s.transactionManager.GetTransaction(ctx).
+		Model(&OutboxMessage{}).
+		Where("id IN ?", ids).
+		Update("sent_at", time.Now()).Error
+}
@@ -0,0 +1,78 @@
+package outbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var ErrEventTypeNotRegistered = errors.New("event type not registered")
+
+// NewJSONCodec unmarshals events into the same kind, value or pointer, as the sample events.
+func NewJSONCodec(events ...interface{}) Codec {
+	codec := &jsonCodec{
+		types: make(map[string]reflect.Type),
+	}
+
+	for _, event := range events {
+		eventType := reflect.TypeOf(event)
+		codec.types[eventTypeName(eventType)] = eventType
+	}
+
+	return codec
+}
+
+type jsonCodec struct {
+	types map[string]reflect.Type
+}
+
+func eventTypeName(eventType reflect.Type) string {
+	if eventType.Kind() == reflect.Pointer {
+		eventType = eventType.Elem()
+	}
+
+	return eventType.PkgPath() + "." + eventType.Name()
+}
+
+func (j jsonCodec) Marshal(event interface{}) (string, []byte, error) {
+	eventType := reflect.TypeOf(event)
+	if eventType == nil {
+		return "", nil, fmt.Errorf("%w: <nil>", ErrEventTypeNotRegistered)
+	}
+
+	name := eventTypeName(eventType)
+	if _, ok := j.types[name]; !ok {
+		return "", nil, fmt.Errorf("%w: %s", ErrEventTypeNotRegistered, name)
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return name, payload, nil
+}
+
+func (j jsonCodec) Unmarshal(eventType string, payload []byte) (interface{}, error) {
+	t, ok := j.types[eventType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrEventTypeNotRegistered, eventType)
+	}
+
+	isPointer := t.Kind() == reflect.Pointer
+	if isPointer {
+		t = t.Elem()
+	}
+
+	event := reflect.New(t)
+	if err := json.Unmarshal(payload, event.Interface()); err != nil {
+		return nil, err
+	}
+
+	if isPointer {
+		return event.Interface(), nil
+	}
+
+	return event.Elem().Interface(), nil
+}
@@ -0,0 +1,68 @@
+package outbox_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/outbox"
+)
+
+type (
+	OrderPlaced struct {
+		OrderID string
+	}
+	OrderCancelled struct {
+		OrderID string
+	}
+	Unregistered struct{}
+)
+
+func Test_jsonCodec(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		event     interface{}
+		eventType string
+		payload   string
+		wantErr   error
+	}{
+		{
+			name:    "unregistered event",
+			event:   Unregistered{},
+			wantErr: outbox.ErrEventTypeNotRegistered,
+		},
+		{
+			name:      "value event",
+			event:     OrderPlaced{OrderID: "order-id"},
+			eventType: "github.com/vulpes-ferrilata/cqrs/pkg/outbox_test.OrderPlaced",
+			payload:   `{"OrderID":"order-id"}`,
+			wantErr:   nil,
+		},
+		{
+			name:      "pointer event",
+			event:     &OrderCancelled{OrderID: "order-id"},
+			eventType: "github.com/vulpes-ferrilata/cqrs/pkg/outbox_test.OrderCancelled",
+			payload:   `{"OrderID":"order-id"}`,
+			wantErr:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec := outbox.NewJSONCodec(OrderPlaced{}, &OrderCancelled{})
+
+			eventType, payload, err := codec.Marshal(tt.event)
+			assert.ErrorIs(t, err, tt.wantErr)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.eventType, eventType)
+			assert.JSONEq(t, tt.payload, string(payload))
+
+			event, err := codec.Unmarshal(eventType, payload)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.event, event)
+		})
+	}
+}
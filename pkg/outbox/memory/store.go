@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+	db_memory "github.com/vulpes-ferrilata/cqrs/pkg/db/memory"
+	"github.com/vulpes-ferrilata/cqrs/pkg/outbox"
+)
+
+const collection = "outbox_messages"
+
+type outboxMessage struct {
+	message outbox.Message
+	sent    bool
+}
+
+func NewStore(transactionManager db.TransactionManager[*db_memory.Tx]) outbox.Store {
+	return &store{
+		transactionManager: transactionManager,
+	}
+}
+
+type store struct {
+	transactionManager db.TransactionManager[*db_memory.Tx]
+}
+
+func (s store) Save(ctx context.Context, messages ...outbox.Message) error {
+	transaction := s.transactionManager.GetTransaction(ctx)
+
+	for _, message := range messages {
+		if err := transaction.Put(collection, message.ID, outboxMessage{message: message}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s store) FetchPending(ctx context.Context, limit int) ([]outbox.Message, error) {
+	documents, err := s.transactionManager.GetTransaction(ctx).Find(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]outbox.Message, 0)
+	for _, document := range documents {
+		if len(messages) >= limit {
+			break
+		}
+
+		outboxMessage := document.(outboxMessage)
+		if outboxMessage.sent {
+			continue
+		}
+
+		messages = append(messages, outboxMessage.message)
+	}
+
+	return messages, nil
+}
+
+func (s store) MarkSent(ctx context.Context, ids ...string) error {
+	transaction := s.transactionManager.GetTransaction(ctx)
+
+	for _, id := range ids {
+		document, ok, err := transaction.Get(collection, id)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		outboxMessage := document.(outboxMessage)
+		outboxMessage.sent = true
+
+		if err := transaction.Put(collection, id, outboxMessage); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
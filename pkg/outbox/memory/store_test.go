@@ -0,0 +1,67 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	db_memory "github.com/vulpes-ferrilata/cqrs/pkg/db/memory"
+	"github.com/vulpes-ferrilata/cqrs/pkg/outbox"
+	outbox_memory "github.com/vulpes-ferrilata/cqrs/pkg/outbox/memory"
+)
+
+func Test_store(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx        = context.Background()
+		occurredAt = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+		messages   = []outbox.Message{
+			{ID: "1", EventType: "event", Payload: []byte("{}"), CorrelationID: "correlation-id", OccurredAt: occurredAt},
+			{ID: "2", EventType: "event", Payload: []byte("{}"), OccurredAt: occurredAt},
+			{ID: "3", EventType: "event", Payload: []byte("{}"), OccurredAt: occurredAt},
+		}
+	)
+
+	transactionManager := db_memory.NewTransactionManager(db_memory.NewStore())
+	store := outbox_memory.NewStore(transactionManager)
+
+	committer, txCtx, err := transactionManager.StartTransaction(ctx)
+	assert.NoError(t, err)
+	err = store.Save(txCtx, messages[2], messages[0])
+	assert.NoError(t, err)
+	err = committer.RollbackTransaction(txCtx)
+	assert.NoError(t, err)
+
+	pending, err := store.FetchPending(ctx, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+
+	committer, txCtx, err = transactionManager.StartTransaction(ctx)
+	assert.NoError(t, err)
+	err = store.Save(txCtx, messages[2], messages[0], messages[1])
+	assert.NoError(t, err)
+	err = committer.CommitTransaction(txCtx)
+	assert.NoError(t, err)
+
+	pending, err = store.FetchPending(ctx, 2)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 2)
+	for i, message := range pending {
+		assert.Equal(t, messages[i].ID, message.ID)
+		assert.Equal(t, messages[i].EventType, message.EventType)
+		assert.Equal(t, messages[i].Payload, message.Payload)
+		assert.Equal(t, messages[i].CorrelationID, message.CorrelationID)
+		assert.True(t, messages[i].OccurredAt.Equal(message.OccurredAt))
+	}
+
+	err = store.MarkSent(ctx, "1", "2")
+	assert.NoError(t, err)
+
+	pending, err = store.FetchPending(ctx, 10)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, "3", pending[0].ID)
+}
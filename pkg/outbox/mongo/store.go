@@ -0,0 +1,104 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+	"github.com/vulpes-ferrilata/cqrs/pkg/outbox"
+)
+
+const collectionName = "outbox_messages"
+
+type message struct {
+	ID            string     `bson:"_id"`
+	EventType     string     `bson:"event_type"`
+	Payload       []byte     `bson:"payload"`
+	CorrelationID string     `bson:"correlation_id,omitempty"`
+	OccurredAt    time.Time  `bson:"occurred_at"`
+	SentAt        *time.Time `bson:"sent_at"`
+}
+
+func NewStore(transactionManager db.TransactionManager[*mongo.Database]) outbox.Store {
+	return &store{
+		transactionManager: transactionManager,
+	}
+}
+
+type store struct {
+	transactionManager db.TransactionManager[*mongo.Database]
+}
+
+func (s store) collection(ctx context.Context) *mongo.Collection {
+	return s.transactionManager.GetTransaction(ctx).Collection(collectionName)
+}
+
+func (s store) Save(ctx context.Context, messages ...outbox.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, 0, len(messages))
+	for _, outboxMessage := range messages {
+		documents = append(documents, message{
+			ID:            outboxMessage.ID,
+			EventType:     outboxMessage.EventType,
+			Payload:       outboxMessage.Payload,
+			CorrelationID: outboxMessage.CorrelationID,
+			OccurredAt:    outboxMessage.OccurredAt,
+		})
+	}
+
+	if _, err := s.collection(ctx).InsertMany(ctx, documents); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s store) FetchPending(ctx context.Context, limit int) ([]outbox.Message, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := s.collection(ctx).Find(ctx, bson.M{"sent_at": nil}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	documents := make([]message, 0)
+	if err := cursor.All(ctx, &documents); err != nil {
+		return nil, err
+	}
+
+	messages := make([]outbox.Message, 0, len(documents))
+	for _, document := range documents {
+		messages = append(messages, outbox.Message{
+			ID:            document.ID,
+			EventType:     document.EventType,
+			Payload:       document.Payload,
+			CorrelationID: document.CorrelationID,
+			OccurredAt:    document.OccurredAt,
+		})
+	}
+
+	return messages, nil
+}
+
+func (s store) MarkSent(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	filter := bson.M{"_id": bson.M{"$in": ids}}
+	update := bson.M{"$set": bson.M{"sent_at": time.Now()}}
+	if _, err := s.collection(ctx).UpdateMany(ctx, filter, update); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package mongo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+
+	db_mongo "github.com/vulpes-ferrilata/cqrs/pkg/db/mongo"
+	"github.com/vulpes-ferrilata/cqrs/pkg/outbox"
+	outbox_mongo "github.com/vulpes-ferrilata/cqrs/pkg/outbox/mongo"
+)
+
+func Test_store_Save(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx      = context.Background()
+		messages = []outbox.Message{
+			{ID: "1", EventType: "event", Payload: []byte("{}"), OccurredAt: time.Now()},
+		}
+	)
+
+	tests := []struct {
+		name      string
+		messages  []outbox.Message
+		responses []bson.D
+		wantErr   bool
+	}{
+		{
+			name:      "no messages",
+			messages:  []outbox.Message{},
+			responses: []bson.D{},
+			wantErr:   false,
+		},
+		{
+			name:     "insert fail",
+			messages: messages,
+			responses: []bson.D{
+				mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "duplicate key error"}),
+			},
+			wantErr: true,
+		},
+		{
+			name:     "success",
+			messages: messages,
+			responses: []bson.D{
+				mtest.CreateSuccessResponse(),
+			},
+			wantErr: false,
+		},
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			mt.AddMockResponses(tt.responses...)
+
+			store := outbox_mongo.NewStore(db_mongo.NewTransactionManager(mt.DB, nil, nil))
+			err := store.Save(ctx, tt.messages...)
+			assert.Equal(mt, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func Test_store_FetchPending(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx        = context.Background()
+		occurredAt = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	)
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.outbox_messages", mtest.FirstBatch,
+			bson.D{
+				{Key: "_id", Value: "1"},
+				{Key: "event_type", Value: "event"},
+				{Key: "payload", Value: []byte("{}")},
+				{Key: "correlation_id", Value: "correlation-id"},
+				{Key: "occurred_at", Value: occurredAt},
+			},
+		))
+
+		store := outbox_mongo.NewStore(db_mongo.NewTransactionManager(mt.DB, nil, nil))
+		messages, err := store.FetchPending(ctx, 10)
+		assert.NoError(mt, err)
+		assert.Equal(mt, []outbox.Message{
+			{ID: "1", EventType: "event", Payload: []byte("{}"), CorrelationID: "correlation-id", OccurredAt: occurredAt},
+		}, messages)
+
+		started := mt.GetStartedEvent()
+		assert.Equal(mt, "find", started.CommandName)
+		assert.Equal(mt, int64(10), started.Command.Lookup("limit").Int64())
+	})
+}
+
+func Test_store_MarkSent(t *testing.T) {
+	t.Parallel()
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run("success", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}, bson.E{Key: "nModified", Value: 2}))
+
+		store := outbox_mongo.NewStore(db_mongo.NewTransactionManager(mt.DB, nil, nil))
+		err := store.MarkSent(context.Background(), "1", "2")
+		assert.NoError(mt, err)
+
+		started := mt.GetStartedEvent()
+		assert.Equal(mt, "update", started.CommandName)
+	})
+}
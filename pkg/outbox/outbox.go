@@ -0,0 +1,32 @@
+package outbox
+
+//go:generate mockgen -destination=./mocks/mock_$GOFILE -source=$GOFILE -package=mock_$GOPACKAGE
+import (
+	"context"
+	"time"
+)
+
+// Message IDs are time ordered.
+type Message struct {
+	ID            string
+	EventType     string
+	Payload       []byte
+	CorrelationID string
+	OccurredAt    time.Time
+}
+
+// Store implementations must save messages through the transaction of the context.
+type Store interface {
+	Save(ctx context.Context, messages ...Message) error
+	FetchPending(ctx context.Context, limit int) ([]Message, error)
+	MarkSent(ctx context.Context, ids ...string) error
+}
+
+type Publisher interface {
+	Publish(ctx context.Context, message Message) error
+}
+
+type Codec interface {
+	Marshal(event interface{}) (string, []byte, error)
+	Unmarshal(eventType string, payload []byte) (interface{}, error)
+}
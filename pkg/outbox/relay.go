@@ -0,0 +1,201 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type DeadLetterError struct {
+	Message  Message
+	Attempts int
+	Err      error
+}
+
+func (d DeadLetterError) Error() string {
+	return fmt.Sprintf("outbox message %s of %s skipped after %d attempts: %v", d.Message.ID, d.Message.EventType, d.Attempts, d.Err)
+}
+
+func (d DeadLetterError) Unwrap() error {
+	return d.Err
+}
+
+type RelayOption func(r *Relay)
+
+func WithRelayInterval(interval time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.interval = interval
+	}
+}
+
+func WithRelayBatchSize(batchSize int) RelayOption {
+	return func(r *Relay) {
+		r.batchSize = batchSize
+	}
+}
+
+func WithRelayErrorHandler(errorHandler func(err error)) RelayOption {
+	return func(r *Relay) {
+		r.errorHandler = errorHandler
+	}
+}
+
+// WithRelayMaxAttempts skips messages after maxAttempts failed publishes, 10 by default. Skipped messages are
+// reported to the error handler as a DeadLetterError. A non-positive maxAttempts retries messages forever, a failing
+// message then blocks the messages behind it.
+func WithRelayMaxAttempts(maxAttempts int) RelayOption {
+	return func(r *Relay) {
+		r.maxAttempts = maxAttempts
+	}
+}
+
+// WithRelayDeadLetter receives the messages exceeding the max attempts before they are skipped, they are kept if it returns an error.
+func WithRelayDeadLetter(deadLetter func(ctx context.Context, message Message, err error) error) RelayOption {
+	return func(r *Relay) {
+		r.deadLetter = deadLetter
+	}
+}
+
+func NewRelay(store Store, publisher Publisher, opts ...RelayOption) *Relay {
+	relay := &Relay{
+		store:        store,
+		publisher:    publisher,
+		interval:     time.Second,
+		batchSize:    100,
+		maxAttempts:  10,
+		errorHandler: func(err error) {},
+		failures: &failures{
+			attempts: make(map[string]int),
+		},
+	}
+
+	for _, opt := range opts {
+		opt(relay)
+	}
+
+	return relay
+}
+
+// Relay publishes messages in order at least once, attempts are counted in memory.
+// Relays polling the same outbox concurrently publish duplicates.
+type Relay struct {
+	store        Store
+	publisher    Publisher
+	interval     time.Duration
+	batchSize    int
+	maxAttempts  int
+	errorHandler func(err error)
+	deadLetter   func(ctx context.Context, message Message, err error) error
+	failures     *failures
+}
+
+type failures struct {
+	mu       sync.Mutex
+	attempts map[string]int
+}
+
+func (f *failures) add(id string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.attempts[id]++
+
+	return f.attempts[id]
+}
+
+func (f *failures) reset(id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.attempts, id)
+}
+
+func (r Relay) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			count, err := r.RelayPending(ctx)
+			if err != nil {
+				r.errorHandler(err)
+			}
+
+			if err != nil || count < r.batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r Relay) RelayPending(ctx context.Context) (int, error) {
+	messages, err := r.store.FetchPending(ctx, r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, message := range messages {
+		if err := r.publish(ctx, message); err != nil {
+			if markErr := r.markSent(ctx, messages[:i]); markErr != nil {
+				return i, markErr
+			}
+
+			return i, err
+		}
+	}
+
+	if err := r.markSent(ctx, messages); err != nil {
+		return 0, err
+	}
+
+	return len(messages), nil
+}
+
+func (r Relay) publish(ctx context.Context, message Message) error {
+	err := r.publisher.Publish(ctx, message)
+	if err == nil {
+		r.failures.reset(message.ID)
+
+		return nil
+	}
+
+	attempts := r.failures.add(message.ID)
+	if r.maxAttempts <= 0 || attempts < r.maxAttempts {
+		return err
+	}
+
+	if r.deadLetter != nil {
+		if err := r.deadLetter(ctx, message, err); err != nil {
+			return err
+		}
+	}
+
+	r.failures.reset(message.ID)
+	r.errorHandler(DeadLetterError{
+		Message:  message,
+		Attempts: attempts,
+		Err:      err,
+	})
+
+	return nil
+}
+
+func (r Relay) markSent(ctx context.Context, messages []Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(messages))
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+	}
+
+	return r.store.MarkSent(ctx, ids...)
+}
@@ -0,0 +1,387 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/outbox"
+	mock_outbox "github.com/vulpes-ferrilata/cqrs/pkg/outbox/mocks"
+)
+
+func TestRelay_RelayPending(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx      = context.Background()
+		messages = []outbox.Message{
+			{ID: "1"},
+			{ID: "2"},
+			{ID: "3"},
+		}
+		Err = errors.New("error")
+	)
+
+	type mocks struct {
+		store     *mock_outbox.MockStore
+		publisher *mock_outbox.MockPublisher
+	}
+	type wants struct {
+		count int
+		err   error
+	}
+	tests := []struct {
+		name    string
+		prepare func(mocks mocks)
+		wants   wants
+	}{
+		{
+			name: "fetch pending fail",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().FetchPending(ctx, 10).Return(nil, Err)
+			},
+			wants: wants{
+				count: 0,
+				err:   Err,
+			},
+		},
+		{
+			name: "no pending messages",
+			prepare: func(mocks mocks) {
+				mocks.store.EXPECT().FetchPending(ctx, 10).Return([]outbox.Message{}, nil)
+			},
+			wants: wants{
+				count: 0,
+				err:   nil,
+			},
+		},
+		{
+			name: "publish fail marks published messages only",
+			prepare: func(mocks mocks) {
+				gomock.InOrder(
+					mocks.store.EXPECT().FetchPending(ctx, 10).Return(messages, nil),
+					mocks.publisher.EXPECT().Publish(ctx, messages[0]).Return(nil),
+					mocks.publisher.EXPECT().Publish(ctx, messages[1]).Return(Err),
+					mocks.store.EXPECT().MarkSent(ctx, "1").Return(nil),
+				)
+			},
+			wants: wants{
+				count: 1,
+				err:   Err,
+			},
+		},
+		{
+			name: "first publish fail",
+			prepare: func(mocks mocks) {
+				gomock.InOrder(
+					mocks.store.EXPECT().FetchPending(ctx, 10).Return(messages, nil),
+					mocks.publisher.EXPECT().Publish(ctx, messages[0]).Return(Err),
+				)
+			},
+			wants: wants{
+				count: 0,
+				err:   Err,
+			},
+		},
+		{
+			name: "mark sent fail",
+			prepare: func(mocks mocks) {
+				gomock.InOrder(
+					mocks.store.EXPECT().FetchPending(ctx, 10).Return(messages, nil),
+					mocks.publisher.EXPECT().Publish(ctx, messages[0]).Return(nil),
+					mocks.publisher.EXPECT().Publish(ctx, messages[1]).Return(nil),
+					mocks.publisher.EXPECT().Publish(ctx, messages[2]).Return(nil),
+					mocks.store.EXPECT().MarkSent(ctx, "1", "2", "3").Return(Err),
+				)
+			},
+			wants: wants{
+				count: 0,
+				err:   Err,
+			},
+		},
+		{
+			name: "success",
+			prepare: func(mocks mocks) {
+				gomock.InOrder(
+					mocks.store.EXPECT().FetchPending(ctx, 10).Return(messages, nil),
+					mocks.publisher.EXPECT().Publish(ctx, messages[0]).Return(nil),
+					mocks.publisher.EXPECT().Publish(ctx, messages[1]).Return(nil),
+					mocks.publisher.EXPECT().Publish(ctx, messages[2]).Return(nil),
+					mocks.store.EXPECT().MarkSent(ctx, "1", "2", "3").Return(nil),
+				)
+			},
+			wants: wants{
+				count: 3,
+				err:   nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := mocks{
+				store:     mock_outbox.NewMockStore(mockCtrl),
+				publisher: mock_outbox.NewMockPublisher(mockCtrl),
+			}
+
+			tt.prepare(mocks)
+
+			relay := outbox.NewRelay(mocks.store, mocks.publisher, outbox.WithRelayBatchSize(10))
+			count, err := relay.RelayPending(ctx)
+			assert.ErrorIs(t, err, tt.wants.err)
+			assert.Equal(t, tt.wants.count, count)
+		})
+	}
+}
+
+func TestRelay_Run(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := mock_outbox.NewMockStore(mockCtrl)
+	publisher := mock_outbox.NewMockPublisher(mockCtrl)
+
+	gomock.InOrder(
+		store.EXPECT().FetchPending(gomock.Any(), 1).Return([]outbox.Message{{ID: "1"}}, nil),
+		publisher.EXPECT().Publish(gomock.Any(), outbox.Message{ID: "1"}).Return(nil),
+		store.EXPECT().MarkSent(gomock.Any(), "1").Return(nil),
+		store.EXPECT().FetchPending(gomock.Any(), 1).DoAndReturn(func(ctx context.Context, limit int) ([]outbox.Message, error) {
+			cancel()
+			return []outbox.Message{}, nil
+		}),
+	)
+
+	relay := outbox.NewRelay(store, publisher, outbox.WithRelayBatchSize(1))
+	err := relay.Run(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRelay_RelayPending_DeadLetter(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx      = context.Background()
+		messages = []outbox.Message{
+			{ID: "1"},
+			{ID: "2"},
+		}
+		Err = errors.New("error")
+	)
+
+	type wants struct {
+		count      int
+		err        error
+		deadLetter bool
+	}
+	tests := []struct {
+		name          string
+		deadLetterErr error
+		prepare       func(store *mock_outbox.MockStore, publisher *mock_outbox.MockPublisher)
+		wants         wants
+	}{
+		{
+			name:          "dead letter fail keeps message",
+			deadLetterErr: Err,
+			prepare: func(store *mock_outbox.MockStore, publisher *mock_outbox.MockPublisher) {
+				gomock.InOrder(
+					store.EXPECT().FetchPending(ctx, 10).Return(messages, nil),
+					publisher.EXPECT().Publish(ctx, messages[0]).Return(Err),
+				)
+			},
+			wants: wants{
+				count:      0,
+				err:        Err,
+				deadLetter: false,
+			},
+		},
+		{
+			name:          "message skipped after max attempts",
+			deadLetterErr: nil,
+			prepare: func(store *mock_outbox.MockStore, publisher *mock_outbox.MockPublisher) {
+				gomock.InOrder(
+					store.EXPECT().FetchPending(ctx, 10).Return(messages, nil),
+					publisher.EXPECT().Publish(ctx, messages[0]).Return(Err),
+					publisher.EXPECT().Publish(ctx, messages[1]).Return(nil),
+					store.EXPECT().MarkSent(ctx, "1", "2").Return(nil),
+				)
+			},
+			wants: wants{
+				count:      2,
+				err:        nil,
+				deadLetter: true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			store := mock_outbox.NewMockStore(mockCtrl)
+			publisher := mock_outbox.NewMockPublisher(mockCtrl)
+
+			errs := make([]error, 0)
+			deadLetters := make([]outbox.Message, 0)
+
+			relay := outbox.NewRelay(store, publisher,
+				outbox.WithRelayBatchSize(10),
+				outbox.WithRelayMaxAttempts(2),
+				outbox.WithRelayErrorHandler(func(err error) {
+					errs = append(errs, err)
+				}),
+				outbox.WithRelayDeadLetter(func(ctx context.Context, message outbox.Message, err error) error {
+					assert.ErrorIs(t, err, Err)
+					deadLetters = append(deadLetters, message)
+					return tt.deadLetterErr
+				}),
+			)
+
+			// the first attempt fails below the max attempts.
+			gomock.InOrder(
+				store.EXPECT().FetchPending(ctx, 10).Return(messages, nil),
+				publisher.EXPECT().Publish(ctx, messages[0]).Return(Err),
+			)
+			count, err := relay.RelayPending(ctx)
+			assert.ErrorIs(t, err, Err)
+			assert.Equal(t, 0, count)
+			assert.Empty(t, deadLetters)
+
+			tt.prepare(store, publisher)
+
+			count, err = relay.RelayPending(ctx)
+			assert.ErrorIs(t, err, tt.wants.err)
+			assert.Equal(t, tt.wants.count, count)
+			assert.Equal(t, []outbox.Message{messages[0]}, deadLetters)
+
+			if tt.wants.deadLetter {
+				assert.Equal(t, []error{outbox.DeadLetterError{Message: messages[0], Attempts: 2, Err: Err}}, errs)
+			} else {
+				assert.Empty(t, errs)
+			}
+		})
+	}
+}
+
+func TestRelay_RelayPending_WithoutDeadLetter(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx      = context.Background()
+		messages = []outbox.Message{
+			{ID: "1"},
+			{ID: "2"},
+		}
+		Err = errors.New("error")
+	)
+
+	tests := []struct {
+		name        string
+		opts        []outbox.RelayOption
+		maxAttempts int
+	}{
+		{
+			name:        "default max attempts",
+			opts:        []outbox.RelayOption{},
+			maxAttempts: 10,
+		},
+		{
+			name: "max attempts",
+			opts: []outbox.RelayOption{
+				outbox.WithRelayMaxAttempts(2),
+			},
+			maxAttempts: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			store := mock_outbox.NewMockStore(mockCtrl)
+			publisher := mock_outbox.NewMockPublisher(mockCtrl)
+
+			errs := make([]error, 0)
+
+			relay := outbox.NewRelay(store, publisher, append(tt.opts,
+				outbox.WithRelayBatchSize(10),
+				outbox.WithRelayErrorHandler(func(err error) {
+					errs = append(errs, err)
+				}),
+			)...)
+
+			// the message is kept in the outbox below the max attempts.
+			for i := 1; i < tt.maxAttempts; i++ {
+				gomock.InOrder(
+					store.EXPECT().FetchPending(ctx, 10).Return(messages, nil),
+					publisher.EXPECT().Publish(ctx, messages[0]).Return(Err),
+				)
+
+				count, err := relay.RelayPending(ctx)
+				assert.ErrorIs(t, err, Err)
+				assert.Equal(t, 0, count)
+			}
+
+			// the message is skipped once it reaches them, the messages behind it are published.
+			gomock.InOrder(
+				store.EXPECT().FetchPending(ctx, 10).Return(messages, nil),
+				publisher.EXPECT().Publish(ctx, messages[0]).Return(Err),
+				publisher.EXPECT().Publish(ctx, messages[1]).Return(nil),
+				store.EXPECT().MarkSent(ctx, "1", "2").Return(nil),
+			)
+
+			count, err := relay.RelayPending(ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, 2, count)
+			assert.Equal(t, []error{outbox.DeadLetterError{Message: messages[0], Attempts: tt.maxAttempts, Err: Err}}, errs)
+		})
+	}
+}
+
+func TestRelay_RelayPending_UnlimitedAttempts(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx      = context.Background()
+		messages = []outbox.Message{
+			{ID: "1"},
+		}
+		Err = errors.New("error")
+	)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	store := mock_outbox.NewMockStore(mockCtrl)
+	publisher := mock_outbox.NewMockPublisher(mockCtrl)
+
+	relay := outbox.NewRelay(store, publisher,
+		outbox.WithRelayBatchSize(10),
+		outbox.WithRelayMaxAttempts(0),
+		outbox.WithRelayDeadLetter(func(ctx context.Context, message outbox.Message, err error) error {
+			t.Fatal("unexpected dead letter")
+			return nil
+		}),
+	)
+
+	// the message is kept in the outbox past the default max attempts.
+	for i := 0; i < 11; i++ {
+		gomock.InOrder(
+			store.EXPECT().FetchPending(ctx, 10).Return(messages, nil),
+			publisher.EXPECT().Publish(ctx, messages[0]).Return(Err),
+		)
+
+		count, err := relay.RelayPending(ctx)
+		assert.ErrorIs(t, err, Err)
+		assert.Equal(t, 0, count)
+	}
+}
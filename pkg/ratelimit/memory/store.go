@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/ratelimit"
+)
+
+type Option func(s *store)
+
+// WithSweepInterval sets how often full buckets are evicted.
+func WithSweepInterval(interval time.Duration) Option {
+	return func(s *store) {
+		s.sweepInterval = interval
+	}
+}
+
+func NewStore(opts ...Option) ratelimit.Store {
+	store := &store{
+		sweepInterval: time.Minute,
+		buckets:       make(map[string]*bucket),
+		sweptAt:       time.Now(),
+	}
+
+	for _, opt := range opts {
+		opt(store)
+	}
+
+	return store
+}
+
+type bucket struct {
+	limit     ratelimit.Limit
+	tokens    float64
+	updatedAt time.Time
+}
+
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.updatedAt); elapsed > 0 {
+		b.tokens = math.Min(float64(b.limit.Burst), b.tokens+elapsed.Seconds()*b.limit.Rate)
+		b.updatedAt = now
+	}
+}
+
+type store struct {
+	mu            sync.Mutex
+	sweepInterval time.Duration
+	buckets       map[string]*bucket
+	sweptAt       time.Time
+}
+
+func (s *store) Take(ctx context.Context, key string, limit ratelimit.Limit) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{
+			tokens:    float64(limit.Burst),
+			updatedAt: now,
+		}
+		s.buckets[key] = b
+	}
+
+	b.limit = limit
+	b.refill(now)
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+
+	if limit.Rate <= 0 {
+		return false, time.Duration(math.MaxInt64), nil
+	}
+
+	retryAfter := time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+
+	return false, retryAfter, nil
+}
+
+func (s *store) sweep(now time.Time) {
+	if now.Sub(s.sweptAt) < s.sweepInterval {
+		return
+	}
+
+	s.sweptAt = now
+
+	for key, b := range s.buckets {
+		b.refill(now)
+
+		if b.tokens >= float64(b.limit.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+}
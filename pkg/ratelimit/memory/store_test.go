@@ -0,0 +1,118 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/ratelimit"
+	"github.com/vulpes-ferrilata/cqrs/pkg/ratelimit/memory"
+)
+
+func Test_store_Take(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ctx   = context.Background()
+		limit = ratelimit.Limit{Rate: 10, Burst: 2}
+	)
+
+	type wants struct {
+		allowed    bool
+		retryAfter time.Duration
+	}
+	tests := []struct {
+		name    string
+		prepare func(store ratelimit.Store)
+		key     string
+		wants   wants
+	}{
+		{
+			name:    "initial burst available",
+			prepare: func(store ratelimit.Store) {},
+			key:     "key",
+			wants: wants{
+				allowed:    true,
+				retryAfter: 0,
+			},
+		},
+		{
+			name: "burst exhausted",
+			prepare: func(store ratelimit.Store) {
+				store.Take(ctx, "key", limit)
+				store.Take(ctx, "key", limit)
+			},
+			key: "key",
+			wants: wants{
+				allowed:    false,
+				retryAfter: 100 * time.Millisecond,
+			},
+		},
+		{
+			name: "other key not affected",
+			prepare: func(store ratelimit.Store) {
+				store.Take(ctx, "key", limit)
+				store.Take(ctx, "key", limit)
+			},
+			key: "other-key",
+			wants: wants{
+				allowed:    true,
+				retryAfter: 0,
+			},
+		},
+		{
+			name: "tokens refilled",
+			prepare: func(store ratelimit.Store) {
+				store.Take(ctx, "key", limit)
+				store.Take(ctx, "key", limit)
+				time.Sleep(150 * time.Millisecond)
+			},
+			key: "key",
+			wants: wants{
+				allowed:    true,
+				retryAfter: 0,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := memory.NewStore()
+
+			tt.prepare(store)
+
+			allowed, retryAfter, err := store.Take(ctx, tt.key, limit)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wants.allowed, allowed)
+			assert.InDelta(t, tt.wants.retryAfter, retryAfter, float64(10*time.Millisecond))
+		})
+	}
+}
+
+func Test_store_Take_Sweep(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	store := memory.NewStore(
+		memory.WithSweepInterval(time.Nanosecond),
+	)
+
+	allowed, _, err := store.Take(ctx, "key", ratelimit.Limit{Rate: 1000, Burst: 1})
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+
+	// the bucket refills and is evicted by the next sweep.
+	time.Sleep(10 * time.Millisecond)
+	_, _, err = store.Take(ctx, "other-key", ratelimit.Limit{Rate: 1000, Burst: 1})
+	assert.NoError(t, err)
+
+	// an evicted bucket starts with the full burst of the new limit.
+	limit := ratelimit.Limit{Rate: 0, Burst: 2}
+	for i := 0; i < 2; i++ {
+		allowed, _, err := store.Take(ctx, "key", limit)
+		assert.NoError(t, err)
+		assert.True(t, allowed)
+	}
+}
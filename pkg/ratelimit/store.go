@@ -0,0 +1,16 @@
+package ratelimit
+
+//go:generate mockgen -destination=./mocks/mock_$GOFILE -source=$GOFILE -package=mock_$GOPACKAGE
+import (
+	"context"
+	"time"
+)
+
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (bool, time.Duration, error)
+}
@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/tracing"
+)
+
+func NewTracer() *Tracer {
+	return &Tracer{}
+}
+
+type Tracer struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+func (t *Tracer) Start(ctx context.Context, name string, opts ...tracing.SpanOption) (context.Context, tracing.Span) {
+	config := tracing.NewSpanConfig(opts...)
+
+	span := &Span{
+		Name:       name,
+		Attributes: make(map[string]interface{}),
+	}
+
+	if parent, ok := ctx.Value(spanKey{}).(*Span); ok {
+		span.Parent = parent
+	}
+
+	for _, link := range config.Links {
+		if link, ok := link.(*Span); ok {
+			span.Links = append(span.Links, link)
+		}
+	}
+
+	span.SetAttributes(config.Attributes...)
+
+	t.mu.Lock()
+	t.spans = append(t.spans, span)
+	t.mu.Unlock()
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func (t *Tracer) Spans() []*Span {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	spans := make([]*Span, len(t.spans))
+	copy(spans, t.spans)
+
+	return spans
+}
+
+func (t *Tracer) Span(name string) (*Span, bool) {
+	for _, span := range t.Spans() {
+		if span.Name == name {
+			return span, true
+		}
+	}
+
+	return nil, false
+}
+
+type spanKey struct{}
+
+type Span struct {
+	mu         sync.Mutex
+	Name       string
+	Parent     *Span
+	Links      []*Span
+	Attributes map[string]interface{}
+	Errors     []error
+	Ended      bool
+}
+
+func (s *Span) SetAttributes(attributes ...tracing.Attribute) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, attribute := range attributes {
+		s.Attributes[attribute.Key] = attribute.Value
+	}
+}
+
+func (s *Span) RecordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Errors = append(s.Errors, err)
+}
+
+func (s *Span) End() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Ended = true
+}
@@ -0,0 +1,56 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/tracing"
+	"github.com/vulpes-ferrilata/cqrs/pkg/tracing/memory"
+)
+
+func TestTracer_Start(t *testing.T) {
+	t.Parallel()
+
+	tracer := memory.NewTracer()
+
+	ctx, parent := tracer.Start(context.Background(), "parent")
+	_, link := tracer.Start(context.Background(), "link")
+	_, child := tracer.Start(ctx, "child",
+		tracing.WithLinks(link),
+		tracing.WithAttributes(tracing.String("key", "value"), tracing.Int("count", 1)),
+	)
+
+	span, ok := tracer.Span("child")
+	assert.True(t, ok)
+	assert.Equal(t, child, span)
+	assert.Equal(t, parent, span.Parent)
+	assert.Equal(t, []*memory.Span{link.(*memory.Span)}, span.Links)
+	assert.Equal(t, map[string]interface{}{"key": "value", "count": 1}, span.Attributes)
+
+	assert.Len(t, tracer.Spans(), 3)
+
+	_, ok = tracer.Span("missing")
+	assert.False(t, ok)
+}
+
+func TestSpan(t *testing.T) {
+	t.Parallel()
+
+	Err := errors.New("error")
+
+	tracer := memory.NewTracer()
+
+	_, span := tracer.Start(context.Background(), "span")
+	span.SetAttributes(tracing.String("key", "value"))
+	span.RecordError(Err)
+	span.End()
+
+	recorded, ok := tracer.Span("span")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]interface{}{"key": "value"}, recorded.Attributes)
+	assert.Equal(t, []error{Err}, recorded.Errors)
+	assert.True(t, recorded.Ended)
+}
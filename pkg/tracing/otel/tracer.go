@@ -0,0 +1,90 @@
+package otel
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/tracing"
+)
+
+func NewTracer(tracer trace.Tracer) tracing.Tracer {
+	return &otelTracer{
+		tracer: tracer,
+	}
+}
+
+type otelTracer struct {
+	tracer trace.Tracer
+}
+
+func (o otelTracer) Start(ctx context.Context, name string, opts ...tracing.SpanOption) (context.Context, tracing.Span) {
+	config := tracing.NewSpanConfig(opts...)
+
+	links := make([]trace.Link, 0, len(config.Links))
+	for _, link := range config.Links {
+		if link, ok := link.(*otelSpan); ok {
+			links = append(links, trace.Link{
+				SpanContext: link.span.SpanContext(),
+			})
+		}
+	}
+
+	ctx, span := o.tracer.Start(ctx, name,
+		trace.WithLinks(links...),
+		trace.WithAttributes(toKeyValues(config.Attributes)...),
+	)
+
+	return ctx, &otelSpan{
+		span: span,
+	}
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+func (o otelSpan) SetAttributes(attributes ...tracing.Attribute) {
+	o.span.SetAttributes(toKeyValues(attributes)...)
+}
+
+func (o otelSpan) RecordError(err error) {
+	o.span.RecordError(err)
+	o.span.SetStatus(codes.Error, err.Error())
+}
+
+func (o otelSpan) End() {
+	o.span.End()
+}
+
+func toKeyValues(attributes []tracing.Attribute) []attribute.KeyValue {
+	keyValues := make([]attribute.KeyValue, 0, len(attributes))
+
+	for _, attr := range attributes {
+		keyValues = append(keyValues, toKeyValue(attr))
+	}
+
+	return keyValues
+}
+
+func toKeyValue(attr tracing.Attribute) attribute.KeyValue {
+	switch value := attr.Value.(type) {
+	case string:
+		return attribute.String(attr.Key, value)
+	case bool:
+		return attribute.Bool(attr.Key, value)
+	case int:
+		return attribute.Int(attr.Key, value)
+	case int64:
+		return attribute.Int64(attr.Key, value)
+	case float64:
+		return attribute.Float64(attr.Key, value)
+	case []string:
+		return attribute.StringSlice(attr.Key, value)
+	default:
+		return attribute.String(attr.Key, fmt.Sprint(value))
+	}
+}
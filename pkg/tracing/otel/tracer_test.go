@@ -0,0 +1,57 @@
+package otel_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/tracing"
+	"github.com/vulpes-ferrilata/cqrs/pkg/tracing/otel"
+)
+
+func Test_otelTracer_Start(t *testing.T) {
+	t.Parallel()
+
+	var (
+		Err = errors.New("error")
+	)
+
+	spanRecorder := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder))
+	tracer := otel.NewTracer(tracerProvider.Tracer("test"))
+
+	ctx, parent := tracer.Start(context.Background(), "parent")
+	_, child := tracer.Start(ctx, "child",
+		tracing.WithLinks(parent),
+		tracing.WithAttributes(
+			tracing.String("string", "value"),
+			tracing.Int("int", 1),
+		),
+	)
+	child.SetAttributes(tracing.Attribute{Key: "bool", Value: true})
+	child.RecordError(Err)
+	child.End()
+	parent.End()
+
+	spans := spanRecorder.Ended()
+	assert.Len(t, spans, 2)
+
+	childSpan, parentSpan := spans[0], spans[1]
+	assert.Equal(t, "child", childSpan.Name())
+	assert.Equal(t, parentSpan.SpanContext().SpanID(), childSpan.Parent().SpanID())
+	assert.Len(t, childSpan.Links(), 1)
+	assert.Equal(t, parentSpan.SpanContext(), childSpan.Links()[0].SpanContext)
+	assert.ElementsMatch(t, []attribute.KeyValue{
+		attribute.String("string", "value"),
+		attribute.Int("int", 1),
+		attribute.Bool("bool", true),
+	}, childSpan.Attributes())
+	assert.Equal(t, codes.Error, childSpan.Status().Code)
+	assert.Equal(t, "error", childSpan.Status().Description)
+}
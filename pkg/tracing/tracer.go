@@ -0,0 +1,62 @@
+package tracing
+
+//go:generate mockgen -destination=./mocks/mock_$GOFILE -source=$GOFILE -package=mock_$GOPACKAGE
+import "context"
+
+type Tracer interface {
+	Start(ctx context.Context, name string, opts ...SpanOption) (context.Context, Span)
+}
+
+type Span interface {
+	SetAttributes(attributes ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+func String(key string, value string) Attribute {
+	return Attribute{
+		Key:   key,
+		Value: value,
+	}
+}
+
+func Int(key string, value int) Attribute {
+	return Attribute{
+		Key:   key,
+		Value: value,
+	}
+}
+
+type SpanConfig struct {
+	Links      []Span
+	Attributes []Attribute
+}
+
+type SpanOption func(config *SpanConfig)
+
+func WithLinks(links ...Span) SpanOption {
+	return func(config *SpanConfig) {
+		config.Links = append(config.Links, links...)
+	}
+}
+
+func WithAttributes(attributes ...Attribute) SpanOption {
+	return func(config *SpanConfig) {
+		config.Attributes = append(config.Attributes, attributes...)
+	}
+}
+
+func NewSpanConfig(opts ...SpanOption) SpanConfig {
+	config := SpanConfig{}
+
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return config
+}
@@ -0,0 +1,140 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+)
+
+func NewTransactionManager[DB any](transactionManager db.TransactionManager[DB], tracer Tracer) db.TransactionManager[DB] {
+	tracingTransactionManager := &tracingTransactionManager[DB]{
+		transactionManager: transactionManager,
+		tracer:             tracer,
+	}
+
+	if _, ok := transactionManager.(db.TransactionSuspender); ok {
+		return &tracingTransactionSuspender[DB]{
+			tracingTransactionManager: tracingTransactionManager,
+		}
+	}
+
+	return tracingTransactionManager
+}
+
+type tracingTransactionManager[DB any] struct {
+	transactionManager db.TransactionManager[DB]
+	tracer             Tracer
+}
+
+func (t tracingTransactionManager[DB]) IsTransactionStarted(ctx context.Context) bool {
+	return t.transactionManager.IsTransactionStarted(ctx)
+}
+
+func (t tracingTransactionManager[DB]) start(ctx context.Context,
+	spanName string,
+	startTransaction func(ctx context.Context) (db.Committer, context.Context, error)) (db.Committer, context.Context, error) {
+	_, span := t.tracer.Start(ctx, spanName)
+	defer span.End()
+
+	committer, ctx, err := startTransaction(ctx)
+	if err != nil {
+		span.RecordError(err)
+
+		return nil, ctx, err
+	}
+
+	return newCommitter(committer, t.tracer), ctx, nil
+}
+
+func (t tracingTransactionManager[DB]) StartTransaction(ctx context.Context) (db.Committer, context.Context, error) {
+	return t.start(ctx, "transaction start", t.transactionManager.StartTransaction)
+}
+
+func (t tracingTransactionManager[DB]) StartNestedTransaction(ctx context.Context) (db.Committer, context.Context, error) {
+	nestedTransactionManager, ok := t.transactionManager.(db.NestedTransactionManager)
+	if !ok {
+		return nil, ctx, db.ErrNestedTransactionNotSupported
+	}
+
+	return t.start(ctx, "nested transaction start", nestedTransactionManager.StartNestedTransaction)
+}
+
+func (t tracingTransactionManager[DB]) StartReadOnlyTransaction(ctx context.Context) (db.Committer, context.Context, error) {
+	readOnlyTransactionManager, ok := t.transactionManager.(db.ReadOnlyTransactionManager)
+	if !ok {
+		return t.StartTransaction(ctx)
+	}
+
+	return t.start(ctx, "read-only transaction start", readOnlyTransactionManager.StartReadOnlyTransaction)
+}
+
+func (t tracingTransactionManager[DB]) StartTransactionWithOptions(ctx context.Context, opts interface{}) (db.Committer, context.Context, error) {
+	configurableTransactionManager, ok := t.transactionManager.(db.ConfigurableTransactionManager)
+	if !ok {
+		return nil, ctx, db.ErrTransactionOptionsNotSupported
+	}
+
+	return t.start(ctx, "transaction start", func(ctx context.Context) (db.Committer, context.Context, error) {
+		return configurableTransactionManager.StartTransactionWithOptions(ctx, opts)
+	})
+}
+
+func (t tracingTransactionManager[DB]) RetryTransaction(err error, attempts int, startedAt time.Time) (time.Duration, bool) {
+	transactionRetrier, ok := t.transactionManager.(db.TransactionRetrier)
+	if !ok {
+		return 0, false
+	}
+
+	return transactionRetrier.RetryTransaction(err, attempts, startedAt)
+}
+
+func (t tracingTransactionManager[DB]) GetTransaction(ctx context.Context) DB {
+	return t.transactionManager.GetTransaction(ctx)
+}
+
+type tracingTransactionSuspender[DB any] struct {
+	*tracingTransactionManager[DB]
+}
+
+func (t tracingTransactionSuspender[DB]) SuspendTransaction(ctx context.Context) context.Context {
+	return t.transactionManager.(db.TransactionSuspender).SuspendTransaction(ctx)
+}
+
+func newCommitter(committer db.Committer, tracer Tracer) db.Committer {
+	return &tracingCommitter{
+		committer: committer,
+		tracer:    tracer,
+	}
+}
+
+type tracingCommitter struct {
+	committer db.Committer
+	tracer    Tracer
+}
+
+func (t tracingCommitter) CommitTransaction(ctx context.Context) error {
+	ctx, span := t.tracer.Start(ctx, "transaction commit")
+	defer span.End()
+
+	if err := t.committer.CommitTransaction(ctx); err != nil {
+		span.RecordError(err)
+
+		return err
+	}
+
+	return nil
+}
+
+func (t tracingCommitter) RollbackTransaction(ctx context.Context) error {
+	ctx, span := t.tracer.Start(ctx, "transaction rollback")
+	defer span.End()
+
+	if err := t.committer.RollbackTransaction(ctx); err != nil {
+		span.RecordError(err)
+
+		return err
+	}
+
+	return nil
+}
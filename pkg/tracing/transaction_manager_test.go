@@ -0,0 +1,523 @@
+package tracing_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+
+	"github.com/vulpes-ferrilata/cqrs/internal/dbmocks"
+	"github.com/vulpes-ferrilata/cqrs/pkg/db"
+	mock_db "github.com/vulpes-ferrilata/cqrs/pkg/db/mocks"
+	"github.com/vulpes-ferrilata/cqrs/pkg/tracing"
+	"github.com/vulpes-ferrilata/cqrs/pkg/tracing/memory"
+)
+
+func Test_tracingTransactionManager_StartTransaction(t *testing.T) {
+	t.Parallel()
+
+	var (
+		Err = errors.New("error")
+	)
+
+	type mocks struct {
+		transactionManager *mock_db.MockTransactionManager[*gorm.DB]
+		committer          *mock_db.MockCommitter
+	}
+	type args struct {
+		commit bool
+	}
+	type wants struct {
+		spans map[string][]error
+		err   error
+	}
+	tests := []struct {
+		name    string
+		prepare func(mocks mocks)
+		args    args
+		wants   wants
+	}{
+		{
+			name: "start transaction fail",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().StartTransaction(gomock.Any()).Return(nil, nil, Err)
+			},
+			args: args{
+				commit: true,
+			},
+			wants: wants{
+				spans: map[string][]error{
+					"transaction start": {Err},
+				},
+				err: Err,
+			},
+		},
+		{
+			name: "commit transaction fail",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().StartTransaction(gomock.Any()).Return(mocks.committer, context.Background(), nil)
+				mocks.committer.EXPECT().CommitTransaction(gomock.Any()).Return(Err)
+			},
+			args: args{
+				commit: true,
+			},
+			wants: wants{
+				spans: map[string][]error{
+					"transaction start":  nil,
+					"transaction commit": {Err},
+				},
+				err: Err,
+			},
+		},
+		{
+			name: "commit transaction success",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().StartTransaction(gomock.Any()).Return(mocks.committer, context.Background(), nil)
+				mocks.committer.EXPECT().CommitTransaction(gomock.Any()).Return(nil)
+			},
+			args: args{
+				commit: true,
+			},
+			wants: wants{
+				spans: map[string][]error{
+					"transaction start":  nil,
+					"transaction commit": nil,
+				},
+				err: nil,
+			},
+		},
+		{
+			name: "rollback transaction success",
+			prepare: func(mocks mocks) {
+				mocks.transactionManager.EXPECT().StartTransaction(gomock.Any()).Return(mocks.committer, context.Background(), nil)
+				mocks.committer.EXPECT().RollbackTransaction(gomock.Any()).Return(nil)
+			},
+			args: args{
+				commit: false,
+			},
+			wants: wants{
+				spans: map[string][]error{
+					"transaction start":    nil,
+					"transaction rollback": nil,
+				},
+				err: nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := mocks{
+				transactionManager: mock_db.NewMockTransactionManager[*gorm.DB](mockCtrl),
+				committer:          mock_db.NewMockCommitter(mockCtrl),
+			}
+
+			tt.prepare(mocks)
+
+			tracer := memory.NewTracer()
+			transactionManager := tracing.NewTransactionManager[*gorm.DB](mocks.transactionManager, tracer)
+
+			err := func() error {
+				committer, ctx, err := transactionManager.StartTransaction(context.Background())
+				if err != nil {
+					return err
+				}
+
+				if !tt.args.commit {
+					return committer.RollbackTransaction(ctx)
+				}
+
+				return committer.CommitTransaction(ctx)
+			}()
+			assert.ErrorIs(t, err, tt.wants.err)
+
+			spans := make(map[string][]error)
+			for _, span := range tracer.Spans() {
+				assert.True(t, span.Ended)
+				spans[span.Name] = span.Errors
+			}
+			assert.Equal(t, tt.wants.spans, spans)
+		})
+	}
+}
+
+func spans(t *testing.T, tracer *memory.Tracer) map[string][]error {
+	spans := make(map[string][]error)
+	for _, span := range tracer.Spans() {
+		assert.True(t, span.Ended)
+		spans[span.Name] = span.Errors
+	}
+
+	return spans
+}
+
+func Test_tracingTransactionManager_SuspendTransaction(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		supported bool
+	}
+	type wants struct {
+		supported bool
+	}
+	tests := []struct {
+		name    string
+		prepare func(mocks dbmocks.Mocks)
+		args    args
+		wants   wants
+	}{
+		{
+			name:    "suspension not supported",
+			prepare: func(mocks dbmocks.Mocks) {},
+			args: args{
+				supported: false,
+			},
+			wants: wants{
+				supported: false,
+			},
+		},
+		{
+			name: "suspension supported",
+			prepare: func(mocks dbmocks.Mocks) {
+				mocks.TransactionSuspender.EXPECT().SuspendTransaction(gomock.Any()).Return(context.TODO())
+			},
+			args: args{
+				supported: true,
+			},
+			wants: wants{
+				supported: true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := dbmocks.NewMocks(mockCtrl)
+
+			tt.prepare(mocks)
+
+			transactionManager := tracing.NewTransactionManager[*gorm.DB](mocks.Build(tt.args.supported), memory.NewTracer())
+
+			transactionSuspender, ok := transactionManager.(db.TransactionSuspender)
+			assert.Equal(t, tt.wants.supported, ok)
+			if ok {
+				assert.Equal(t, context.TODO(), transactionSuspender.SuspendTransaction(context.Background()))
+			}
+		})
+	}
+}
+
+func Test_tracingTransactionManager_StartNestedTransaction(t *testing.T) {
+	t.Parallel()
+
+	var (
+		Err = errors.New("error")
+	)
+
+	type args struct {
+		supported bool
+	}
+	type wants struct {
+		spans map[string][]error
+		err   error
+	}
+	tests := []struct {
+		name    string
+		prepare func(mocks dbmocks.Mocks)
+		args    args
+		wants   wants
+	}{
+		{
+			name:    "nested transaction not supported",
+			prepare: func(mocks dbmocks.Mocks) {},
+			args: args{
+				supported: false,
+			},
+			wants: wants{
+				spans: map[string][]error{},
+				err:   db.ErrNestedTransactionNotSupported,
+			},
+		},
+		{
+			name: "start nested transaction fail",
+			prepare: func(mocks dbmocks.Mocks) {
+				mocks.NestedTransactionManager.EXPECT().StartNestedTransaction(gomock.Any()).Return(nil, nil, Err)
+			},
+			args: args{
+				supported: true,
+			},
+			wants: wants{
+				spans: map[string][]error{
+					"nested transaction start": {Err},
+				},
+				err: Err,
+			},
+		},
+		{
+			name: "commit nested transaction success",
+			prepare: func(mocks dbmocks.Mocks) {
+				mocks.NestedTransactionManager.EXPECT().StartNestedTransaction(gomock.Any()).Return(mocks.Committer, context.Background(), nil)
+				mocks.Committer.EXPECT().CommitTransaction(gomock.Any()).Return(nil)
+			},
+			args: args{
+				supported: true,
+			},
+			wants: wants{
+				spans: map[string][]error{
+					"nested transaction start": nil,
+					"transaction commit":       nil,
+				},
+				err: nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := dbmocks.NewMocks(mockCtrl)
+
+			tt.prepare(mocks)
+
+			tracer := memory.NewTracer()
+			transactionManager := tracing.NewTransactionManager[*gorm.DB](mocks.Build(tt.args.supported), tracer)
+
+			err := func() error {
+				committer, ctx, err := transactionManager.(db.NestedTransactionManager).StartNestedTransaction(context.Background())
+				if err != nil {
+					return err
+				}
+
+				return committer.CommitTransaction(ctx)
+			}()
+			assert.ErrorIs(t, err, tt.wants.err)
+			assert.Equal(t, tt.wants.spans, spans(t, tracer))
+		})
+	}
+}
+
+func Test_tracingTransactionManager_RetryTransaction(t *testing.T) {
+	t.Parallel()
+
+	var (
+		Err       = errors.New("error")
+		startedAt = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	)
+
+	type args struct {
+		supported bool
+	}
+	type wants struct {
+		backoff time.Duration
+		retry   bool
+	}
+	tests := []struct {
+		name    string
+		prepare func(mocks dbmocks.Mocks)
+		args    args
+		wants   wants
+	}{
+		{
+			name:    "retry not supported",
+			prepare: func(mocks dbmocks.Mocks) {},
+			args: args{
+				supported: false,
+			},
+			wants: wants{
+				backoff: 0,
+				retry:   false,
+			},
+		},
+		{
+			name: "retry supported",
+			prepare: func(mocks dbmocks.Mocks) {
+				mocks.TransactionRetrier.EXPECT().RetryTransaction(Err, 1, startedAt).Return(time.Second, true)
+			},
+			args: args{
+				supported: true,
+			},
+			wants: wants{
+				backoff: time.Second,
+				retry:   true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := dbmocks.NewMocks(mockCtrl)
+
+			tt.prepare(mocks)
+
+			transactionManager := tracing.NewTransactionManager[*gorm.DB](mocks.Build(tt.args.supported), memory.NewTracer())
+
+			backoff, retry := transactionManager.(db.TransactionRetrier).RetryTransaction(Err, 1, startedAt)
+			assert.Equal(t, tt.wants.backoff, backoff)
+			assert.Equal(t, tt.wants.retry, retry)
+		})
+	}
+}
+
+func Test_tracingTransactionManager_StartReadOnlyTransaction(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		supported bool
+	}
+	type wants struct {
+		spans map[string][]error
+		err   error
+	}
+	tests := []struct {
+		name    string
+		prepare func(mocks dbmocks.Mocks)
+		args    args
+		wants   wants
+	}{
+		{
+			name: "read-only transaction not supported",
+			prepare: func(mocks dbmocks.Mocks) {
+				mocks.TransactionManager.EXPECT().StartTransaction(gomock.Any()).Return(mocks.Committer, context.Background(), nil)
+				mocks.Committer.EXPECT().CommitTransaction(gomock.Any()).Return(nil)
+			},
+			args: args{
+				supported: false,
+			},
+			wants: wants{
+				spans: map[string][]error{
+					"transaction start":  nil,
+					"transaction commit": nil,
+				},
+				err: nil,
+			},
+		},
+		{
+			name: "read-only transaction supported",
+			prepare: func(mocks dbmocks.Mocks) {
+				mocks.ReadOnlyTransactionManager.EXPECT().StartReadOnlyTransaction(gomock.Any()).Return(mocks.Committer, context.Background(), nil)
+				mocks.Committer.EXPECT().CommitTransaction(gomock.Any()).Return(nil)
+			},
+			args: args{
+				supported: true,
+			},
+			wants: wants{
+				spans: map[string][]error{
+					"read-only transaction start": nil,
+					"transaction commit":          nil,
+				},
+				err: nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := dbmocks.NewMocks(mockCtrl)
+
+			tt.prepare(mocks)
+
+			tracer := memory.NewTracer()
+			transactionManager := tracing.NewTransactionManager[*gorm.DB](mocks.Build(tt.args.supported), tracer)
+
+			err := func() error {
+				committer, ctx, err := transactionManager.(db.ReadOnlyTransactionManager).StartReadOnlyTransaction(context.Background())
+				if err != nil {
+					return err
+				}
+
+				return committer.CommitTransaction(ctx)
+			}()
+			assert.ErrorIs(t, err, tt.wants.err)
+			assert.Equal(t, tt.wants.spans, spans(t, tracer))
+		})
+	}
+}
+
+func Test_tracingTransactionManager_StartTransactionWithOptions(t *testing.T) {
+	t.Parallel()
+
+	var (
+		opts = &sql.TxOptions{ReadOnly: true}
+	)
+
+	type args struct {
+		supported bool
+	}
+	type wants struct {
+		spans map[string][]error
+		err   error
+	}
+	tests := []struct {
+		name    string
+		prepare func(mocks dbmocks.Mocks)
+		args    args
+		wants   wants
+	}{
+		{
+			name:    "transaction options not supported",
+			prepare: func(mocks dbmocks.Mocks) {},
+			args: args{
+				supported: false,
+			},
+			wants: wants{
+				spans: map[string][]error{},
+				err:   db.ErrTransactionOptionsNotSupported,
+			},
+		},
+		{
+			name: "transaction options supported",
+			prepare: func(mocks dbmocks.Mocks) {
+				mocks.ConfigurableTransactionManager.EXPECT().StartTransactionWithOptions(gomock.Any(), opts).Return(mocks.Committer, context.Background(), nil)
+				mocks.Committer.EXPECT().CommitTransaction(gomock.Any()).Return(nil)
+			},
+			args: args{
+				supported: true,
+			},
+			wants: wants{
+				spans: map[string][]error{
+					"transaction start":  nil,
+					"transaction commit": nil,
+				},
+				err: nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			mocks := dbmocks.NewMocks(mockCtrl)
+
+			tt.prepare(mocks)
+
+			tracer := memory.NewTracer()
+			transactionManager := tracing.NewTransactionManager[*gorm.DB](mocks.Build(tt.args.supported), tracer)
+
+			err := func() error {
+				committer, ctx, err := transactionManager.(db.ConfigurableTransactionManager).StartTransactionWithOptions(context.Background(), opts)
+				if err != nil {
+					return err
+				}
+
+				return committer.CommitTransaction(ctx)
+			}()
+			assert.ErrorIs(t, err, tt.wants.err)
+			assert.Equal(t, tt.wants.spans, spans(t, tracer))
+		})
+	}
+}
@@ -0,0 +1,5 @@
+package cqrs
+
+type Principal interface {
+	ID() string
+}